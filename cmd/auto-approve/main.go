@@ -0,0 +1,840 @@
+// Package main implements the auto-approve command-line tool.
+// It analyzes open pull requests and approves, auto-merges, or rebases
+// the ones that are safe to auto-approve.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+	"github.com/thegroove/trivial-auto-approve/internal/constants"
+	"github.com/thegroove/trivial-auto-approve/internal/gemini"
+	githubAPI "github.com/thegroove/trivial-auto-approve/internal/github"
+	"github.com/thegroove/trivial-auto-approve/internal/llm"
+	"github.com/thegroove/trivial-auto-approve/internal/processor"
+	"github.com/thegroove/trivial-auto-approve/internal/report"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+	"github.com/thegroove/trivial-auto-approve/internal/state"
+	"github.com/thegroove/trivial-auto-approve/internal/webhook"
+)
+
+// defaultModel is the Gemini model used when --model is not specified.
+// Other providers have no sensible default and require --model.
+const defaultModel = "gemini-2.0-flash"
+
+// anomalyHistorySize is the number of responses kept per repository and model for anomaly detection.
+const anomalyHistorySize = 100
+
+// Webhook server settings.
+const (
+	webhookPath       = "/webhook"
+	webhookQueueSize  = 1000
+	webhookWorkers    = 4
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 30 * time.Second
+
+	// A PR waiting for required checks is analyzed again after deferDelay, at most
+	// maxDeferrals times; check suite and status events usually trigger it sooner.
+	deferDelay   = 2 * time.Minute
+	maxDeferrals = 30
+)
+
+// options holds the parsed command-line options.
+type options struct {
+	pr      string
+	project string
+	org     string
+	serve   string
+
+	webhookSecret string
+
+	poll       time.Duration
+	state      string
+	dryRun     bool
+	autoMerge  bool
+	autoRebase bool
+	debug      bool
+	format     string
+
+	maxFiles       int
+	maxLines       int
+	minOpenTime    time.Duration
+	maxOpenTime    time.Duration
+	allowFirstTime bool
+	allowDraft     bool
+	skipChecks     bool
+
+	provider     string
+	llmURL       string
+	model        string
+	models       string
+	threshold    float64
+	anomalies    string
+	verdicts     string
+	verdictTTL   time.Duration
+	trustedUsers string
+	trustedRoles string
+	bots         string
+
+	codeOwners    string
+	allowedOwners string
+
+	appID          int64
+	appKey         string
+	installationID int64
+}
+
+func main() {
+	opts := parseFlags()
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "auto-approve: %v\n\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, opts); err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("[MAIN] Fatal: %v", err)
+		os.Exit(1)
+	}
+}
+
+// parseFlags defines and parses the command-line flags.
+func parseFlags() *options {
+	opts := &options{}
+
+	flag.StringVar(&opts.pr, "pr", "", "Single PR to analyze (URL or owner/repo#123)")
+	flag.StringVar(&opts.project, "project", "", "Repository to monitor (owner/repo)")
+	flag.StringVar(&opts.org, "org", "", "Organization or user to monitor")
+	flag.StringVar(&opts.serve, "serve", "", "Listen address for webhook server mode (e.g. :8080)")
+	flag.StringVar(&opts.webhookSecret, "webhook-secret", os.Getenv("GITHUB_WEBHOOK_SECRET"), "Webhook secret (default $GITHUB_WEBHOOK_SECRET)")
+
+	flag.DurationVar(&opts.poll, "poll", 0, "Polling interval (0 runs once)")
+	flag.StringVar(&opts.state, "state", defaultCacheFile("state.json"), `File keeping the last analysis of each PR, so unchanged PRs are skipped ("" disables)`)
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "Preview mode only, take no actions")
+	flag.BoolVar(&opts.autoMerge, "auto-merge", false, "Enable auto-merge on approvable PRs")
+	flag.BoolVar(&opts.autoRebase, "auto-rebase", false, "Update PR branches that are behind the base branch")
+	flag.BoolVar(&opts.debug, "debug", false, "Log AI requests and responses")
+	flag.StringVar(&opts.format, "format", string(report.FormatText), "Output format: text, json, ndjson, markdown or sarif")
+
+	flag.IntVar(&opts.maxFiles, "max-files", constants.DefaultMaxFiles, "Maximum number of files changed")
+	flag.IntVar(&opts.maxLines, "max-lines", constants.DefaultMaxLines, "Maximum number of lines changed")
+	flag.DurationVar(&opts.minOpenTime, "min-open-time", constants.DefaultMinOpenTime, "Minimum time since the last push")
+	flag.DurationVar(&opts.maxOpenTime, "max-open-time", constants.DefaultMaxOpenTime, "Maximum time since the last push")
+	flag.BoolVar(&opts.allowFirstTime, "allow-first-time", false, "Allow PRs from first-time contributors")
+	flag.BoolVar(&opts.allowDraft, "allow-draft", false, "Allow draft PRs")
+	flag.BoolVar(&opts.skipChecks, "skip-checks", false, "Do not require CI checks to pass")
+
+	flag.StringVar(&opts.provider, "provider", string(llm.ProviderGemini), "LLM provider: gemini, openai or ollama")
+	flag.StringVar(&opts.llmURL, "llm-url", "", "Base URL of the openai or ollama endpoint (default: the provider's public or local URL)")
+	flag.StringVar(&opts.model, "model", defaultModel, `Model to use ("" disables AI analysis)`)
+	flag.StringVar(&opts.models, "models", "", `Comma-separated models for multi-model consensus (at least 2), optionally prefixed by provider (e.g. "ollama:llama3")`)
+	flag.Float64Var(&opts.threshold, "consensus-threshold", analyzer.DefaultConsensusThreshold, "Fraction of consensus models that must agree a change is safe (1 requires all)")
+	flag.StringVar(&opts.anomalies, "anomaly-history", defaultCacheFile("anomalies.json"), `File keeping model response history for anomaly detection ("" disables)`)
+	flag.StringVar(&opts.verdicts, "verdict-cache", defaultCacheFile("verdicts"), `Directory caching model verdicts, so identical changes are analyzed once ("" disables)`)
+	flag.DurationVar(&opts.verdictTTL, "verdict-cache-ttl", llm.DefaultCacheTTL, "How long a cached model verdict is reused")
+	flag.StringVar(&opts.trustedUsers, "trusted-users", "", "Comma-separated users whose code changes may be approved by AI consensus")
+	flag.StringVar(&opts.trustedRoles, "trusted-roles", "", "Comma-separated repository roles (admin, maintain, write) treated as trusted")
+	flag.StringVar(&opts.bots, "bots", "", "Comma-separated trusted bots as login=profile (dependency-only, lockfile-only or formatting-only), added to the defaults")
+	flag.StringVar(&opts.codeOwners, "code-owners", string(analyzer.CodeOwnersEnforce), "Code owner handling: enforce, required (only where branch protection requires code owner review) or ignore")
+	flag.StringVar(&opts.allowedOwners, "allowed-owners", "", "Comma-separated code owners (@user or @org/team) whose files may be approved")
+
+	flag.Int64Var(&opts.appID, "app-id", 0, "GitHub App ID")
+	flag.StringVar(&opts.appKey, "app-key", "", "Path to the GitHub App private key")
+	flag.Int64Var(&opts.installationID, "installation-id", 0, "GitHub App installation ID (auto-detected if 0)")
+
+	flag.Parse()
+	return opts
+}
+
+// validate checks that the options are consistent.
+func (o *options) validate() error {
+	modes := 0
+	for _, v := range []string{o.pr, o.project, o.org, o.serve} {
+		if v != "" {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return fmt.Errorf("exactly one of --pr, --project, --org or --serve is required")
+	}
+
+	if o.serve != "" {
+		if o.webhookSecret == "" {
+			return fmt.Errorf("--serve requires --webhook-secret or $GITHUB_WEBHOOK_SECRET")
+		}
+		if o.poll > 0 {
+			return fmt.Errorf("--poll cannot be used with --serve")
+		}
+	}
+
+	format, err := report.ParseFormat(o.format)
+	if err != nil {
+		return fmt.Errorf("--format: %w", err)
+	}
+	if o.serve != "" && !format.Streaming() {
+		return fmt.Errorf("--format %s cannot be used with --serve (use text or ndjson)", format)
+	}
+
+	provider, err := llm.ParseProvider(o.provider)
+	if err != nil {
+		return fmt.Errorf("--provider: %w", err)
+	}
+	if provider != llm.ProviderGemini {
+		if o.model == defaultModel {
+			return fmt.Errorf("--provider %s requires --model", provider)
+		}
+	}
+	if o.models != "" {
+		if len(splitList(o.models)) < 2 {
+			return fmt.Errorf("--models requires at least 2 models")
+		}
+		if o.threshold <= 0 || o.threshold > 1 {
+			return fmt.Errorf("--consensus-threshold must be greater than 0 and at most 1")
+		}
+	}
+	if o.llmURL != "" && provider == llm.ProviderGemini {
+		return fmt.Errorf("--llm-url cannot be used with --provider gemini")
+	}
+
+	if o.project != "" {
+		if _, _, err := parseProject(o.project); err != nil {
+			return err
+		}
+	}
+
+	if _, err := o.botRegistry(); err != nil {
+		return fmt.Errorf("--bots: %w", err)
+	}
+	switch analyzer.CodeOwnersMode(o.codeOwners) {
+	case "", analyzer.CodeOwnersEnforce, analyzer.CodeOwnersRequired, analyzer.CodeOwnersIgnore:
+	default:
+		return fmt.Errorf("--code-owners must be enforce, required or ignore")
+	}
+
+	if o.poll < 0 {
+		return fmt.Errorf("--poll must not be negative")
+	}
+	if o.poll > 0 && o.pr != "" {
+		return fmt.Errorf("--poll cannot be used with --pr")
+	}
+
+	if (o.appID != 0) != (o.appKey != "") {
+		return fmt.Errorf("--app-id and --app-key must be used together")
+	}
+	if o.installationID != 0 && o.appID == 0 {
+		return fmt.Errorf("--installation-id requires --app-id and --app-key")
+	}
+
+	return nil
+}
+
+// analyzerConfig builds the analyzer configuration from the options.
+func (o *options) analyzerConfig() *analyzer.Config {
+	config := analyzer.DefaultConfig()
+	config.MaxFiles = o.maxFiles
+	config.MaxLines = o.maxLines
+	config.MinOpenTime = o.minOpenTime
+	config.MaxOpenTime = o.maxOpenTime
+	config.SkipFirstTime = !o.allowFirstTime
+	config.SkipDraft = !o.allowDraft
+	config.RequirePassingChecks = !o.skipChecks
+	config.UseAI = o.model != ""
+	config.TrustedUsers = splitList(o.trustedUsers)
+	config.TrustedRoles = splitList(o.trustedRoles)
+	config.Bots, _ = o.botRegistry() // Checked by validate
+	config.CodeOwners = analyzer.CodeOwnersMode(o.codeOwners)
+	config.AllowedOwners = splitList(o.allowedOwners)
+	config.DryRun = o.dryRun
+
+	if models := splitList(o.models); len(models) > 0 {
+		config.UseMultiModel = true
+		config.Models = models
+		config.ConsensusThreshold = o.threshold
+	}
+
+	return config
+}
+
+// botRegistry returns the default bots merged with those given by --bots.
+func (o *options) botRegistry() ([]analyzer.Bot, error) {
+	var bots []analyzer.Bot
+	for _, spec := range splitList(o.bots) {
+		bot, err := analyzer.ParseBot(spec)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	return analyzer.MergeBots(analyzer.DefaultBots, bots), nil
+}
+
+// run creates the clients and processes PRs once or on every poll interval.
+func run(ctx context.Context, opts *options) error {
+	gh, err := newGitHubClient(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	var verdicts *llm.Cache
+	if opts.verdicts != "" {
+		verdicts, err = llm.NewCache(opts.verdicts, opts.verdictTTL)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Printf("[MAIN] Verdict cache disabled (--verdict-cache is empty)")
+	}
+
+	var aiClient llm.Analyzer
+	if opts.model != "" {
+		client, err := newAIClient(ctx, opts, verdicts)
+		if err != nil {
+			return fmt.Errorf("creating %s client: %w", opts.provider, err)
+		}
+		defer func() { _ = client.Close() }()
+		aiClient = client
+	} else {
+		log.Printf("[MAIN] AI analysis disabled (--model is empty)")
+	}
+
+	consensus, err := newConsensusModels(ctx, opts, verdicts)
+	defer func() {
+		for _, m := range consensus {
+			_ = m.Client.Close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	var anomalies *security.AnomalyHistory
+	if opts.anomalies != "" {
+		anomalies, err = security.LoadAnomalyHistory(opts.anomalies, anomalyHistorySize)
+		if err != nil {
+			return err
+		}
+	} else {
+		log.Printf("[MAIN] Anomaly detection disabled (--anomaly-history is empty)")
+	}
+
+	var prState *state.Store
+	switch {
+	case opts.state == "":
+		log.Printf("[MAIN] PR state disabled (--state is empty): every PR is analyzed on each run")
+	case opts.dryRun:
+		// Dry-run decisions were never acted on, so they must not be remembered
+		log.Printf("[MAIN] PR state not used in dry-run mode")
+	default:
+		prState, err = state.Load(opts.state)
+		if err != nil {
+			return err
+		}
+	}
+
+	format, err := report.ParseFormat(opts.format)
+	if err != nil {
+		return err
+	}
+
+	r := &runner{
+		gh:        gh,
+		ai:        aiClient,
+		consensus: consensus,
+		anomalies: anomalies,
+		state:     prState,
+		opts:      opts,
+		config:    opts.analyzerConfig(),
+		report:    report.New(os.Stdout, format),
+		accounts:  make(map[string]*accountClients),
+	}
+
+	if opts.dryRun {
+		log.Printf("[MAIN] Dry-run mode: no approvals, merges or rebases will be performed")
+	}
+
+	if opts.serve != "" {
+		return r.serve(ctx)
+	}
+
+	if err := r.runOnce(ctx); err != nil {
+		if opts.poll == 0 {
+			return err
+		}
+		log.Printf("[MAIN] Run failed: %v", err)
+	}
+
+	if opts.poll == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(opts.poll)
+	defer ticker.Stop()
+	for {
+		log.Printf("[MAIN] Next run in %v", opts.poll)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.runOnce(ctx); err != nil {
+				log.Printf("[MAIN] Run failed: %v", err)
+			}
+		}
+	}
+}
+
+// newAIClient creates the analyzer for the selected LLM provider.
+func newAIClient(ctx context.Context, opts *options, verdicts *llm.Cache) (llm.Analyzer, error) {
+	provider, _ := llm.ParseProvider(opts.provider)
+	return newLLMClient(ctx, provider, opts.llmURL, opts.model, opts.debug, verdicts)
+}
+
+// newLLMClient creates an analyzer for model on provider, reusing verdicts from the
+// cache if it is not nil. An empty url uses the provider's default.
+func newLLMClient(ctx context.Context, provider llm.Provider, url, model string, debug bool, verdicts *llm.Cache) (llm.Analyzer, error) {
+	var completer llm.Completer
+	var err error
+	switch provider {
+	case llm.ProviderOpenAI:
+		completer, err = llm.NewOpenAI(url, model)
+	case llm.ProviderOllama:
+		completer, err = llm.NewOllama(url, model)
+	default:
+		client, err := gemini.NewClient(ctx, model, debug)
+		if err != nil {
+			return nil, err
+		}
+		client.SetCache(verdicts)
+		return client, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client := llm.NewClient(completer, debug)
+	client.SetCache(verdicts)
+	return client, nil
+}
+
+// newConsensusModels creates a client for each --models entry. Entries without a
+// provider prefix use --provider, and only those use --llm-url.
+func newConsensusModels(ctx context.Context, opts *options, verdicts *llm.Cache) ([]analyzer.ConsensusModel, error) {
+	provider, _ := llm.ParseProvider(opts.provider)
+
+	var models []analyzer.ConsensusModel
+	for _, spec := range splitList(opts.models) {
+		p, model := llm.ParseModel(spec, provider)
+		url := ""
+		if p == provider {
+			url = opts.llmURL
+		}
+
+		client, err := newLLMClient(ctx, p, url, model, opts.debug, verdicts)
+		if err != nil {
+			return models, fmt.Errorf("creating consensus client for %s: %w", spec, err)
+		}
+		models = append(models, analyzer.ConsensusModel{Provider: p, Model: model, Client: client})
+	}
+	return models, nil
+}
+
+// newGitHubClient creates a GitHub client using the gh CLI or GitHub App credentials.
+func newGitHubClient(ctx context.Context, opts *options) (*githubAPI.Client, error) {
+	if opts.appID == 0 {
+		client, err := githubAPI.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("creating GitHub client (is `gh auth login` done?): %w", err)
+		}
+		return client, nil
+	}
+
+	keyPath, err := filepath.Abs(opts.appKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolving private key path: %w", err)
+	}
+
+	client, err := githubAPI.NewClientWithApp(ctx, opts.appID, keyPath, opts.installationID)
+	if err != nil {
+		return nil, fmt.Errorf("creating GitHub App client: %w", err)
+	}
+	return client, nil
+}
+
+// runner processes pull requests for the configured mode.
+type runner struct {
+	gh        *githubAPI.Client
+	ai        llm.Analyzer
+	consensus []analyzer.ConsensusModel
+	anomalies *security.AnomalyHistory
+	state     *state.Store // nil if disabled
+	opts      *options
+	config    *analyzer.Config
+	report    *report.Reporter
+	queue     *webhook.Queue // Set in webhook server mode
+
+	// accounts caches clients per repository owner or installation.
+	mu       sync.Mutex
+	accounts map[string]*accountClients
+}
+
+// accountClients holds the clients bound to a single repository owner.
+type accountClients struct {
+	gh        *githubAPI.Client
+	analyzer  *analyzer.Analyzer
+	processor *processor.Processor
+}
+
+// runOnce processes every PR selected by the current mode and writes the report.
+func (r *runner) runOnce(ctx context.Context) error {
+	err := r.processSelected(ctx)
+	if flushErr := r.report.Flush(); flushErr != nil {
+		log.Printf("[MAIN] Failed to write report: %v", flushErr)
+	}
+	return err
+}
+
+// processSelected processes every PR selected by the current mode.
+func (r *runner) processSelected(ctx context.Context) error {
+	switch {
+	case r.opts.pr != "":
+		owner, repo, number, err := githubAPI.ParsePullRequestURL(r.opts.pr)
+		if err != nil {
+			return fmt.Errorf("parsing --pr: %w", err)
+		}
+		return r.processPR(ctx, owner, repo, number)
+
+	case r.opts.project != "":
+		owner, repo, err := parseProject(r.opts.project)
+		if err != nil {
+			return err
+		}
+		c, err := r.forAccount(ctx, owner)
+		if err != nil {
+			return err
+		}
+		prs, err := c.gh.ListRepoPullRequests(ctx, owner, repo)
+		if err != nil {
+			return fmt.Errorf("listing PRs for %s/%s: %w", owner, repo, err)
+		}
+		return r.processAll(ctx, prs)
+
+	default:
+		c, err := r.forAccount(ctx, r.opts.org)
+		if err != nil {
+			return err
+		}
+		prs, err := c.gh.ListOrgPullRequests(ctx, r.opts.org)
+		if err != nil {
+			return fmt.Errorf("listing PRs for %s: %w", r.opts.org, err)
+		}
+		return r.processAll(ctx, prs)
+	}
+}
+
+// processAll processes a list of PRs, continuing past individual failures.
+func (r *runner) processAll(ctx context.Context, prs []*github.PullRequest) error {
+	log.Printf("[MAIN] Found %d open PRs", len(prs))
+
+	failed, unchanged := 0, 0
+	for _, pr := range prs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pr.GetBase().GetRepo() == nil {
+			continue
+		}
+		owner := pr.GetBase().GetRepo().GetOwner().GetLogin()
+		repo := pr.GetBase().GetRepo().GetName()
+		if r.state.Unchanged(owner, repo, pr.GetNumber(), pr.GetHead().GetSHA(), pr.GetUpdatedAt().Time) {
+			unchanged++
+			continue
+		}
+		if err := r.processPR(ctx, owner, repo, pr.GetNumber()); err != nil {
+			log.Printf("[MAIN] Failed to process %s/%s#%d: %v", owner, repo, pr.GetNumber(), err)
+			failed++
+		}
+	}
+
+	if unchanged > 0 {
+		log.Printf("[MAIN] Skipped %d PRs unchanged since their last analysis", unchanged)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d PRs failed to process", failed, len(prs))
+	}
+	return nil
+}
+
+// processPR analyzes a single PR and acts on the result.
+func (r *runner) processPR(ctx context.Context, owner, repo string, number int) error {
+	c, err := r.forAccount(ctx, owner)
+	if err != nil {
+		return err
+	}
+	_, err = r.processWith(ctx, c, owner, repo, number)
+	return err
+}
+
+// processWith analyzes a single PR using the given clients and acts on the result.
+// If we approved an earlier commit, the commits pushed since are analyzed on their own too.
+// It reports whether the decision was deferred until required checks complete.
+func (r *runner) processWith(ctx context.Context, c *accountClients, owner, repo string, number int) (bool, error) {
+	last, _ := r.state.Get(owner, repo, number)
+	result, err := c.analyzer.AnalyzePullRequestSince(ctx, owner, repo, number, last.ApprovedSHA)
+	if err != nil {
+		err = fmt.Errorf("analyzing PR: %w", err)
+		r.addRecord(report.NewRecord(owner, repo, number, nil, nil, err))
+		return false, err
+	}
+
+	outcome, err := c.processor.Process(ctx, owner, repo, number, result)
+	if err != nil {
+		err = fmt.Errorf("processing PR: %w", err)
+	}
+	rec := report.NewRecord(owner, repo, number, result, outcome, err)
+	r.addRecord(rec)
+	r.remember(last, rec, result, outcome, err)
+	return result.Deferred, err
+}
+
+// remember records a PR's analysis in the state store. The decision is final only if
+// acting on it succeeded, so failed actions are retried.
+func (r *runner) remember(last state.PR, rec report.Record, result *analyzer.Result, outcome *processor.Outcome, err error) {
+	pr := state.PR{
+		HeadSHA:     result.HeadSHA,
+		AnalyzedAt:  time.Now(),
+		Approvable:  result.Approvable,
+		Final:       result.Final() && err == nil,
+		Reason:      result.Reason,
+		Category:    rec.Category,
+		Flags:       rec.Flags,
+		ApprovedSHA: last.ApprovedSHA,
+	}
+	switch {
+	case result.AlreadyApprovedByUs || outcome.Succeeded(processor.ActionApprove):
+		pr.ApprovedSHA = result.HeadSHA
+	case outcome.Succeeded(processor.ActionDismissStale):
+		pr.ApprovedSHA = ""
+	}
+	r.state.Put(rec.Owner, rec.Repo, rec.Number, pr)
+}
+
+// addRecord adds a record to the report, logging write failures.
+func (r *runner) addRecord(rec report.Record) {
+	if err := r.report.Add(rec); err != nil {
+		log.Printf("[MAIN] Failed to write report for %s: %v", rec.Name(), err)
+	}
+}
+
+// forAccount returns the GitHub client and analyzer to use for an account.
+// With GitHub App authentication and no explicit installation ID, it looks up
+// the installation for the account so that multi-org apps act with the right
+// token. Results are cached for the lifetime of the runner.
+func (r *runner) forAccount(ctx context.Context, account string) (*accountClients, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := strings.ToLower(account)
+	if c, ok := r.accounts[key]; ok {
+		return c, nil
+	}
+
+	gh := r.gh
+	if r.gh.AppAuth() != nil && r.opts.installationID == 0 {
+		installations, err := r.gh.ListAppInstallations(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing app installations: %w", err)
+		}
+		gh = nil
+		for _, inst := range installations {
+			if !strings.EqualFold(inst.GetAccount().GetLogin(), account) {
+				continue
+			}
+			gh, err = githubAPI.NewClientWithAppInstallation(ctx, r.gh.AppAuth(), inst.GetID())
+			if err != nil {
+				return nil, fmt.Errorf("creating client for installation %d: %w", inst.GetID(), err)
+			}
+			break
+		}
+		if gh == nil {
+			return nil, fmt.Errorf("GitHub App is not installed on %s", account)
+		}
+	}
+
+	c, err := r.newAccountClients(gh)
+	if err != nil {
+		return nil, err
+	}
+	r.accounts[key] = c
+	return c, nil
+}
+
+// forInstallation returns the clients for a webhook event's App installation.
+// Events without an installation, or when not authenticated as an App, fall
+// back to the clients for the repository owner.
+func (r *runner) forInstallation(ctx context.Context, installationID int64, owner string) (*accountClients, error) {
+	if installationID == 0 || r.gh.AppAuth() == nil {
+		return r.forAccount(ctx, owner)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprintf("installation:%d", installationID)
+	if c, ok := r.accounts[key]; ok {
+		return c, nil
+	}
+
+	gh, err := githubAPI.NewClientWithAppInstallation(ctx, r.gh.AppAuth(), installationID)
+	if err != nil {
+		return nil, fmt.Errorf("creating client for installation %d: %w", installationID, err)
+	}
+
+	c, err := r.newAccountClients(gh)
+	if err != nil {
+		return nil, err
+	}
+	r.accounts[key] = c
+	return c, nil
+}
+
+// newAccountClients creates the analyzer and processor bound to a GitHub client.
+func (r *runner) newAccountClients(gh *githubAPI.Client) (*accountClients, error) {
+	a, err := analyzer.New(gh, r.ai, r.config)
+	if err != nil {
+		return nil, fmt.Errorf("creating analyzer: %w", err)
+	}
+	a.SetConsensusModels(r.consensus)
+	a.SetAnomalyHistory(r.anomalies)
+
+	p, err := processor.New(gh, processor.Options{
+		DryRun:     r.opts.dryRun,
+		AutoMerge:  r.opts.autoMerge,
+		AutoRebase: r.opts.autoRebase,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating processor: %w", err)
+	}
+
+	return &accountClients{gh: gh, analyzer: a, processor: p}, nil
+}
+
+// serve runs the webhook server until ctx is done.
+func (r *runner) serve(ctx context.Context) error {
+	queue := webhook.NewQueue(webhookQueueSize)
+	r.queue = queue
+	handler, err := webhook.NewHandler(r.opts.webhookSecret, func(ev webhook.Event) { queue.Push(ev) })
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(webhookPath, handler)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{
+		Addr:              r.opts.serve,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go queue.Run(ctx, webhookWorkers, r.handleEvent)
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("[MAIN] Listening for webhooks on %s%s", r.opts.serve, webhookPath)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("webhook server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down webhook server: %w", err)
+	}
+	return ctx.Err()
+}
+
+// handleEvent analyzes the PR a webhook event refers to. Events for PRs waiting for
+// required checks are queued again. Status events, which only carry the commit, are
+// queued again for each open PR it heads, so the queue never lets them run alongside
+// another event for the same PR.
+func (r *runner) handleEvent(ctx context.Context, ev webhook.Event) error {
+	c, err := r.forInstallation(ctx, ev.InstallationID, ev.Owner)
+	if err != nil {
+		return err
+	}
+
+	if ev.Number > 0 {
+		deferred, err := r.processWith(ctx, c, ev.Owner, ev.Repo, ev.Number)
+		if deferred {
+			r.queue.Defer(ev, deferDelay, maxDeferrals)
+		}
+		return err
+	}
+
+	prs, err := c.gh.ListRepoPullRequests(ctx, ev.Owner, ev.Repo)
+	if err != nil {
+		return fmt.Errorf("listing PRs for %s/%s: %w", ev.Owner, ev.Repo, err)
+	}
+	for _, pr := range prs {
+		if pr.GetHead().GetSHA() != ev.HeadSHA {
+			continue
+		}
+		prEv := ev
+		prEv.Number, prEv.HeadSHA = pr.GetNumber(), ""
+		r.queue.Push(prEv)
+	}
+	return nil
+}
+
+// parseProject splits an owner/repo string.
+func parseProject(project string) (owner, repo string, err error) {
+	parts := strings.Split(project, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid --project %q: expected owner/repo", project)
+	}
+	return parts[0], parts[1], nil
+}
+
+// defaultCacheFile returns the default path of a file in the user's cache directory.
+// It returns "" (disabled) if there is no cache directory.
+func defaultCacheFile(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "auto-approve", name)
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    options
+		wantErr bool
+	}{
+		{
+			name: "single PR",
+			opts: options{pr: "owner/repo#1"},
+		},
+		{
+			name: "project with poll",
+			opts: options{project: "owner/repo", poll: time.Hour},
+		},
+		{
+			name: "org with app",
+			opts: options{org: "myorg", appID: 1, appKey: "key.pem"},
+		},
+		{
+			name: "webhook server",
+			opts: options{serve: ":8080", webhookSecret: "secret"},
+		},
+		{
+			name:    "webhook server without secret",
+			opts:    options{serve: ":8080"},
+			wantErr: true,
+		},
+		{
+			name:    "poll with webhook server",
+			opts:    options{serve: ":8080", webhookSecret: "secret", poll: time.Hour},
+			wantErr: true,
+		},
+		{
+			name: "ndjson with webhook server",
+			opts: options{serve: ":8080", webhookSecret: "secret", format: "ndjson"},
+		},
+		{
+			name:    "json with webhook server",
+			opts:    options{serve: ":8080", webhookSecret: "secret", format: "json"},
+			wantErr: true,
+		},
+		{
+			name: "sarif for project",
+			opts: options{project: "owner/repo", format: "sarif"},
+		},
+		{
+			name: "extra bot",
+			opts: options{pr: "owner/repo#1", bots: "release-bot[bot]=lockfile-only"},
+		},
+		{
+			name:    "bot without profile",
+			opts:    options{pr: "owner/repo#1", bots: "release-bot[bot]"},
+			wantErr: true,
+		},
+		{
+			name: "code owners required",
+			opts: options{pr: "owner/repo#1", codeOwners: "required", allowedOwners: "@org/docs"},
+		},
+		{
+			name:    "unknown code owners mode",
+			opts:    options{pr: "owner/repo#1", codeOwners: "strict"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			opts:    options{org: "myorg", format: "yaml"},
+			wantErr: true,
+		},
+		{
+			name: "ollama with model",
+			opts: options{pr: "owner/repo#1", provider: "ollama", model: "llama3", llmURL: "http://ollama:11434"},
+		},
+		{
+			name:    "openai without model",
+			opts:    options{pr: "owner/repo#1", provider: "openai", model: defaultModel},
+			wantErr: true,
+		},
+		{
+			name: "mixed-provider consensus",
+			opts: options{pr: "owner/repo#1", provider: "ollama", model: "llama3", models: "llama3,gemini:gemini-2.0-flash", threshold: 0.66},
+		},
+		{
+			name:    "consensus with one model",
+			opts:    options{pr: "owner/repo#1", models: "gemini-2.0-flash", threshold: 1},
+			wantErr: true,
+		},
+		{
+			name:    "consensus threshold out of range",
+			opts:    options{pr: "owner/repo#1", models: "gemini-2.0-flash,ollama:llama3", threshold: 1.5},
+			wantErr: true,
+		},
+		{
+			name:    "llm URL with gemini",
+			opts:    options{pr: "owner/repo#1", llmURL: "http://localhost:8000/v1"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown provider",
+			opts:    options{pr: "owner/repo#1", provider: "claude"},
+			wantErr: true,
+		},
+		{
+			name:    "no mode",
+			opts:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "multiple modes",
+			opts:    options{pr: "owner/repo#1", org: "myorg"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid project",
+			opts:    options{project: "owner"},
+			wantErr: true,
+		},
+		{
+			name:    "poll with single PR",
+			opts:    options{pr: "owner/repo#1", poll: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "app ID without key",
+			opts:    options{org: "myorg", appID: 1},
+			wantErr: true,
+		},
+		{
+			name:    "installation ID without app",
+			opts:    options{org: "myorg", installationID: 5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnalyzerConfig(t *testing.T) {
+	opts := &options{
+		maxFiles:     3,
+		maxLines:     50,
+		model:        "",
+		models:       "gemini-2.0-flash, ollama:llama3",
+		threshold:    0.5,
+		trustedUsers: "alice,,bob",
+		dryRun:       true,
+	}
+
+	config := opts.analyzerConfig()
+
+	if config.MaxFiles != 3 || config.MaxLines != 50 {
+		t.Errorf("limits = %d/%d, want 3/50", config.MaxFiles, config.MaxLines)
+	}
+	if config.UseAI {
+		t.Error("UseAI should be false when --model is empty")
+	}
+	if !config.UseMultiModel || len(config.Models) != 2 {
+		t.Errorf("multi-model = %v %v, want enabled with 2 models", config.UseMultiModel, config.Models)
+	}
+	if config.ConsensusThreshold != 0.5 {
+		t.Errorf("ConsensusThreshold = %v, want 0.5", config.ConsensusThreshold)
+	}
+	if len(config.TrustedUsers) != 2 {
+		t.Errorf("TrustedUsers = %v, want [alice bob]", config.TrustedUsers)
+	}
+	if !config.SkipFirstTime || !config.SkipDraft || !config.RequirePassingChecks {
+		t.Error("safety defaults should remain enabled")
+	}
+	if !config.DryRun {
+		t.Error("DryRun should be set")
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestParseProject(t *testing.T) {
+	owner, repo, err := parseProject("golang/go")
+	if err != nil || owner != "golang" || repo != "go" {
+		t.Errorf("parseProject() = %q, %q, %v", owner, repo, err)
+	}
+
+	for _, bad := range []string{"", "golang", "golang/", "/go", "a/b/c"} {
+		if _, _, err := parseProject(bad); err == nil {
+			t.Errorf("parseProject(%q) expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/llm"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// anomalyMessage is the reason given when a model response is anomalous.
+const anomalyMessage = "Model response is anomalous; needs human review"
+
+// SetAnomalyHistory enables anomaly detection on every model response,
+// comparing each against the history of the same model on the same repository.
+func (a *Analyzer) SetAnomalyHistory(history *security.AnomalyHistory) {
+	a.anomalies = history
+}
+
+// detectAnomalies records a model's response for the repository and returns how it
+// deviates from that model's history there, prefixed with the model.
+func (a *Analyzer) detectAnomalies(owner, repo, model string, res *llm.AnalysisResult) []string {
+	if a.anomalies == nil {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%s %s", owner, repo, model)
+	anomalies := a.anomalies.Detect(key, newModelAnalysis(model, res))
+	for i, anomaly := range anomalies {
+		anomalies[i] = fmt.Sprintf("%s: %s", model, anomaly)
+	}
+	return anomalies
+}
+
+// checkAnomalies runs anomaly detection on a model's response and returns its check.
+func (a *Analyzer) checkAnomalies(owner, repo, model string, res *llm.AnalysisResult) Check {
+	start := time.Now()
+	if a.anomalies == nil {
+		return Check{ID: CheckAIAnomaly, Status: CheckSkip, Message: "anomaly detection disabled"}
+	}
+
+	anomalies := a.detectAnomalies(owner, repo, model, res)
+	if len(anomalies) > 0 {
+		return Check{ID: CheckAIAnomaly, Status: CheckFail, Message: anomalyMessage,
+			Details: anomalies, Duration: time.Since(start)}
+	}
+	return Check{ID: CheckAIAnomaly, Status: CheckPass, Value: model, Duration: time.Since(start)}
+}
+
+// newModelAnalysis converts an LLM result for model ("provider/name") to a security.ModelAnalysis.
+func newModelAnalysis(model string, res *llm.AnalysisResult) security.ModelAnalysis {
+	provider, name, _ := strings.Cut(model, "/")
+	return security.ModelAnalysis{
+		Provider:          security.ModelProvider(provider),
+		Model:             name,
+		AltersBehavior:    res.AltersBehavior,
+		Category:          res.Category,
+		Risky:             res.Risky,
+		PossiblyMalicious: res.PossiblyMalicious,
+		InsecureChange:    res.InsecureChange,
+		NonTrivial:        res.NonTrivial,
+		Superfluous:       res.Superfluous,
+		Vandalism:         res.Vandalism,
+		MajorVersionBump:  res.MajorVersionBump,
+		Confidence:        res.Confidence,
+		Reason:            res.Reason,
+	}
+}
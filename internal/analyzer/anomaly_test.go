@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+func TestAnalyzePullRequest_Anomaly(t *testing.T) {
+	history, err := security.LoadAnomalyHistory(filepath.Join(t.TempDir(), "anomalies.json"), 100)
+	if err != nil {
+		t.Fatalf("LoadAnomalyHistory() error = %v", err)
+	}
+	// The model has always rejected changes to this repository
+	for i := 0; i < 10; i++ {
+		history.Detect("owner/repo mock/model", security.ModelAnalysis{AltersBehavior: true, Category: "documentation", Confidence: 0.9})
+	}
+
+	pr := &github.PullRequest{
+		State:             github.String("open"),
+		Draft:             github.Bool(false),
+		ChangedFiles:      github.Int(1),
+		Additions:         github.Int(1),
+		Deletions:         github.Int(1),
+		UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+		User:              &github.User{Login: github.String("testuser")},
+		AuthorAssociation: github.String("CONTRIBUTOR"),
+	}
+	files := []*github.CommitFile{
+		{Filename: github.String("README.md"), Patch: github.String("@@ -1 +1 @@\n-Teh\n+The")},
+	}
+
+	config := DefaultConfig()
+	config.RequirePassingChecks = false
+	a, err := New(&mockGitHubAPI{pr: pr, files: files}, &mockGeminiAPI{result: &geminiAnalysisResult{Category: "documentation"}}, config)
+	if err != nil {
+		t.Fatalf("Failed to create analyzer: %v", err)
+	}
+	a.SetAnomalyHistory(history)
+
+	result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+	if err != nil {
+		t.Fatalf("AnalyzePullRequest() error = %v", err)
+	}
+
+	if result.Approvable || !result.NeedsHuman {
+		t.Errorf("Approvable = %v, NeedsHuman = %v; want a human decision", result.Approvable, result.NeedsHuman)
+	}
+	if result.Reason != anomalyMessage {
+		t.Errorf("Reason = %q, want %q", result.Reason, anomalyMessage)
+	}
+	if len(result.Anomalies) != 1 || !strings.HasPrefix(result.Anomalies[0], "mock/model: Unusual approval") {
+		t.Errorf("Anomalies = %v", result.Anomalies)
+	}
+}
@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/deps"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// BotProfile is the kind of change a trusted bot may make.
+type BotProfile string
+
+// Bot profiles.
+const (
+	BotDependencies BotProfile = "dependency-only" // Dependency manifests, lockfiles and workflow action bumps
+	BotLockfiles    BotProfile = "lockfile-only"   // Lockfiles
+	BotFormatting   BotProfile = "formatting-only" // Whitespace, and Go files proved no-op
+	BotGenerated    BotProfile = "generated-files" // Files matching the bot's paths
+)
+
+// Bot is an automation account whose PRs are trusted within its profile. Its PRs are exempt
+// from the line-count limit and the AI's non-trivial flag, but every file must fit the profile.
+type Bot struct {
+	Login   string     `yaml:"login"`
+	Profile BotProfile `yaml:"profile"`
+
+	// Paths are the gitignore-style patterns of files a generated-files bot may change.
+	Paths []string `yaml:"paths"`
+}
+
+// DefaultBots is the bot registry used when Config.Bots is nil.
+var DefaultBots = []Bot{
+	{Login: "dependabot[bot]", Profile: BotDependencies},
+	{Login: "renovate[bot]", Profile: BotDependencies},
+	{Login: "pre-commit-ci[bot]", Profile: BotFormatting},
+}
+
+// Validate checks that the bot has a login, a known profile, and paths only if it
+// generates files.
+func (b Bot) Validate() error {
+	if strings.TrimSpace(b.Login) == "" {
+		return fmt.Errorf("login must not be empty")
+	}
+	switch b.Profile {
+	case BotDependencies, BotLockfiles, BotFormatting:
+		if len(b.Paths) > 0 {
+			return fmt.Errorf("%s: paths only apply to the %s profile", b.Login, BotGenerated)
+		}
+	case BotGenerated:
+		if len(b.Paths) == 0 {
+			return fmt.Errorf("%s: the %s profile requires paths", b.Login, BotGenerated)
+		}
+		for _, p := range b.Paths {
+			if err := security.ValidatePattern(p); err != nil {
+				return fmt.Errorf("%s: %w", b.Login, err)
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unknown profile %q (want %s, %s, %s or %s)", b.Login, b.Profile,
+			BotDependencies, BotLockfiles, BotFormatting, BotGenerated)
+	}
+	return nil
+}
+
+// ParseBot parses a "login=profile" bot specification.
+func ParseBot(spec string) (Bot, error) {
+	login, profile, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Bot{}, fmt.Errorf("invalid bot %q (want login=profile)", spec)
+	}
+	bot := Bot{Login: strings.TrimSpace(login), Profile: BotProfile(strings.TrimSpace(profile))}
+	if err := bot.Validate(); err != nil {
+		return Bot{}, err
+	}
+	return bot, nil
+}
+
+// MergeBots returns base with extra added, where a bot in extra replaces the bot in
+// base with the same login.
+func MergeBots(base, extra []Bot) []Bot {
+	merged := slices.Clone(base)
+	for _, bot := range extra {
+		i := slices.IndexFunc(merged, func(b Bot) bool { return strings.EqualFold(b.Login, bot.Login) })
+		if i >= 0 {
+			merged[i] = bot
+		} else {
+			merged = append(merged, bot)
+		}
+	}
+	return merged
+}
+
+// botFor returns the registered bot that authored the PR, or nil. Only GitHub App
+// accounts, whose logins users cannot register, are bots.
+func (a *Analyzer) botFor(pr *github.PullRequest) *Bot {
+	login := pr.GetUser().GetLogin()
+	if login == "" || pr.GetUser().GetType() != "Bot" {
+		return nil
+	}
+	bots := a.config.Bots
+	if bots == nil {
+		bots = DefaultBots
+	}
+	for i := range bots {
+		if strings.EqualFold(bots[i].Login, login) {
+			return &bots[i]
+		}
+	}
+	return nil
+}
+
+// allows reports whether the bot's profile covers a file's change.
+func (b *Bot) allows(file *github.CommitFile, provedNoOp []string) bool {
+	for _, name := range []string{file.GetFilename(), file.GetPreviousFilename()} {
+		if name != "" && !b.allowsPath(name) {
+			return false
+		}
+	}
+	if b.Profile == BotFormatting {
+		filename := file.GetFilename()
+		return slices.Contains(provedNoOp, filename) || security.WhitespaceOnly(file.GetPatch(), filename)
+	}
+	return true
+}
+
+// allowsPath reports whether the bot's profile covers a path, regardless of the change.
+func (b *Bot) allowsPath(name string) bool {
+	switch b.Profile {
+	case BotDependencies:
+		return deps.IsDependencyFile(name) || security.IsWorkflow(name) // Workflows are limited to action bumps
+	case BotLockfiles:
+		return deps.IsLockfile(name)
+	case BotFormatting:
+		return true
+	case BotGenerated:
+		return slices.ContainsFunc(b.Paths, func(p string) bool { return security.MatchPath(p, name) })
+	default:
+		return false
+	}
+}
+
+// checkBotProfile records a CheckBotProfile check verifying that every file fits the
+// bot's profile. It reports whether the check passed.
+func (a *Analyzer) checkBotProfile(bot *Bot, files []*github.CommitFile, result *Result) bool {
+	start := time.Now()
+	var outside []string
+	for _, file := range files {
+		if !bot.allows(file, result.ProvedNoOp) {
+			outside = append(outside, fmt.Sprintf("%s: outside the %s profile", file.GetFilename(), bot.Profile))
+		}
+	}
+	if len(outside) > 0 {
+		result.fail(CheckBotProfile, start, fmt.Sprintf("Changes by %s exceed its %s profile", bot.Login, bot.Profile), len(outside), 0, outside...)
+		return false
+	}
+	result.pass(CheckBotProfile, start, string(bot.Profile), nil)
+	return true
+}
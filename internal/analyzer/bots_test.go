@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestBotValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		bot     Bot
+		wantErr string
+	}{
+		{name: "dependency bot", bot: Bot{Login: "renovate[bot]", Profile: BotDependencies}},
+		{name: "generated files", bot: Bot{Login: "release[bot]", Profile: BotGenerated, Paths: []string{"dist/**"}}},
+		{name: "empty login", bot: Bot{Profile: BotLockfiles}, wantErr: "login"},
+		{name: "unknown profile", bot: Bot{Login: "x", Profile: "anything"}, wantErr: "unknown profile"},
+		{name: "generated without paths", bot: Bot{Login: "x", Profile: BotGenerated}, wantErr: "requires paths"},
+		{name: "paths without generated", bot: Bot{Login: "x", Profile: BotFormatting, Paths: []string{"*"}}, wantErr: "paths only apply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.bot.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAnalyzePullRequest_BotProfile(t *testing.T) {
+	release := Bot{Login: "release-bot[bot]", Profile: BotGenerated, Paths: []string{"CHANGELOG.md", "dist/**"}}
+
+	tests := []struct {
+		name       string
+		author     string
+		file       *github.CommitFile
+		wantReason string // "" if the profile check passes
+	}{
+		{
+			name:   "renovate lockfile",
+			author: "renovate[bot]",
+			file:   &github.CommitFile{Filename: github.String("go.sum"), Patch: github.String("@@ -1,1 +1,1 @@\n-golang.org/x/text v0.14.0 h1:a=\n+golang.org/x/text v0.14.1 h1:b=")},
+		},
+		{
+			name:       "dependabot source file",
+			author:     "dependabot[bot]",
+			file:       &github.CommitFile{Filename: github.String("main.go"), Patch: github.String("@@ -1,1 +1,1 @@\n-// a\n+// b")},
+			wantReason: "Changes by dependabot[bot] exceed its dependency-only profile",
+		},
+		{
+			name:   "pre-commit trailing whitespace",
+			author: "pre-commit-ci[bot]",
+			file:   &github.CommitFile{Filename: github.String("app.js"), Patch: github.String("@@ -1,1 +1,1 @@\n-x = 1;  \n+x = 1;")},
+		},
+		{
+			name:       "pre-commit comment change",
+			author:     "pre-commit-ci[bot]",
+			file:       &github.CommitFile{Filename: github.String("app.js"), Patch: github.String("@@ -1,1 +1,1 @@\n-// a\n+// b")},
+			wantReason: "Changes by pre-commit-ci[bot] exceed its formatting-only profile",
+		},
+		{
+			name:   "release bot generated file",
+			author: "release-bot[bot]",
+			file:   &github.CommitFile{Filename: github.String("CHANGELOG.md"), Patch: github.String("@@ -1,1 +1,2 @@\n # Changelog\n+## v1.2.0")},
+		},
+		{
+			name:       "release bot other file",
+			author:     "release-bot[bot]",
+			file:       &github.CommitFile{Filename: github.String("docs/guide.md"), Patch: github.String("@@ -1,1 +1,2 @@\n # Guide\n+More")},
+			wantReason: "Changes by release-bot[bot] exceed its generated-files profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(1000), // Bots are exempt from the line limit
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              prAuthor(tt.author),
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+			}
+			gh := &mockGitHubAPI{pr: pr, files: []*github.CommitFile{tt.file}}
+			mockGemini := &mockGeminiAPI{result: &geminiAnalysisResult{Category: "other", NonTrivial: true}}
+
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			config.PathRules = nil
+			config.Bots = MergeBots(DefaultBots, []Bot{release})
+			a, err := New(gh, mockGemini, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+			if check := result.Check(CheckMaxLines); check == nil || check.Status != CheckSkip {
+				t.Errorf("line-count check = %+v, want skipped", check)
+			}
+
+			check := result.Check(CheckBotProfile)
+			if tt.wantReason != "" {
+				if result.Approvable || result.Reason != tt.wantReason {
+					t.Errorf("Approvable = %v, Reason = %q, want %q", result.Approvable, result.Reason, tt.wantReason)
+				}
+				return
+			}
+			if check == nil || check.Status != CheckPass {
+				t.Errorf("bot profile check = %+v, want pass (reason %q)", check, result.Reason)
+			}
+			if nonTrivial := result.Check(CheckAINonTrivial); nonTrivial != nil && nonTrivial.Status != CheckSkip {
+				t.Errorf("non-trivial check = %+v, want waived for bots", nonTrivial)
+			}
+		})
+	}
+}
+
+// prAuthor returns a PR's author, an App's bot account if the login ends in "[bot]".
+func prAuthor(login string) *github.User {
+	userType := "User"
+	if strings.HasSuffix(login, "[bot]") {
+		userType = "Bot"
+	}
+	return &github.User{Login: github.String(login), Type: github.String(userType)}
+}
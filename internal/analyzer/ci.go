@@ -0,0 +1,234 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/constants"
+)
+
+// ciState is the state of a status check reported on a commit.
+type ciState string
+
+// Status check states.
+const (
+	ciPassing ciState = "passing"
+	ciPending ciState = "pending"
+	ciFailing ciState = "failing"
+)
+
+// ciCheck is a commit status or check run reported on the PR's head commit.
+type ciCheck struct {
+	Name   string
+	AppID  int64 // The GitHub App that created a check run; 0 for commit statuses
+	State  ciState
+	Detail string // How the check failed, e.g. "ci/test (Tests timed out)"
+}
+
+// requiredCheck is a status check that the base branch's protection or a ruleset requires.
+type requiredCheck struct {
+	Context string
+	AppID   int64 // The GitHub App that must report the check, or 0 or -1 for any
+}
+
+// matches reports whether a reported check satisfies the requirement.
+func (r requiredCheck) matches(c ciCheck) bool {
+	return c.Name == r.Context && (r.AppID <= 0 || r.AppID == c.AppID)
+}
+
+// checkCI records the CheckCIRequired check, gating on the status checks the base branch
+// requires, and the informational CheckCIOptional check listing the others. If the base
+// branch requires none, every reported check gates approval. Required checks that are
+// still running or not yet reported leave the check pending, deferring the decision.
+// It reports whether the required checks passed.
+func (a *Analyzer) checkCI(ctx context.Context, owner, repo string, pr *github.PullRequest, result *Result) bool {
+	if !a.config.RequirePassingChecks || pr.GetHead().GetSHA() == "" {
+		result.skip(CheckCIRequired, time.Now(), "passing checks not required")
+		result.skip(CheckCIOptional, time.Now(), "passing checks not required")
+		return true
+	}
+	sha := pr.GetHead().GetSHA()
+	log.Printf("[ANALYZER] Checking CI status for PR %s/%s#%d (SHA: %s)", owner, repo, pr.GetNumber(), sha)
+
+	start := time.Now()
+	required, err := a.requiredChecks(ctx, owner, repo, pr.GetBase().GetRef())
+	if err != nil {
+		// Gating on every check is stricter than gating on the required ones
+		log.Printf("[ANALYZER] Warning: Failed to get required checks for %s/%s@%s: %v - requiring all checks", owner, repo, pr.GetBase().GetRef(), err)
+		required = nil
+	}
+
+	status, err := a.gh.CombinedStatus(ctx, owner, repo, sha)
+	if err != nil {
+		// Degrade gracefully - don't approve if we can't verify CI status
+		log.Printf("[ANALYZER] Warning: Failed to get CI status for %s/%s#%d: %v - rejecting for safety", owner, repo, pr.GetNumber(), err)
+		result.errored(CheckCIRequired, start, "Unable to verify CI status", fmt.Sprintf("CI status error: %v", err))
+		return false
+	}
+	checkRuns, err := a.gh.ListCheckRunsForRef(ctx, owner, repo, sha)
+	if err != nil {
+		log.Printf("[ANALYZER] Warning: Failed to get check runs for %s/%s#%d: %v - rejecting for safety", owner, repo, pr.GetNumber(), err)
+		result.errored(CheckCIRequired, start, "Unable to verify check runs", fmt.Sprintf("Check runs error: %v", err))
+		return false
+	}
+
+	reported := append(statusChecks(status), checkRunChecks(checkRuns)...)
+	failing, pending, optional := evaluateChecks(required, reported, a.ignoresCheck(pr.GetUser()))
+
+	gating := len(required)
+	if gating == 0 {
+		gating = len(reported) - len(optional)
+	}
+	switch {
+	case len(failing) > 0:
+		log.Printf("[ANALYZER] PR %s/%s#%d has failing required checks", owner, repo, pr.GetNumber())
+		details := failing
+		if len(pending) > 0 {
+			details = append(details, "Pending checks: "+strings.Join(pending, ", "))
+		}
+		result.fail(CheckCIRequired, start, "Required checks not passing", len(failing), 0, details...)
+	case len(pending) > 0:
+		log.Printf("[ANALYZER] PR %s/%s#%d is waiting for required checks: %s", owner, repo, pr.GetNumber(), strings.Join(pending, ", "))
+		result.pending(CheckCIRequired, start, "Waiting for required checks", len(pending), 0, "Pending checks: "+strings.Join(pending, ", "))
+	default:
+		log.Printf("[ANALYZER] PR %s/%s#%d required checks are passing", owner, repo, pr.GetNumber())
+		result.pass(CheckCIRequired, start, gating, nil)
+	}
+	result.pass(CheckCIOptional, start, len(optional), nil, optional...)
+
+	return len(failing) == 0 && len(pending) == 0
+}
+
+// requiredChecks returns the status checks that a branch's protection and rulesets require.
+func (a *Analyzer) requiredChecks(ctx context.Context, owner, repo, branch string) ([]requiredCheck, error) {
+	var required []requiredCheck
+	protection, err := a.gh.BranchProtection(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	if checks := protection.GetRequiredStatusChecks(); checks != nil {
+		if checks.Checks != nil {
+			for _, c := range *checks.Checks {
+				required = append(required, requiredCheck{Context: c.Context, AppID: c.GetAppID()})
+			}
+		} else if checks.Contexts != nil {
+			for _, name := range *checks.Contexts {
+				required = append(required, requiredCheck{Context: name})
+			}
+		}
+	}
+
+	rules, err := a.gh.BranchRules(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		var params github.RequiredStatusChecksRuleParameters
+		if rule.Type != "required_status_checks" || !decodeRuleParameters(rule, &params) {
+			continue
+		}
+		for _, c := range params.RequiredStatusChecks {
+			required = append(required, requiredCheck{Context: c.Context, AppID: c.GetIntegrationID()})
+		}
+	}
+	return required, nil
+}
+
+// ignoresCheck returns a function reporting whether a check never gates approval: signing
+// checks of bot authors, if IgnoreSigningChecks is set.
+func (a *Analyzer) ignoresCheck(author *github.User) func(name string) bool {
+	return func(name string) bool {
+		return a.config.IgnoreSigningChecks && author.GetType() == "Bot" &&
+			strings.Contains(strings.ToLower(name), "sign")
+	}
+}
+
+// statusChecks converts the commit statuses of a combined status.
+func statusChecks(status *github.CombinedStatus) []ciCheck {
+	if status == nil {
+		return nil
+	}
+	var checks []ciCheck
+	for _, s := range status.Statuses {
+		c := ciCheck{Name: s.GetContext(), Detail: fmt.Sprintf("%s: %s", s.GetContext(), s.GetState())}
+		if s.GetDescription() != "" {
+			c.Detail = fmt.Sprintf("%s (%s)", s.GetContext(), s.GetDescription())
+		}
+		switch s.GetState() {
+		case constants.CheckStateSuccess:
+			c.State = ciPassing
+		case constants.CheckStatePending:
+			c.State = ciPending
+		default:
+			c.State = ciFailing
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkRunChecks converts check runs. Runs that are not completed are pending; neutral and
+// skipped conclusions pass.
+func checkRunChecks(checkRuns []*github.CheckRun) []ciCheck {
+	var checks []ciCheck
+	for _, run := range checkRuns {
+		c := ciCheck{Name: run.GetName(), AppID: run.GetApp().GetID()}
+		switch {
+		case run.GetStatus() != "completed":
+			c.State = ciPending
+		case run.GetConclusion() == "success" || run.GetConclusion() == "neutral" || run.GetConclusion() == "skipped":
+			c.State = ciPassing
+		default:
+			c.State = ciFailing
+		}
+		c.Detail = fmt.Sprintf("%s (%s)", run.GetName(), run.GetConclusion())
+		if title := run.GetOutput().GetTitle(); title != "" {
+			c.Detail = fmt.Sprintf("%s: %s", run.GetName(), title)
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// evaluateChecks returns the failing and pending checks that gate approval and a summary
+// of the optional ones. Required checks not reported yet are pending. Without required
+// checks every reported check gates approval, except ignored ones.
+func evaluateChecks(required []requiredCheck, reported []ciCheck, ignored func(name string) bool) (failing, pending, optional []string) {
+	gating := make([]bool, len(reported))
+	for _, req := range required {
+		if ignored(req.Context) {
+			continue
+		}
+		found := false
+		for i, c := range reported {
+			if req.matches(c) {
+				found = true
+				gating[i] = true
+			}
+		}
+		if !found {
+			pending = append(pending, req.Context+" (expected)")
+		}
+	}
+	if len(required) == 0 {
+		for i, c := range reported {
+			gating[i] = !ignored(c.Name)
+		}
+	}
+
+	for i, c := range reported {
+		switch {
+		case !gating[i]:
+			optional = append(optional, fmt.Sprintf("Optional check %s: %s", c.Name, c.State))
+		case c.State == ciFailing:
+			failing = append(failing, c.Detail)
+		case c.State == ciPending:
+			pending = append(pending, c.Name)
+		}
+	}
+	return failing, pending, optional
+}
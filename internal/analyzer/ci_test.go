@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestAnalyzePullRequest_RequiredChecks(t *testing.T) {
+	const actionsApp = 15368
+	run := func(name, status, conclusion string, appID int64) *github.CheckRun {
+		r := &github.CheckRun{Name: github.String(name), Status: github.String(status), App: &github.App{ID: github.Int64(appID)}}
+		if conclusion != "" {
+			r.Conclusion = github.String(conclusion)
+		}
+		return r
+	}
+	requireTest := &github.Protection{RequiredStatusChecks: &github.RequiredStatusChecks{
+		Checks: &[]*github.RequiredStatusCheck{{Context: "test", AppID: github.Int64(actionsApp)}},
+	}}
+
+	tests := []struct {
+		name         string
+		protection   *github.Protection
+		rules        []*github.RepositoryRule
+		status       *github.CombinedStatus
+		checkRuns    []*github.CheckRun
+		wantStatus   CheckStatus
+		wantReason   string
+		wantDeferred bool
+		wantOptional []string
+	}{
+		{
+			name:         "optional check failing",
+			protection:   requireTest,
+			checkRuns:    []*github.CheckRun{run("test", "completed", "success", actionsApp), run("flaky", "completed", "failure", actionsApp)},
+			wantStatus:   CheckPass,
+			wantOptional: []string{"Optional check flaky: failing"},
+		},
+		{
+			name:       "required check failing",
+			protection: requireTest,
+			checkRuns:  []*github.CheckRun{run("test", "completed", "failure", actionsApp)},
+			wantStatus: CheckFail,
+			wantReason: "Required checks not passing",
+		},
+		{
+			name:         "required check running",
+			protection:   requireTest,
+			checkRuns:    []*github.CheckRun{run("test", "in_progress", "", actionsApp)},
+			wantStatus:   CheckPending,
+			wantReason:   "Waiting for required checks",
+			wantDeferred: true,
+		},
+		{
+			name:         "required check not reported yet",
+			protection:   requireTest,
+			wantStatus:   CheckPending,
+			wantReason:   "Waiting for required checks",
+			wantDeferred: true,
+		},
+		{
+			name:         "required check from another app",
+			protection:   requireTest,
+			checkRuns:    []*github.CheckRun{run("test", "completed", "success", 1)},
+			wantStatus:   CheckPending,
+			wantReason:   "Waiting for required checks",
+			wantDeferred: true,
+			wantOptional: []string{"Optional check test: passing"},
+		},
+		{
+			name: "required by a ruleset",
+			rules: []*github.RepositoryRule{github.NewRequiredStatusChecksRule(&github.RequiredStatusChecksRuleParameters{
+				RequiredStatusChecks: []github.RuleRequiredStatusChecks{{Context: "ci/lint"}},
+			})},
+			status: &github.CombinedStatus{Statuses: []*github.RepoStatus{
+				{Context: github.String("ci/lint"), State: github.String("failure"), Description: github.String("Lint failed")},
+			}},
+			wantStatus: CheckFail,
+			wantReason: "Required checks not passing",
+		},
+		{
+			name:         "no required checks",
+			checkRuns:    []*github.CheckRun{run("test", "completed", "success", actionsApp), run("docs", "queued", "", actionsApp)},
+			wantStatus:   CheckPending,
+			wantReason:   "Waiting for required checks",
+			wantDeferred: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(1),
+				Deletions:         github.Int(1),
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              &github.User{Login: github.String("testuser")},
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+				Base:              &github.PullRequestBranch{Ref: github.String("main")},
+				Head:              &github.PullRequestBranch{SHA: github.String("head")},
+			}
+			files := []*github.CommitFile{{Filename: github.String("README.md"), Patch: github.String("@@ -1 +1 @@\n-Teh\n+The")}}
+			gh := &mockGitHubAPI{pr: pr, files: files, protection: tt.protection, rules: tt.rules, status: tt.status, checkRuns: tt.checkRuns}
+
+			a, err := New(gh, &mockGeminiAPI{result: &geminiAnalysisResult{Category: "typo"}}, DefaultConfig())
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+
+			check := result.Check(CheckCIRequired)
+			if check == nil || check.Status != tt.wantStatus {
+				t.Fatalf("%s = %+v, want %s", CheckCIRequired, check, tt.wantStatus)
+			}
+			if tt.wantStatus == CheckPass {
+				if !result.Approvable {
+					t.Errorf("Approvable = false, reason: %s", result.Reason)
+				}
+			} else if result.Approvable || result.Reason != tt.wantReason {
+				t.Errorf("Approvable = %v, Reason = %q, want %q", result.Approvable, result.Reason, tt.wantReason)
+			}
+			if result.Deferred != tt.wantDeferred {
+				t.Errorf("Deferred = %v, want %v", result.Deferred, tt.wantDeferred)
+			}
+			if optional := result.Check(CheckCIOptional); optional == nil || !slices.Equal(optional.Details, tt.wantOptional) {
+				t.Errorf("%s = %+v, want details %q", CheckCIOptional, optional, tt.wantOptional)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/codeowners"
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+)
+
+// CodeOwnersMode is how the analyzer treats files that have code owners.
+type CodeOwnersMode string
+
+// Code owner modes.
+const (
+	CodeOwnersEnforce  CodeOwnersMode = "enforce"  // Refuse files owned only outside AllowedOwners
+	CodeOwnersRequired CodeOwnersMode = "required" // Enforce only where branch protection or a ruleset requires code owner review
+	CodeOwnersIgnore   CodeOwnersMode = "ignore"
+)
+
+// validCodeOwnersModes are the accepted CodeOwnersMode values; "" enforces.
+var validCodeOwnersModes = map[CodeOwnersMode]bool{
+	"":                 true,
+	CodeOwnersEnforce:  true,
+	CodeOwnersRequired: true,
+	CodeOwnersIgnore:   true,
+}
+
+// checkCodeOwners records a CheckCodeOwners check on the CODEOWNERS file of the base
+// branch. Every changed file with code owners, under both names if renamed, needs one
+// owner whose approval the bot can give: an owner in AllowedOwners, the bot itself, or a
+// team it is a member of. It reports whether the check passed.
+func (a *Analyzer) checkCodeOwners(ctx context.Context, owner, repo string, pr *github.PullRequest, files []*github.CommitFile, currentUser *github.User, result *Result) bool {
+	start := time.Now()
+	mode := a.config.CodeOwners
+	if mode == CodeOwnersIgnore {
+		result.skip(CheckCodeOwners, start, "Code owners are ignored")
+		return true
+	}
+
+	baseRef := pr.GetBase().GetRef()
+	required, err := a.codeOwnerReviewRequired(ctx, owner, repo, baseRef)
+	if err != nil {
+		log.Printf("[ANALYZER] Failed to fetch review rules for %s/%s@%s: %v", owner, repo, baseRef, err)
+		if mode == CodeOwnersRequired {
+			result.errored(CheckCodeOwners, start, "Unable to read branch protection", err.Error())
+			return false
+		}
+	}
+	if mode == CodeOwnersRequired && len(required) == 0 {
+		result.skip(CheckCodeOwners, start, "Code owner review is not required for "+baseRef)
+		return true
+	}
+
+	file, path, err := a.loadCodeOwners(ctx, owner, repo, baseRef)
+	if err != nil {
+		log.Printf("[ANALYZER] Failed to read CODEOWNERS for %s/%s@%s: %v", owner, repo, baseRef, err)
+		result.errored(CheckCodeOwners, start, "Unable to read CODEOWNERS", err.Error())
+		return false
+	}
+	if file == nil {
+		result.pass(CheckCodeOwners, start, "no CODEOWNERS", nil)
+		return true
+	}
+	for _, err := range file.Errors {
+		log.Printf("[ANALYZER] Ignoring invalid %s line in %s/%s@%s: %v", path, owner, repo, baseRef, err)
+	}
+
+	approves := a.ownerApprover(ctx, currentUser)
+	var missing []string
+	var details []string
+	for _, f := range files {
+		for _, name := range []string{f.GetPreviousFilename(), f.GetFilename()} {
+			owners := file.Owners(name)
+			if name == "" || len(owners) == 0 || slices.ContainsFunc(owners, approves) {
+				continue
+			}
+			for _, o := range owners {
+				if !slices.Contains(missing, o) {
+					missing = append(missing, o)
+				}
+			}
+			details = append(details, fmt.Sprintf("%s: owned by %s", name, strings.Join(owners, ", ")))
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		if len(required) > 0 {
+			details = append(details, "Code owner review is required by "+strings.Join(required, " and "))
+		}
+		result.fail(CheckCodeOwners, start, "Code owners must approve: "+strings.Join(missing, ", "), len(missing), 0, details...)
+		return false
+	}
+	result.pass(CheckCodeOwners, start, path, nil)
+	return true
+}
+
+// codeOwnerReviewRequired returns what requires code owner review on a branch: its
+// branch protection and any rulesets.
+func (a *Analyzer) codeOwnerReviewRequired(ctx context.Context, owner, repo, branch string) ([]string, error) {
+	var required []string
+	protection, err := a.gh.BranchProtection(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	if reviews := protection.GetRequiredPullRequestReviews(); reviews != nil && reviews.RequireCodeOwnerReviews {
+		required = append(required, "branch protection")
+	}
+
+	rules, err := a.gh.BranchRules(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		var params github.PullRequestRuleParameters
+		if rule.Type == "pull_request" && decodeRuleParameters(rule, &params) && params.RequireCodeOwnerReview {
+			required = append(required, fmt.Sprintf("ruleset %d", rule.RulesetID))
+		}
+	}
+	return required, nil
+}
+
+// decodeRuleParameters decodes the parameters of a ruleset rule into params, reporting
+// whether the rule has parameters of that shape.
+func decodeRuleParameters(rule *github.RepositoryRule, params interface{}) bool {
+	if rule.Parameters == nil {
+		return false
+	}
+	if err := json.Unmarshal(*rule.Parameters, params); err != nil {
+		log.Printf("[ANALYZER] Warning: Failed to decode %s rule of ruleset %d: %v", rule.Type, rule.RulesetID, err)
+		return false
+	}
+	return true
+}
+
+// loadCodeOwners reads the CODEOWNERS file GitHub uses on a branch. It returns a nil file
+// if there is none.
+func (a *Analyzer) loadCodeOwners(ctx context.Context, owner, repo, ref string) (*codeowners.File, string, error) {
+	for _, path := range codeowners.Paths {
+		data, err := a.gh.FileContents(ctx, owner, repo, path, ref)
+		if stderrors.Is(err, errors.ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("reading %s: %w", path, err)
+		}
+		return codeowners.Parse(data), path, nil
+	}
+	return nil, "", nil
+}
+
+// ownerApprover returns a function reporting whether the bot's approval counts as a code
+// owner's: the owner is allowed by configuration, is the bot, or is a team the bot is in.
+func (a *Analyzer) ownerApprover(ctx context.Context, currentUser *github.User) func(string) bool {
+	login := currentUser.GetLogin()
+	members := make(map[string]bool)
+	return func(owner string) bool {
+		if slices.ContainsFunc(a.config.AllowedOwners, func(allowed string) bool {
+			return strings.EqualFold(normalizeOwner(allowed), owner)
+		}) {
+			return true
+		}
+		if login == "" {
+			return false
+		}
+		if strings.EqualFold(owner, "@"+login) {
+			return true
+		}
+		org, team, isTeam := strings.Cut(strings.TrimPrefix(owner, "@"), "/")
+		if !isTeam || !strings.HasPrefix(owner, "@") {
+			return false
+		}
+		if member, ok := members[owner]; ok {
+			return member
+		}
+		member, err := a.gh.IsTeamMember(ctx, org, team, login)
+		if err != nil {
+			log.Printf("[ANALYZER] Failed to check %s membership of %s: %v", owner, login, err)
+		}
+		members[owner] = member
+		return member
+	}
+}
+
+// normalizeOwner adds the "@" that users and teams have in CODEOWNERS.
+func normalizeOwner(owner string) string {
+	owner = strings.TrimSpace(owner)
+	if strings.Contains(owner, "@") {
+		return owner
+	}
+	return "@" + owner
+}
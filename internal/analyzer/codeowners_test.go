@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// pullRequestRule returns a pull_request rule of ruleset id, as GetRulesForBranch does.
+func pullRequestRule(id int64, params *github.PullRequestRuleParameters) *github.RepositoryRule {
+	rule := github.NewPullRequestRule(params)
+	rule.RulesetID = id
+	return rule
+}
+
+func TestAnalyzePullRequest_CodeOwners(t *testing.T) {
+	const codeOwners = "* @org/core\n/docs/ @org/docs @alice\n"
+	docPatch := "@@ -1,2 +1,2 @@\n # Guide\n-Teh guide\n+The guide"
+	guide := &github.CommitFile{Filename: github.String("docs/guide.md"), Status: github.String("modified"), Patch: github.String(docPatch)}
+	readme := &github.CommitFile{Filename: github.String("README.md"), Status: github.String("modified"), Patch: github.String(docPatch)}
+	protected := &github.Protection{RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequireCodeOwnerReviews: true}}
+
+	tests := []struct {
+		name          string
+		file          *github.CommitFile
+		codeOwners    string // Contents of .github/CODEOWNERS, "" for none
+		mode          CodeOwnersMode
+		allowedOwners []string
+		protection    *github.Protection
+		rules         []*github.RepositoryRule
+		teams         map[string]string
+		wantStatus    CheckStatus
+		wantReason    string
+		wantDetail    string
+	}{
+		{
+			name:       "no CODEOWNERS",
+			file:       readme,
+			wantStatus: CheckPass,
+		},
+		{
+			name:       "owned outside the allowlist",
+			file:       guide,
+			codeOwners: codeOwners,
+			wantStatus: CheckFail,
+			wantReason: "Code owners must approve: @alice, @org/docs",
+			wantDetail: "docs/guide.md: owned by @org/docs, @alice",
+		},
+		{
+			name:          "allowed owner",
+			file:          guide,
+			codeOwners:    codeOwners,
+			allowedOwners: []string{"org/docs"},
+			wantStatus:    CheckPass,
+		},
+		{
+			name:       "bot in the owning team",
+			file:       readme,
+			codeOwners: codeOwners,
+			teams:      map[string]string{"org/core": "approver-bot"},
+			wantStatus: CheckPass,
+		},
+		{
+			name:       "rename out of an owned directory",
+			file:       &github.CommitFile{Filename: github.String("guide.md"), PreviousFilename: github.String("docs/guide.md"), Status: github.String("renamed"), Patch: github.String(docPatch)},
+			codeOwners: codeOwners,
+			teams:      map[string]string{"org/core": "approver-bot"},
+			wantStatus: CheckFail,
+			wantReason: "Code owners must approve: @alice, @org/docs",
+		},
+		{
+			name:       "required by branch protection",
+			file:       readme,
+			codeOwners: codeOwners,
+			protection: protected,
+			wantStatus: CheckFail,
+			wantReason: "Code owners must approve: @org/core",
+			wantDetail: "Code owner review is required by branch protection",
+		},
+		{
+			name:       "required by a ruleset",
+			file:       readme,
+			codeOwners: codeOwners,
+			mode:       CodeOwnersRequired,
+			rules:      []*github.RepositoryRule{pullRequestRule(42, &github.PullRequestRuleParameters{RequireCodeOwnerReview: true})},
+			wantStatus: CheckFail,
+			wantReason: "Code owners must approve: @org/core",
+			wantDetail: "Code owner review is required by ruleset 42",
+		},
+		{
+			name:       "not required",
+			file:       readme,
+			codeOwners: codeOwners,
+			mode:       CodeOwnersRequired,
+			wantStatus: CheckSkip,
+		},
+		{
+			name:       "ignored",
+			file:       readme,
+			codeOwners: codeOwners,
+			mode:       CodeOwnersIgnore,
+			wantStatus: CheckSkip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(1),
+				Deletions:         github.Int(1),
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              &github.User{Login: github.String("testuser")},
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+				Base:              &github.PullRequestBranch{Ref: github.String("main")},
+			}
+			gh := &mockGitHubAPI{
+				pr:          pr,
+				files:       []*github.CommitFile{tt.file},
+				currentUser: &github.User{Login: github.String("approver-bot")},
+				protection:  tt.protection,
+				rules:       tt.rules,
+				teams:       tt.teams,
+			}
+			if tt.codeOwners != "" {
+				gh.contents = map[string]string{".github/CODEOWNERS@main": tt.codeOwners}
+			}
+
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			config.AllowedOwners = tt.allowedOwners
+			if tt.mode != "" {
+				config.CodeOwners = tt.mode
+			}
+			a, err := New(gh, &mockGeminiAPI{result: &geminiAnalysisResult{Category: "typo"}}, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+
+			check := result.Check(CheckCodeOwners)
+			if check == nil || check.Status != tt.wantStatus {
+				t.Fatalf("%s = %+v, want %s (reason %q)", CheckCodeOwners, check, tt.wantStatus, result.Reason)
+			}
+			if tt.wantStatus != CheckFail {
+				if !result.Approvable {
+					t.Errorf("Approvable = false, reason: %s", result.Reason)
+				}
+				return
+			}
+			if result.Approvable || result.Reason != tt.wantReason {
+				t.Errorf("Approvable = %v, Reason = %q, want %q", result.Approvable, result.Reason, tt.wantReason)
+			}
+			if tt.wantDetail != "" && !slices.Contains(check.Details, tt.wantDetail) {
+				t.Errorf("Details = %q, want %q", check.Details, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package analyzer
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+	"github.com/thegroove/trivial-auto-approve/internal/llm"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// DefaultConsensusThreshold requires every model to agree a change is safe.
+const DefaultConsensusThreshold = 1.0
+
+// ConsensusModel is one member of the panel that votes on code changes from trusted users.
+// Models from different providers can be mixed.
+type ConsensusModel struct {
+	Provider llm.Provider
+	Model    string
+	Client   llm.Analyzer
+}
+
+// name identifies the model as "provider/model".
+func (m ConsensusModel) name() string {
+	return string(m.Provider) + "/" + m.Model
+}
+
+// SetConsensusModels sets the panel used for multi-model consensus.
+// Consensus is only used when Config.UseMultiModel is set and at least two models are given.
+func (a *Analyzer) SetConsensusModels(models []ConsensusModel) {
+	a.consensus = models
+}
+
+// consensusApplies reports whether the PR author's code changes may be approved by multi-model consensus.
+func (a *Analyzer) consensusApplies(ctx context.Context, owner, repo string, pr *github.PullRequest) bool {
+	if !a.config.UseMultiModel || len(a.consensus) < 2 || pr.User == nil {
+		return false
+	}
+	return a.isTrustedUser(ctx, owner, repo, pr.User.GetLogin())
+}
+
+// reviewWithConsensus asks every consensus model about a single file and combines their
+// answers with security.MultiModelAnalyzer. It records a CheckCodeConsensus check and the
+// models' disagreements on result, and returns a rejection reason if the file is not approved.
+func (a *Analyzer) reviewWithConsensus(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, isCode bool, result *Result) (string, []string) {
+	start := time.Now()
+	filename := file.GetFilename()
+
+	analyses, anomalies, cached, err := a.collectConsensusVotes(ctx, owner, repo, pr, file)
+	if err != nil {
+		// Fall back to rejection if any model fails
+		log.Printf("[ANALYZER] Multi-model consensus failed: %v", err)
+		if stderrors.Is(err, errors.ErrDiffTooLarge) {
+			// Reject rather than judge a truncated diff
+			reason := "Changes too large for AI analysis"
+			result.fail(CheckCodeConsensus, start, reason, nil, a.config.ConsensusThreshold, fmt.Sprintf("%s: %v", filename, err))
+			return reason, []string{fmt.Sprintf("%s: too large for AI analysis", filename)}
+		}
+		result.errored(CheckCodeConsensus, start, "AI consensus failed", fmt.Sprintf("%s: %v", filename, err))
+		if isCode {
+			return "Code changes could alter program behavior (AI consensus failed)",
+				[]string{fmt.Sprintf("%s: Non-comment changes in code file", filename)}
+		}
+		return "Config changes could alter program behavior (AI consensus failed)",
+			[]string{fmt.Sprintf("%s: Changes in configuration file", filename)}
+	}
+
+	// Any anomalous vote needs a human, whatever the consensus
+	if len(anomalies) > 0 {
+		result.fail(CheckAIAnomaly, start, anomalyMessage, nil, nil, anomalies...)
+		result.fail(CheckCodeConsensus, start, anomalyMessage, nil, a.config.ConsensusThreshold)
+		return anomalyMessage, []string{fmt.Sprintf("%s: anomalous model response", filename)}
+	}
+
+	providers := make([]security.ModelProvider, len(analyses))
+	for i, analysis := range analyses {
+		providers[i] = analysis.Provider
+	}
+	threshold := a.config.ConsensusThreshold
+	consensus, err := security.NewMultiModelAnalyzer(providers, threshold, false).AnalyzeWithConsensus(ctx, analyses)
+	if err != nil {
+		result.errored(CheckCodeConsensus, start, "AI consensus failed", fmt.Sprintf("%s: %v", filename, err))
+		return "Changes could alter program behavior (AI consensus failed)", []string{fmt.Sprintf("%s: %v", filename, err)}
+	}
+
+	for _, d := range consensus.Disagreements {
+		result.Disagreements = append(result.Disagreements, fmt.Sprintf("%s: %s", filename, d))
+	}
+
+	var reasons []string
+	if !consensus.Consensus {
+		reasons = append(reasons, "models disagree")
+	}
+	if consensus.AltersBehavior {
+		reasons = append(reasons, "alters behavior")
+	}
+	reasons = append(reasons, consensus.RedFlags()...)
+
+	votes := make([]string, len(analyses))
+	for i, analysis := range analyses {
+		votes[i] = fmt.Sprintf("%s: %s", analysis.Name(), analysis.Reason)
+		if cached[i] {
+			votes[i] += " (cached verdict)"
+		}
+	}
+
+	if len(reasons) > 0 {
+		log.Printf("[ANALYZER] Multi-model consensus: REJECTED %s (reasons: %v)", filename, reasons)
+		reason := fmt.Sprintf("Multi-model AI analysis rejected: %s", strings.Join(reasons, "; "))
+		result.fail(CheckCodeConsensus, start, reason, consensus.Category, threshold,
+			append(votes, consensus.Disagreements...)...)
+		result.Checks[len(result.Checks)-1].Cached = slices.Contains(cached, true)
+		return reason, append([]string{fmt.Sprintf("%s: AI rejection", filename)}, consensus.Disagreements...)
+	}
+
+	log.Printf("[ANALYZER] Multi-model consensus: APPROVED %s (%d models, category: %s)", filename, consensus.ModelCount, consensus.Category)
+	result.pass(CheckCodeConsensus, start, consensus.Category, threshold, votes...)
+	result.Checks[len(result.Checks)-1].Cached = slices.Contains(cached, true)
+	return "", nil
+}
+
+// collectConsensusVotes analyzes the file with every consensus model in parallel,
+// returning each model's analysis, anomalies against its history and whether its
+// verdict was reused from the cache.
+// Any model failing is an error, since a missing vote could hide a red flag.
+func (a *Analyzer) collectConsensusVotes(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile) ([]security.ModelAnalysis, []string, []bool, error) {
+	changes := []llm.FileChange{{
+		Filename:  file.GetFilename(),
+		Patch:     file.GetPatch(),
+		Additions: file.GetAdditions(),
+		Deletions: file.GetDeletions(),
+	}}
+	prContext := a.buildPRContext(pr)
+
+	analyses := make([]security.ModelAnalysis, len(a.consensus))
+	anomalies := make([][]string, len(a.consensus))
+	cached := make([]bool, len(a.consensus))
+	errs := make([]error, len(a.consensus))
+
+	var wg sync.WaitGroup
+	for i, model := range a.consensus {
+		wg.Add(1)
+		go func(i int, model ConsensusModel) {
+			defer wg.Done()
+
+			res, err := model.Client.AnalyzePRChanges(ctx, changes, prContext)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", model.name(), err)
+				return
+			}
+			analyses[i] = newModelAnalysis(model.name(), res)
+			cached[i] = res.Cached
+			anomalies[i] = a.detectAnomalies(owner, repo, model.name(), res)
+		}(i, model)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var all []string
+	for _, found := range anomalies {
+		all = append(all, found...)
+	}
+	return analyses, all, cached, nil
+}
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/llm"
+)
+
+func TestAnalyzePullRequest_Consensus(t *testing.T) {
+	ctx := context.Background()
+
+	pr := &github.PullRequest{
+		State:             github.String("open"),
+		Draft:             github.Bool(false),
+		ChangedFiles:      github.Int(1),
+		Additions:         github.Int(1),
+		Deletions:         github.Int(1),
+		UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+		User:              &github.User{Login: github.String("trusted")},
+		AuthorAssociation: github.String("CONTRIBUTOR"),
+	}
+	files := []*github.CommitFile{
+		{Filename: github.String("main.go"), Patch: github.String("@@ -1 +1 @@\n-const retries = 3\n+const retries = 5")},
+	}
+
+	safe := &geminiAnalysisResult{Category: "refactor", Reason: "safe"}
+	alters := &geminiAnalysisResult{Category: "refactor", AltersBehavior: true, Reason: "changes retries"}
+	risky := &geminiAnalysisResult{Category: "refactor", Risky: true, Reason: "risky"}
+
+	tests := []struct {
+		name              string
+		threshold         float64
+		votes             []*mockGeminiAPI
+		wantApprovable    bool
+		wantStatus        CheckStatus
+		wantDisagreements int
+	}{
+		{
+			name:       "unanimous",
+			threshold:  1,
+			votes:      []*mockGeminiAPI{{result: safe}, {result: safe}},
+			wantStatus: CheckPass, wantApprovable: true,
+		},
+		{
+			name:       "one dissent rejects at unanimity",
+			threshold:  1,
+			votes:      []*mockGeminiAPI{{result: safe}, {result: alters}},
+			wantStatus: CheckFail, wantDisagreements: 1,
+		},
+		{
+			name:       "one dissent passes a 2/3 threshold",
+			threshold:  0.66,
+			votes:      []*mockGeminiAPI{{result: safe}, {result: safe}, {result: alters}},
+			wantStatus: CheckPass, wantApprovable: true, wantDisagreements: 1,
+		},
+		{
+			name:       "any red flag rejects",
+			threshold:  0.66,
+			votes:      []*mockGeminiAPI{{result: safe}, {result: safe}, {result: risky}},
+			wantStatus: CheckFail, wantDisagreements: 1,
+		},
+		{
+			name:       "model error fails closed",
+			threshold:  0.5,
+			votes:      []*mockGeminiAPI{{result: safe}, {err: fmt.Errorf("connection refused")}},
+			wantStatus: CheckError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			config.UseMultiModel = true
+			config.ConsensusThreshold = tt.threshold
+			config.TrustedUsers = []string{"trusted"}
+
+			a, err := New(&mockGitHubAPI{pr: pr, files: files}, &mockGeminiAPI{result: safe}, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+			models := make([]ConsensusModel, len(tt.votes))
+			for i, vote := range tt.votes {
+				models[i] = ConsensusModel{Provider: llm.ProviderOllama, Model: fmt.Sprintf("model-%d", i), Client: vote}
+			}
+			models[0].Provider = llm.ProviderGemini
+			a.SetConsensusModels(models)
+
+			result, err := a.AnalyzePullRequest(ctx, "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+
+			if result.Approvable != tt.wantApprovable {
+				t.Errorf("Approvable = %v, reason: %s", result.Approvable, result.Reason)
+			}
+			c := result.Check(CheckCodeConsensus)
+			if c == nil || c.Status != tt.wantStatus {
+				t.Fatalf("code.consensus = %+v, want %s", c, tt.wantStatus)
+			}
+			if len(result.Disagreements) != tt.wantDisagreements {
+				t.Errorf("Disagreements = %v, want %d", result.Disagreements, tt.wantDisagreements)
+			}
+			for _, d := range result.Disagreements {
+				if !strings.HasPrefix(d, "main.go: ") || !strings.Contains(d, "gemini/model-0") {
+					t.Errorf("disagreement %q should name the file and models", d)
+				}
+			}
+		})
+	}
+}
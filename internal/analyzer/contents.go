@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// maxContentSize is the largest file, in bytes, whose contents are fetched to replace a missing patch.
+const maxContentSize = 1 << 20
+
+// patchFromContents builds a patch from a file's full contents at the PR's base and head
+// commits, for files whose patch GitHub omits (binary or too large). It returns a rejection
+// reason and detail if the contents cannot be fetched or analyzed as text.
+func (a *Analyzer) patchFromContents(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, result *Result) (patch, reason, detail string) {
+	filename := file.GetFilename()
+	if pr.GetBase().GetSHA() == "" || pr.GetHead().GetSHA() == "" {
+		return "", "File changes could not be validated", fmt.Sprintf("%s: no patch, and the PR's commits are unknown", filename)
+	}
+
+	base, head, err := a.compareFile(ctx, owner, repo, pr, file, result)
+	if err != nil {
+		log.Printf("[ANALYZER] Failed to fetch contents of %s: %v", filename, err)
+		return "", "File changes could not be validated", fmt.Sprintf("%s: no patch, and fetching contents failed: %v", filename, err)
+	}
+
+	for _, content := range [][]byte{base, head} {
+		if len(content) > maxContentSize {
+			return "", "File too large to validate", fmt.Sprintf("%s: %d bytes exceeds %d", filename, len(content), maxContentSize)
+		}
+		if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+			return "", "Binary file changes require manual review", fmt.Sprintf("%s: binary content", filename)
+		}
+	}
+
+	log.Printf("[ANALYZER] No patch for %s, validating full contents", filename)
+	return security.DiffPatch(string(base), string(head)), "", ""
+}
+
+// fileContents is a file's contents at a PR's base and head commits, or why they could
+// not be fetched.
+type fileContents struct {
+	base, head []byte
+	err        error
+}
+
+// compareFile returns a file's contents at the PR's base and head commits. They are
+// fetched once per analysis, however many checks need them.
+func (a *Analyzer) compareFile(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, result *Result) ([]byte, []byte, error) {
+	filename := file.GetFilename()
+	basePath := filename
+	if previous := file.GetPreviousFilename(); previous != "" {
+		basePath = previous
+	}
+
+	key := strings.Join([]string{basePath, filename, pr.GetBase().GetSHA(), pr.GetHead().GetSHA()}, "\x00")
+	if c, ok := result.contents[key]; ok {
+		return c.base, c.head, c.err
+	}
+
+	base, head, err := a.gh.CompareFile(ctx, owner, repo, basePath, filename, pr.GetBase().GetSHA(), pr.GetHead().GetSHA())
+	if result.contents == nil {
+		result.contents = make(map[string]fileContents)
+	}
+	result.contents[key] = fileContents{base: base, head: head, err: err}
+	return base, head, err
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestValidateCodeChanges_MissingPatch(t *testing.T) {
+	generated := strings.Repeat("// Code generated by tool. DO NOT EDIT.\n", 100)
+
+	tests := []struct {
+		name       string
+		contents   map[string]string
+		wantReason string
+	}{
+		{
+			name:       "contents unavailable",
+			wantReason: "File changes could not be validated",
+		},
+		{
+			name:       "binary",
+			contents:   map[string]string{"gen.go@head": "\x00\x01"},
+			wantReason: "Binary file changes require manual review",
+		},
+		{
+			name: "hidden payload in generated file",
+			contents: map[string]string{
+				"gen.go@base": generated,
+				"gen.go@head": generated + "func init() { go steal() }\n",
+			},
+			wantReason: "Code changes contain security risks",
+		},
+		{
+			name: "comment-only change",
+			contents: map[string]string{
+				"gen.go@base": generated,
+				"gen.go@head": generated + "// regenerated\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				User: &github.User{Login: github.String("testuser")},
+				Base: &github.PullRequestBranch{SHA: github.String("base")},
+				Head: &github.PullRequestBranch{SHA: github.String("head")},
+			}
+			files := []*github.CommitFile{{Filename: github.String("gen.go")}}
+
+			config := DefaultConfig()
+			a, err := New(&mockGitHubAPI{contents: tt.contents}, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			reason, details, _ := a.validateCodeChanges(context.Background(), pr, "owner", "repo", files, &Result{})
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q (%v), want %q", reason, details, tt.wantReason)
+			}
+			if reason == "" && !strings.Contains(files[0].GetPatch(), "+// regenerated") {
+				t.Errorf("patch = %q, want the synthesized diff", files[0].GetPatch())
+			}
+		})
+	}
+}
+
+func TestCompareFileOncePerAnalysis(t *testing.T) {
+	pr := &github.PullRequest{
+		User: &github.User{Login: github.String("testuser")},
+		Base: &github.PullRequestBranch{SHA: github.String("base")},
+		Head: &github.PullRequestBranch{SHA: github.String("head")},
+	}
+	files := []*github.CommitFile{{Filename: github.String("gen.go")}}
+	gh := &mockGitHubAPI{contents: map[string]string{
+		"gen.go@base": "package main\n\nfunc main() {\n\tprintln(1)\n}\n",
+		"gen.go@head": "package main\n\nfunc main() {\n\tprintln( 1 )\n}\n",
+	}}
+
+	a, err := New(gh, nil, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create analyzer: %v", err)
+	}
+	result := &Result{}
+	if !a.checkBinaryFiles(context.Background(), "owner", "repo", pr, files, result) {
+		t.Fatalf("checkBinaryFiles() failed: %+v", result.Checks)
+	}
+	if reason, details, _ := a.validateCodeChanges(context.Background(), pr, "owner", "repo", files, result); reason != "" {
+		t.Fatalf("validateCodeChanges() = %q (%v)", reason, details)
+	}
+
+	// The binary check and the no-op proof share one fetch
+	if len(result.ProvedNoOp) != 1 || gh.compares != 1 {
+		t.Errorf("ProvedNoOp = %v after %d CompareFile calls, want gen.go after 1", result.ProvedNoOp, gh.compares)
+	}
+}
@@ -79,14 +79,14 @@ func TestDependabotBehavior(t *testing.T) {
 			wantReason:        "Changes alter application behavior", // AltersBehavior is checked before NonTrivial
 		},
 		{
-			name:              "dependabot alternative name",
+			name:              "user account named dependabot",
 			prUser:            "dependabot",
 			authorAssociation: "CONTRIBUTOR",
 			additions:         500,
 			deletions:         300,
 			nonTrivial:        true,
-			altersBehavior:    false, // Dependabot non-major updates don't alter behavior
-			wantApprovable:    true,
+			altersBehavior:    false,
+			wantApprovable:    false, // Only the App's bot account is trusted
 		},
 		{
 			name:              "dependabot minor version update doesn't alter behavior",
@@ -111,7 +111,7 @@ func TestDependabotBehavior(t *testing.T) {
 					Additions:         github.Int(tt.additions),
 					Deletions:         github.Int(tt.deletions),
 					UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
-					User:              &github.User{Login: github.String(tt.prUser)},
+					User:              prAuthor(tt.prUser),
 					AuthorAssociation: github.String(tt.authorAssociation),
 					Base: &github.PullRequestBranch{
 						Repo: &github.Repository{
@@ -142,7 +142,7 @@ func TestDependabotBehavior(t *testing.T) {
 			}
 
 			config := DefaultConfig()
-			config.UseGemini = true
+			config.UseAI = true
 			config.MaxLines = 250
 
 			analyzer, err := New(mockGH, mockGemini, config)
@@ -178,7 +178,7 @@ func TestDependabotAltersBehaviorCheck(t *testing.T) {
 			Additions:         github.Int(10),
 			Deletions:         github.Int(5),
 			UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
-			User:              &github.User{Login: github.String("dependabot[bot]")},
+			User:              prAuthor("dependabot[bot]"),
 			AuthorAssociation: github.String("CONTRIBUTOR"),
 			Base: &github.PullRequestBranch{
 				Repo: &github.Repository{
@@ -208,7 +208,7 @@ func TestDependabotAltersBehaviorCheck(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 
 	analyzer, err := New(mockGH, mockGemini, config)
 	if err != nil {
@@ -236,7 +236,7 @@ func TestMajorVersionBumpDetection(t *testing.T) {
 			Additions:         github.Int(10),
 			Deletions:         github.Int(5),
 			UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
-			User:              &github.User{Login: github.String("dependabot[bot]")},
+			User:              prAuthor("dependabot[bot]"),
 			AuthorAssociation: github.String("CONTRIBUTOR"),
 			Base: &github.PullRequestBranch{
 				Repo: &github.Repository{
@@ -266,7 +266,7 @@ func TestMajorVersionBumpDetection(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 
 	analyzer, err := New(mockGH, mockGemini, config)
 	if err != nil {
@@ -299,8 +299,8 @@ func TestMajorVersionBumpDetection(t *testing.T) {
 	}
 }
 
-func TestIsDependabotPR(t *testing.T) {
-	a := &Analyzer{}
+func TestBotFor(t *testing.T) {
+	a := &Analyzer{config: DefaultConfig()}
 
 	tests := []struct {
 		name string
@@ -310,14 +310,28 @@ func TestIsDependabotPR(t *testing.T) {
 		{
 			name: "dependabot[bot] user",
 			pr: &github.PullRequest{
-				User: &github.User{Login: github.String("dependabot[bot]")},
+				User: prAuthor("dependabot[bot]"),
 			},
 			want: true,
 		},
 		{
-			name: "dependabot user",
+			name: "dependabot user account",
+			pr: &github.PullRequest{
+				User: prAuthor("dependabot"),
+			},
+			want: false,
+		},
+		{
+			name: "bot login on a user account",
+			pr: &github.PullRequest{
+				User: &github.User{Login: github.String("renovate[bot]"), Type: github.String("User")},
+			},
+			want: false,
+		},
+		{
+			name: "renovate[bot] user",
 			pr: &github.PullRequest{
-				User: &github.User{Login: github.String("dependabot")},
+				User: prAuthor("renovate[bot]"),
 			},
 			want: true,
 		},
@@ -346,9 +360,9 @@ func TestIsDependabotPR(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := a.isDependabotPR(tt.pr)
+			got := a.botFor(tt.pr) != nil
 			if got != tt.want {
-				t.Errorf("isDependabotPR() = %v, want %v", got, tt.want)
+				t.Errorf("botFor() = %v, want %v", got, tt.want)
 			}
 		})
 	}
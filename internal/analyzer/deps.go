@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"log"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/deps"
+)
+
+// dependencyBumps returns the version bumps in files. all is true if every file is a
+// dependency manifest or lockfile whose patch changes nothing but versions.
+func dependencyBumps(files []*github.CommitFile) (bumps []deps.Bump, all bool) {
+	all = len(files) > 0
+	for _, file := range files {
+		fileBumps, ok := deps.Parse(file.GetFilename(), file.GetPatch())
+		if !ok {
+			all = false
+			continue
+		}
+		bumps = append(bumps, fileBumps...)
+	}
+	return bumps, all
+}
+
+// checkDependencies records a CheckDependencies check for PRs that bump dependency
+// versions, failing it if any bump is major. minorOnly reports whether the PR contains
+// only minor and patch bumps, which need no AI review when they come from a dependency
+// bot; ok is false if a major bump was rejected.
+func (a *Analyzer) checkDependencies(files []*github.CommitFile, result *Result) (minorOnly, ok bool) {
+	start := time.Now()
+	bumps, all := dependencyBumps(files)
+	if len(bumps) == 0 {
+		return false, true
+	}
+
+	var major, details []string
+	minorOnly = all
+	for _, bump := range bumps {
+		details = append(details, bump.String())
+		switch bump.Level() {
+		case deps.LevelMajor:
+			major = append(major, bump.String())
+		case deps.LevelUnknown:
+			minorOnly = false
+		}
+	}
+
+	if len(major) > 0 {
+		log.Printf("[ANALYZER] Major version bump detected: %v", major)
+		result.fail(CheckDependencies, start, "Major version bump detected - requires manual review", len(major), 0, major...)
+		return false, false
+	}
+	result.pass(CheckDependencies, start, len(bumps), nil, details...)
+	return minorOnly, true
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestAnalyzePullRequest_DependencyBumps(t *testing.T) {
+	tests := []struct {
+		name           string
+		author         string
+		to             string
+		wantApprovable bool
+		wantReason     string
+		wantAI         CheckStatus
+	}{
+		{
+			name:           "renovate minor bump",
+			author:         "renovate[bot]",
+			to:             "4.19.0",
+			wantApprovable: true,
+			wantAI:         CheckSkip,
+		},
+		{
+			name:       "dependabot major bump",
+			author:     "dependabot[bot]",
+			to:         "5.0.0",
+			wantReason: "Major version bump detected - requires manual review",
+		},
+		{
+			name:       "human minor bump still needs AI",
+			author:     "testuser",
+			to:         "4.19.0",
+			wantReason: "Cannot verify changes without AI analysis (use --model to enable)",
+			wantAI:     CheckFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(1),
+				Deletions:         github.Int(1),
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              prAuthor(tt.author),
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+			}
+			files := []*github.CommitFile{{
+				Filename: github.String("package.json"),
+				Patch:    github.String("@@ -10,3 +10,3 @@\n   \"dependencies\": {\n-    \"express\": \"4.18.2\",\n+    \"express\": \"" + tt.to + "\",\n     \"lodash\": \"4.17.21\""),
+			}}
+			gh := &mockGitHubAPI{pr: pr, files: files}
+
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			// Without AI, only deterministically verified bumps can be approved
+			a, err := New(gh, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+			if result.Approvable != tt.wantApprovable {
+				t.Fatalf("Approvable = %v (%s), want %v", result.Approvable, result.Reason, tt.wantApprovable)
+			}
+			if tt.wantReason != "" && result.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", result.Reason, tt.wantReason)
+			}
+			if check := result.Check(CheckDependencies); check == nil {
+				t.Errorf("dependency check not recorded")
+			}
+			if tt.wantAI != "" {
+				check := result.Check(CheckAI)
+				if check == nil || check.Status != tt.wantAI {
+					t.Errorf("AI check = %+v, want status %s", check, tt.wantAI)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// Git file modes.
+const (
+	modeFile       = "100644"
+	modeExecutable = "100755"
+	modeSymlink    = "120000"
+	modeSubmodule  = "160000"
+)
+
+// File statuses reported by GitHub.
+const (
+	fileRemoved = "removed"
+	fileRenamed = "renamed"
+	fileCopied  = "copied"
+)
+
+// submodulePatch matches the patch line GitHub shows for a submodule pointer change.
+var submodulePatch = regexp.MustCompile(`(?m)^[-+]Subproject commit [0-9a-f]{40}$`)
+
+// fileModes are the git modes of a PR's files at its base and head commits, keyed by
+// path. A path is missing from a side where the file does not exist.
+type fileModes struct {
+	base, head map[string]string
+}
+
+// modes returns the base and head modes of a file, following renames.
+func (m *fileModes) modes(file *github.CommitFile) (base, head string) {
+	basePath := file.GetFilename()
+	if previous := file.GetPreviousFilename(); previous != "" {
+		basePath = previous
+	}
+	return m.base[basePath], m.head[file.GetFilename()]
+}
+
+// checkFileMetadata records the checks on what GitHub reports about each file besides
+// its patch: deletions, renames, mode changes, symlinks, submodules and missing patches.
+// It reports whether they all passed.
+func (a *Analyzer) checkFileMetadata(ctx context.Context, owner, repo string, pr *github.PullRequest, files []*github.CommitFile, result *Result) bool {
+	if !a.checkDeletedFiles(files, result) || !a.checkRenames(files, result) {
+		return false
+	}
+	modes, ok := a.fileModes(ctx, owner, repo, pr, files, result)
+	if !ok {
+		return false
+	}
+	return a.checkModes(files, modes, result) &&
+		a.checkSymlinks(files, modes, result) &&
+		a.checkSubmodules(files, modes, result) &&
+		a.checkBinaryFiles(ctx, owner, repo, pr, files, result)
+}
+
+// checkDeletedFiles records a CheckFileDeleted check rejecting removed files.
+func (a *Analyzer) checkDeletedFiles(files []*github.CommitFile, result *Result) bool {
+	start := time.Now()
+	var deleted []string
+	for _, file := range files {
+		if file.GetStatus() == fileRemoved {
+			deleted = append(deleted, fmt.Sprintf("%s: deleted", file.GetFilename()))
+		}
+	}
+	if len(deleted) > 0 {
+		result.fail(CheckFileDeleted, start, "File deletions require manual review", len(deleted), 0, deleted...)
+		return false
+	}
+	result.pass(CheckFileDeleted, start, 0, 0)
+	return true
+}
+
+// checkRenames records a CheckFileRenames check applying the deny rules and protected
+// paths to both the old and the new path of renamed and copied files, so a file cannot
+// be moved into or out of a workflow, CI or script path unnoticed.
+func (a *Analyzer) checkRenames(files []*github.CommitFile, result *Result) bool {
+	start := time.Now()
+	renames := 0
+	var reason string
+	var details []string
+	for _, file := range files {
+		if status := file.GetStatus(); status != fileRenamed && status != fileCopied {
+			continue
+		}
+		renames++
+		move := fmt.Sprintf("%s -> %s", file.GetPreviousFilename(), file.GetFilename())
+		for _, name := range []string{file.GetPreviousFilename(), file.GetFilename()} {
+			if name == "" {
+				continue
+			}
+			if pattern, denied := a.config.PathRules.Denied(name); denied {
+				reason = firstNonEmpty(reason, "File path is denied by policy")
+				details = append(details, fmt.Sprintf("%s: %s matches deny rule %q", move, name, pattern))
+			} else if protected := a.config.PathRules.Protected(name); protected != nil {
+				reason = firstNonEmpty(reason, protected.Reason)
+				details = append(details, fmt.Sprintf("%s: %s", move, protected.Detail))
+			}
+		}
+	}
+	if len(details) > 0 {
+		result.fail(CheckFileRenames, start, reason, len(details), 0, details...)
+		return false
+	}
+	result.pass(CheckFileRenames, start, renames, nil)
+	return true
+}
+
+// fileModes fetches the modes of the PR's files. It returns nil modes, and records nothing,
+// if the PR's commits are unknown.
+func (a *Analyzer) fileModes(ctx context.Context, owner, repo string, pr *github.PullRequest, files []*github.CommitFile, result *Result) (*fileModes, bool) {
+	baseSHA, headSHA := pr.GetBase().GetSHA(), pr.GetHead().GetSHA()
+	if baseSHA == "" || headSHA == "" {
+		return nil, true
+	}
+
+	start := time.Now()
+	var basePaths, headPaths []string
+	for _, file := range files {
+		if previous := file.GetPreviousFilename(); previous != "" {
+			basePaths = append(basePaths, previous)
+		} else {
+			basePaths = append(basePaths, file.GetFilename())
+		}
+		headPaths = append(headPaths, file.GetFilename())
+	}
+
+	base, err := a.gh.FileModes(ctx, owner, repo, baseSHA, basePaths)
+	if err == nil {
+		var head map[string]string
+		head, err = a.gh.FileModes(ctx, owner, repo, headSHA, headPaths)
+		if err == nil {
+			return &fileModes{base: base, head: head}, true
+		}
+	}
+	log.Printf("[ANALYZER] Failed to fetch file modes for %s/%s: %v", owner, repo, err)
+	result.errored(CheckFileModes, start, "Unable to verify file modes", fmt.Sprintf("File mode error: %v", err))
+	return nil, false
+}
+
+// checkModes records a CheckFileModes check rejecting mode changes of regular files,
+// such as an executable bit flip.
+func (a *Analyzer) checkModes(files []*github.CommitFile, modes *fileModes, result *Result) bool {
+	start := time.Now()
+	if modes == nil {
+		result.skip(CheckFileModes, start, "PR commits unknown")
+		return true
+	}
+	var changed []string
+	for _, file := range files {
+		base, head := modes.modes(file)
+		if isRegularMode(base) && isRegularMode(head) && base != head {
+			changed = append(changed, fmt.Sprintf("%s: mode %s -> %s", file.GetFilename(), base, head))
+		}
+	}
+	if len(changed) > 0 {
+		result.fail(CheckFileModes, start, "File mode changes require manual review", len(changed), 0, changed...)
+		return false
+	}
+	result.pass(CheckFileModes, start, 0, 0)
+	return true
+}
+
+// isRegularMode reports whether a git mode is that of a regular file.
+func isRegularMode(mode string) bool {
+	return mode == modeFile || mode == modeExecutable
+}
+
+// checkSymlinks records a CheckFileSymlinks check rejecting any change to a symlink,
+// including a file becoming one, since a symlink can expose files outside the change.
+func (a *Analyzer) checkSymlinks(files []*github.CommitFile, modes *fileModes, result *Result) bool {
+	start := time.Now()
+	if modes == nil {
+		result.skip(CheckFileSymlinks, start, "PR commits unknown")
+		return true
+	}
+	var symlinks []string
+	for _, file := range files {
+		if base, head := modes.modes(file); base == modeSymlink || head == modeSymlink {
+			symlinks = append(symlinks, fmt.Sprintf("%s: symlink", file.GetFilename()))
+		}
+	}
+	if len(symlinks) > 0 {
+		result.fail(CheckFileSymlinks, start, "Symlink changes require manual review", len(symlinks), 0, symlinks...)
+		return false
+	}
+	result.pass(CheckFileSymlinks, start, 0, 0)
+	return true
+}
+
+// checkSubmodules records a CheckFileSubmodules check rejecting submodule pointer changes,
+// which pull in code the patch doesn't show. Submodules are recognized by their mode or,
+// without modes, by their "Subproject commit" patch.
+func (a *Analyzer) checkSubmodules(files []*github.CommitFile, modes *fileModes, result *Result) bool {
+	start := time.Now()
+	var submodules []string
+	for _, file := range files {
+		isSubmodule := submodulePatch.MatchString(file.GetPatch())
+		if modes != nil {
+			base, head := modes.modes(file)
+			isSubmodule = isSubmodule || base == modeSubmodule || head == modeSubmodule
+		}
+		if isSubmodule {
+			submodules = append(submodules, fmt.Sprintf("%s: submodule", file.GetFilename()))
+		}
+	}
+	if len(submodules) > 0 {
+		result.fail(CheckFileSubmodules, start, "Submodule changes require manual review", len(submodules), 0, submodules...)
+		return false
+	}
+	result.pass(CheckFileSubmodules, start, 0, 0)
+	return true
+}
+
+// checkBinaryFiles records a CheckFileBinary check on the files whose patch GitHub omits,
+// which are binary or very large. Their full contents must be text within the size limit;
+// the diff of the contents then replaces the patch for the following checks.
+func (a *Analyzer) checkBinaryFiles(ctx context.Context, owner, repo string, pr *github.PullRequest, files []*github.CommitFile, result *Result) bool {
+	start := time.Now()
+	missing := 0
+	for _, file := range files {
+		if file.Patch != nil {
+			continue
+		}
+		missing++
+		patch, reason, detail := a.patchFromContents(ctx, owner, repo, pr, file, result)
+		if reason != "" {
+			result.fail(CheckFileBinary, start, reason, nil, nil, detail)
+			return false
+		}
+		file.Patch = github.String(patch)
+	}
+	result.pass(CheckFileBinary, start, missing, nil)
+	return true
+}
+
+// firstNonEmpty returns a if it is set, or b.
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
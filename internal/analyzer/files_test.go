@@ -0,0 +1,149 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestAnalyzePullRequest_FileMetadata(t *testing.T) {
+	docPatch := "@@ -1,2 +1,2 @@\n # Guide\n-Teh guide\n+The guide"
+
+	tests := []struct {
+		name       string
+		file       *github.CommitFile
+		modes      map[string]string
+		contents   map[string]string
+		wantCheck  string // The failing check, or "" if all file checks pass
+		wantReason string
+	}{
+		{
+			name:  "modified file",
+			file:  &github.CommitFile{Filename: github.String("docs/guide.md"), Status: github.String("modified"), Patch: github.String(docPatch)},
+			modes: map[string]string{"docs/guide.md@base": modeFile, "docs/guide.md@head": modeFile},
+		},
+		{
+			name:       "deleted file",
+			file:       &github.CommitFile{Filename: github.String("docs/old.md"), Status: github.String("removed"), Patch: github.String("@@ -1,1 +0,0 @@\n-# Old")},
+			wantCheck:  CheckFileDeleted,
+			wantReason: "File deletions require manual review",
+		},
+		{
+			name: "plain rename",
+			file: &github.CommitFile{
+				Filename: github.String("docs/guide.md"), PreviousFilename: github.String("guide.md"),
+				Status: github.String("renamed"), Patch: github.String(docPatch),
+			},
+			modes: map[string]string{"guide.md@base": modeFile, "docs/guide.md@head": modeFile},
+		},
+		{
+			name: "rename into workflows",
+			file: &github.CommitFile{
+				Filename: github.String(".github/workflows/release.yml"), PreviousFilename: github.String("docs/release.yml"),
+				Status: github.String("renamed"),
+			},
+			wantCheck:  CheckFileRenames,
+			wantReason: "GitHub Actions workflow changes require manual review",
+		},
+		{
+			name: "rename out of workflows",
+			file: &github.CommitFile{
+				Filename: github.String("docs/ci.yml"), PreviousFilename: github.String(".github/workflows/ci.yml"),
+				Status: github.String("renamed"),
+			},
+			wantCheck:  CheckFileRenames,
+			wantReason: "GitHub Actions workflow changes require manual review",
+		},
+		{
+			name: "rename of a script",
+			file: &github.CommitFile{
+				Filename: github.String("docs/setup.txt"), PreviousFilename: github.String("setup.sh"),
+				Status: github.String("renamed"),
+			},
+			wantCheck:  CheckFileRenames,
+			wantReason: "Shell script modifications require manual review",
+		},
+		{
+			name:       "executable bit flip",
+			file:       &github.CommitFile{Filename: github.String("docs/guide.md"), Status: github.String("modified"), Patch: github.String(docPatch)},
+			modes:      map[string]string{"docs/guide.md@base": modeFile, "docs/guide.md@head": modeExecutable},
+			wantCheck:  CheckFileModes,
+			wantReason: "File mode changes require manual review",
+		},
+		{
+			name:       "new symlink",
+			file:       &github.CommitFile{Filename: github.String("docs/latest.md"), Status: github.String("added"), Patch: github.String("@@ -0,0 +1 @@\n+../../etc/passwd\n\\ No newline at end of file")},
+			modes:      map[string]string{"docs/latest.md@head": modeSymlink},
+			wantCheck:  CheckFileSymlinks,
+			wantReason: "Symlink changes require manual review",
+		},
+		{
+			name: "submodule bump",
+			file: &github.CommitFile{
+				Filename: github.String("vendor/lib"), Status: github.String("modified"),
+				Patch: github.String("@@ -1 +1 @@\n-Subproject commit 1111111111111111111111111111111111111111\n+Subproject commit 2222222222222222222222222222222222222222"),
+			},
+			wantCheck:  CheckFileSubmodules,
+			wantReason: "Submodule changes require manual review",
+		},
+		{
+			name:       "binary blob",
+			file:       &github.CommitFile{Filename: github.String("docs/logo.png"), Status: github.String("modified")},
+			modes:      map[string]string{"docs/logo.png@base": modeFile, "docs/logo.png@head": modeFile},
+			contents:   map[string]string{"docs/logo.png@base": "\x89PNG\x00", "docs/logo.png@head": "\x89PNG\x00\x01"},
+			wantCheck:  CheckFileBinary,
+			wantReason: "Binary file changes require manual review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(1),
+				Deletions:         github.Int(1),
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              &github.User{Login: github.String("testuser")},
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+				Base:              &github.PullRequestBranch{SHA: github.String("base")},
+				Head:              &github.PullRequestBranch{SHA: github.String("head")},
+			}
+			gh := &mockGitHubAPI{pr: pr, files: []*github.CommitFile{tt.file}, modes: tt.modes, contents: tt.contents}
+
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			a, err := New(gh, &mockGeminiAPI{result: &geminiAnalysisResult{Category: "typo"}}, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+
+			if tt.wantCheck == "" {
+				for _, id := range []string{CheckFileDeleted, CheckFileRenames, CheckFileModes, CheckFileSymlinks, CheckFileSubmodules, CheckFileBinary} {
+					if check := result.Check(id); check == nil || check.Status != CheckPass {
+						t.Errorf("%s = %+v, want pass", id, check)
+					}
+				}
+				if !result.Approvable {
+					t.Errorf("Approvable = false, reason: %s", result.Reason)
+				}
+				return
+			}
+			check := result.Check(tt.wantCheck)
+			if check == nil || check.Status != CheckFail {
+				t.Fatalf("%s = %+v, want fail (reason %q)", tt.wantCheck, check, result.Reason)
+			}
+			if result.Approvable || result.Reason != tt.wantReason {
+				t.Errorf("Approvable = %v, Reason = %q, want %q", result.Approvable, result.Reason, tt.wantReason)
+			}
+		})
+	}
+}
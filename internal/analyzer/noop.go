@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// noOpDetail describes a file whose change was proved not to alter behavior.
+const noOpDetail = "proved no-op (syntax tree unchanged)"
+
+// provedNoOp reports whether a Go file's change leaves its syntax tree unchanged,
+// comparing the file at the PR's base and head commits. Such changes are limited to
+// formatting, comments and import order, so no AI review is needed. A successful
+// proof is recorded as a CheckCodeNoOp check and in result.ProvedNoOp.
+func (a *Analyzer) provedNoOp(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, result *Result) bool {
+	filename := file.GetFilename()
+	if security.DetectLanguage(filename) != security.LanguageGo || pr.GetBase().GetSHA() == "" || pr.GetHead().GetSHA() == "" {
+		return false
+	}
+	start := time.Now()
+
+	base, head, err := a.compareFile(ctx, owner, repo, pr, file, result)
+	if err != nil {
+		log.Printf("[ANALYZER] Cannot prove %s is a no-op: %v", filename, err)
+		return false
+	}
+	if base == nil || head == nil {
+		return false // Added or removed
+	}
+
+	equivalent, err := security.GoEquivalent(base, head)
+	if err != nil {
+		log.Printf("[ANALYZER] Cannot prove %s is a no-op: %v", filename, err)
+		return false
+	}
+	if !equivalent {
+		return false
+	}
+
+	log.Printf("[ANALYZER] %s: %s", filename, noOpDetail)
+	result.ProvedNoOp = append(result.ProvedNoOp, filename)
+	result.pass(CheckCodeNoOp, start, filename, nil, fmt.Sprintf("%s: %s", filename, noOpDetail))
+	return true
+}
+
+// commentsOnlyInHead reports whether a change adds only comments and whitespace, lexing
+// its added lines as part of the file at the PR's head commit, where a hunk's state at
+// its first line is known. known is false if the file has no lexer or its contents
+// can't be fetched, leaving the patch's own conservative verdict.
+func (a *Analyzer) commentsOnlyInHead(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, patch string, result *Result) (commentsOnly, known bool) {
+	filename := file.GetFilename()
+	if security.DetectLanguage(filename) == "" || pr.GetBase().GetSHA() == "" || pr.GetHead().GetSHA() == "" {
+		return false, false
+	}
+
+	_, head, err := a.compareFile(ctx, owner, repo, pr, file, result)
+	if err != nil {
+		log.Printf("[ANALYZER] Cannot lex %s in full: %v", filename, err)
+		return false, false
+	}
+	if head == nil {
+		return false, false // Removed
+	}
+	return security.CommentsOnlyInFile(patch, filename, head), true
+}
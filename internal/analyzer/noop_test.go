@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestAnalyzePullRequest_ProvedNoOp(t *testing.T) {
+	const (
+		base      = "package main\n\nfunc main() {\n\tprintln(1)\n}\n"
+		formatted = "package main\n\n// main prints 1.\nfunc main() {\n\tprintln( 1 )\n}\n"
+		changed   = "package main\n\nfunc main() {\n\tprintln(2)\n}\n"
+	)
+
+	tests := []struct {
+		name           string
+		head           string
+		wantApprovable bool
+		wantReason     string
+	}{
+		{
+			name:           "formatting only",
+			head:           formatted,
+			wantApprovable: true,
+		},
+		{
+			name:       "behavior change",
+			head:       changed,
+			wantReason: "Code changes contain security risks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				State:             github.String("open"),
+				Draft:             github.Bool(false),
+				ChangedFiles:      github.Int(1),
+				Additions:         github.Int(2),
+				Deletions:         github.Int(1),
+				UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+				User:              &github.User{Login: github.String("testuser")},
+				AuthorAssociation: github.String("CONTRIBUTOR"),
+				Base:              &github.PullRequestBranch{SHA: github.String("base")},
+				Head:              &github.PullRequestBranch{SHA: github.String("head")},
+			}
+			files := []*github.CommitFile{{
+				Filename: github.String("main.go"),
+				Patch:    github.String("@@ -1,4 +1,5 @@\n package main\n \n+// main prints 1.\n func main() {\n-\tprintln(1)\n+\tprintln( 1 )\n }"),
+			}}
+			gh := &mockGitHubAPI{pr: pr, files: files, contents: map[string]string{
+				"main.go@base": base,
+				"main.go@head": tt.head,
+			}}
+
+			config := DefaultConfig()
+			config.RequirePassingChecks = false
+			// Without AI, only a proved no-op can be approved
+			a, err := New(gh, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := a.AnalyzePullRequest(context.Background(), "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("AnalyzePullRequest() error = %v", err)
+			}
+			if result.Approvable != tt.wantApprovable {
+				t.Fatalf("Approvable = %v (%s), want %v", result.Approvable, result.Reason, tt.wantApprovable)
+			}
+			if tt.wantReason != "" && result.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", result.Reason, tt.wantReason)
+			}
+
+			proved := result.Check(CheckCodeNoOp) != nil
+			if proved != tt.wantApprovable || (len(result.ProvedNoOp) == 1) != tt.wantApprovable {
+				t.Errorf("no-op check recorded = %v, ProvedNoOp = %v", proved, result.ProvedNoOp)
+			}
+			if tt.wantApprovable && result.Check(CheckAI).Status != CheckSkip {
+				t.Errorf("AI check = %+v, want skipped", result.Check(CheckAI))
+			}
+		})
+	}
+}
+
+func TestValidateCodeChanges_LexesHead(t *testing.T) {
+	tests := []struct {
+		name       string
+		head       string
+		patch      string
+		wantReason string
+	}{
+		{
+			name:       "hunk starting inside a string",
+			head:       "def greet\n  text = \"Hello,\n  world\n  # drop users\n  \"\n  puts text\nend\n",
+			patch:      "@@ -3,2 +3,3 @@\n   world\n+  # drop users\n   \"",
+			wantReason: "Code changes could alter program behavior",
+		},
+		{
+			name:  "comment with a quote",
+			head:  "def greet\n  # don't shout\n  puts \"hi\"\nend\n",
+			patch: "@@ -1,2 +1,3 @@\n def greet\n+  # don't shout\n   puts \"hi\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &github.PullRequest{
+				User: &github.User{Login: github.String("testuser")},
+				Base: &github.PullRequestBranch{SHA: github.String("base")},
+				Head: &github.PullRequestBranch{SHA: github.String("head")},
+			}
+			files := []*github.CommitFile{{Filename: github.String("greet.rb"), Patch: github.String(tt.patch)}}
+			gh := &mockGitHubAPI{contents: map[string]string{"greet.rb@head": tt.head}}
+
+			a, err := New(gh, nil, DefaultConfig())
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+			reason, details, _ := a.validateCodeChanges(context.Background(), pr, "owner", "repo", files, &Result{})
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q (%v), want %q", reason, details, tt.wantReason)
+			}
+		})
+	}
+}
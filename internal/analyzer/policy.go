@@ -0,0 +1,201 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyPath is the repository file holding per-repository overrides of the analyzer configuration.
+// It is always read from the PR's base branch so a PR cannot relax its own rules.
+const PolicyPath = ".github/auto-approve.yml"
+
+// PolicyVersion is the only supported policy schema version.
+const PolicyVersion = 1
+
+// validRoles are the repository permission levels accepted in trusted_roles.
+var validRoles = map[string]bool{
+	"admin":    true,
+	"maintain": true,
+	"write":    true,
+	"triage":   true,
+	"read":     true,
+}
+
+// Policy is a repository-level override of Config.
+// Fields left unset keep the value from the command line.
+//
+// Example:
+//
+//	version: 1
+//	max_files: 20
+//	max_lines: 500
+//	min_open_time: 1h
+//	max_open_time: 720h
+//	trusted_users: [alice, bob]
+//	trusted_roles: [maintain]
+//	code_owners: enforce
+//	allowed_owners: ["@org/docs"]
+//	bots:
+//	  - login: release-bot[bot]
+//	    profile: generated-files
+//	    paths: ["CHANGELOG.md", "dist/**"]
+//	paths:
+//	  deny: ["**/testdata/**"]
+//	  allow: ["docs/**"]
+//	  overrides:
+//	    - pattern: "i18n/*.json"
+//	      type: data
+//	    - pattern: "docs/ja/**"
+//	      scripts: [Han, Hiragana, Katakana]
+type Policy struct {
+	Version      int            `yaml:"version"`
+	MaxFiles     *int           `yaml:"max_files"`
+	MaxLines     *int           `yaml:"max_lines"`
+	MinOpenTime  *time.Duration `yaml:"min_open_time"`
+	MaxOpenTime  *time.Duration `yaml:"max_open_time"`
+	TrustedUsers []string       `yaml:"trusted_users"`
+	TrustedRoles []string       `yaml:"trusted_roles"`
+
+	// CodeOwners and AllowedOwners set how files with code owners are treated.
+	CodeOwners    *CodeOwnersMode `yaml:"code_owners"`
+	AllowedOwners []string        `yaml:"allowed_owners"`
+
+	// Bots are added to the bot registry, replacing bots with the same login.
+	Bots []Bot `yaml:"bots"`
+
+	// Paths replaces the path rules when set.
+	Paths *security.PathRules `yaml:"paths"`
+}
+
+// ParsePolicy decodes a policy file. Unknown keys and unsupported versions are errors.
+// An empty file yields an empty policy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil {
+		if stderrors.Is(err, io.EOF) {
+			return p, nil
+		}
+		return nil, err
+	}
+
+	if p.Version != PolicyVersion {
+		return nil, errors.Validation("version", p.Version, fmt.Sprintf("must be %d", PolicyVersion))
+	}
+	for _, role := range p.TrustedRoles {
+		if !validRoles[strings.ToLower(role)] {
+			return nil, errors.Validation("trusted_roles", role, "must be one of admin, maintain, write, triage, read")
+		}
+	}
+	for _, user := range p.TrustedUsers {
+		if strings.TrimSpace(user) == "" {
+			return nil, errors.Validation("trusted_users", user, "must not be empty")
+		}
+	}
+
+	return p, nil
+}
+
+// Apply returns a copy of base with the policy's fields merged over it.
+// The result is validated with Config.Validate.
+func (p *Policy) Apply(base *Config) (*Config, error) {
+	config := *base
+
+	if p.MaxFiles != nil {
+		config.MaxFiles = *p.MaxFiles
+	}
+	if p.MaxLines != nil {
+		config.MaxLines = *p.MaxLines
+	}
+	if p.MinOpenTime != nil {
+		config.MinOpenTime = *p.MinOpenTime
+	}
+	if p.MaxOpenTime != nil {
+		config.MaxOpenTime = *p.MaxOpenTime
+	}
+	if p.TrustedUsers != nil {
+		config.TrustedUsers = p.TrustedUsers
+	}
+	if p.TrustedRoles != nil {
+		config.TrustedRoles = p.TrustedRoles
+	}
+	if p.CodeOwners != nil {
+		config.CodeOwners = *p.CodeOwners
+	}
+	if p.AllowedOwners != nil {
+		config.AllowedOwners = p.AllowedOwners
+	}
+	if p.Paths != nil {
+		config.PathRules = p.Paths
+	}
+	if p.Bots != nil {
+		bots := config.Bots
+		if bots == nil {
+			bots = DefaultBots
+		}
+		config.Bots = MergeBots(bots, p.Bots)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// withConfig returns a shallow copy of the analyzer that uses config.
+func (a *Analyzer) withConfig(config *Config) *Analyzer {
+	clone := *a
+	clone.config = config
+	clone.codeValidator = security.NewCodeValidatorWithRules(true, config.PathRules)
+	return &clone
+}
+
+// loadPolicy reads the repository policy from the base branch and returns the
+// effective configuration. A missing policy file yields the analyzer's config.
+func (a *Analyzer) loadPolicy(ctx context.Context, owner, repo, baseRef string) (*Config, error) {
+	data, err := a.gh.FileContents(ctx, owner, repo, PolicyPath, baseRef)
+	if stderrors.Is(err, errors.ErrFileNotFound) {
+		return a.config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", PolicyPath, err)
+	}
+
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, &policyError{err: err}
+	}
+
+	config, err := policy.Apply(a.config)
+	if err != nil {
+		return nil, &policyError{err: err}
+	}
+
+	log.Printf("[ANALYZER] Applied %s from %s/%s@%s", PolicyPath, owner, repo, baseRef)
+	return config, nil
+}
+
+// policyError indicates that the policy file exists but is invalid.
+type policyError struct {
+	err error
+}
+
+func (e *policyError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", PolicyPath, e.err)
+}
+
+func (e *policyError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,270 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "full policy",
+			data: "version: 1\nmax_files: 20\nmax_lines: 500\nmin_open_time: 1h\nmax_open_time: 720h\ntrusted_users: [alice]\ntrusted_roles: [maintain]\ncode_owners: required\nallowed_owners: [\"@org/docs\"]\n",
+		},
+		{
+			name: "empty file",
+			data: "",
+		},
+		{
+			name:    "missing version",
+			data:    "max_files: 20\n",
+			wantErr: "version",
+		},
+		{
+			name:    "unknown key",
+			data:    "version: 1\nmax_file: 20\n",
+			wantErr: "max_file",
+		},
+		{
+			name:    "bad duration",
+			data:    "version: 1\nmin_open_time: soon\n",
+			wantErr: "soon",
+		},
+		{
+			name: "path rules",
+			data: "version: 1\npaths:\n  deny: [\"**/testdata/**\"]\n  overrides:\n    - pattern: i18n/*.json\n      type: data\n",
+		},
+		{
+			name:    "unknown path rule key",
+			data:    "version: 1\npaths:\n  ignore: [vendor]\n",
+			wantErr: "ignore",
+		},
+		{
+			name:    "bad role",
+			data:    "version: 1\ntrusted_roles: [owner]\n",
+			wantErr: "trusted_roles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParsePolicy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePolicy() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	base := DefaultConfig()
+	base.TrustedUsers = []string{"cli-user"}
+
+	policy, err := ParsePolicy([]byte("version: 1\nmax_files: 20\nmin_open_time: 30m\ntrusted_roles: [write]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	config, err := policy.Apply(base)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if config.MaxFiles != 20 || config.MinOpenTime != 30*time.Minute {
+		t.Errorf("overrides not applied: MaxFiles=%d MinOpenTime=%v", config.MaxFiles, config.MinOpenTime)
+	}
+	if config.MaxLines != base.MaxLines {
+		t.Errorf("MaxLines = %d, want CLI value %d", config.MaxLines, base.MaxLines)
+	}
+	if len(config.TrustedUsers) != 1 || config.TrustedUsers[0] != "cli-user" {
+		t.Errorf("TrustedUsers = %v, want CLI value", config.TrustedUsers)
+	}
+	if base.MaxFiles != DefaultConfig().MaxFiles {
+		t.Error("Apply() must not modify the base config")
+	}
+
+	invalid, err := ParsePolicy([]byte("version: 1\nmax_files: 0\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if _, err := invalid.Apply(base); err == nil {
+		t.Error("Apply() should run Config.Validate")
+	}
+
+	owners, err := ParsePolicy([]byte("version: 1\ncode_owners: strict\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if _, err := owners.Apply(base); err == nil {
+		t.Error("Apply() should reject an unknown code_owners mode")
+	}
+
+	bots, err := ParsePolicy([]byte("version: 1\nbots:\n  - login: release-bot[bot]\n    profile: generated-files\n    paths: [CHANGELOG.md]\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	config, err = bots.Apply(base)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(config.Bots) != len(DefaultBots)+1 || config.Bots[len(DefaultBots)].Login != "release-bot[bot]" {
+		t.Errorf("Bots = %v, want the defaults and release-bot[bot]", config.Bots)
+	}
+}
+
+func TestAnalyzePullRequest_Policy(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		contents   map[string]string
+		wantOK     bool
+		wantReason string
+	}{
+		{
+			name:       "no policy uses CLI limits",
+			wantReason: "Too many files changed (3 > 2)",
+		},
+		{
+			name:     "base branch policy raises limit",
+			contents: map[string]string{PolicyPath + "@main": "version: 1\nmax_files: 5\n"},
+			wantOK:   true,
+		},
+		{
+			name:       "head branch policy is ignored",
+			contents:   map[string]string{PolicyPath + "@relax-limits": "version: 1\nmax_files: 5\n"},
+			wantReason: "Too many files changed (3 > 2)",
+		},
+		{
+			name:       "invalid policy is a rejection",
+			contents:   map[string]string{PolicyPath + "@main": "version: 2\n"},
+			wantReason: "Invalid repository policy (" + PolicyPath + ")",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGH := &mockGitHubAPI{
+				pr: &github.PullRequest{
+					State:             github.String("open"),
+					Draft:             github.Bool(false),
+					ChangedFiles:      github.Int(3),
+					Additions:         github.Int(3),
+					Deletions:         github.Int(3),
+					UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+					User:              &github.User{Login: github.String("testuser")},
+					AuthorAssociation: github.String("CONTRIBUTOR"),
+					Base:              &github.PullRequestBranch{Ref: github.String("main")},
+					Head:              &github.PullRequestBranch{Ref: github.String("relax-limits")},
+				},
+				files: []*github.CommitFile{
+					{Filename: github.String("README.md"), Patch: github.String("@@ -1 +1 @@\n-old\n+new")},
+					{Filename: github.String("CONTRIBUTING.md"), Patch: github.String("@@ -1 +1 @@\n-old\n+new")},
+					{Filename: github.String("docs/guide.md"), Patch: github.String("@@ -1 +1 @@\n-old\n+new")},
+				},
+				contents: tt.contents,
+			}
+
+			mockGemini := &mockGeminiAPI{
+				result: &geminiAnalysisResult{Category: "documentation"},
+			}
+
+			config := DefaultConfig()
+			config.MaxFiles = 2
+
+			analyzer, err := New(mockGH, mockGemini, config)
+			if err != nil {
+				t.Fatalf("Failed to create analyzer: %v", err)
+			}
+
+			result, err := analyzer.AnalyzePullRequest(ctx, "owner", "repo", 1)
+			if err != nil {
+				t.Fatalf("Failed to analyze PR: %v", err)
+			}
+
+			if result.Approvable != tt.wantOK {
+				t.Errorf("Approvable = %v, want %v (reason: %s)", result.Approvable, tt.wantOK, result.Reason)
+			}
+			if tt.wantReason != "" && result.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", result.Reason, tt.wantReason)
+			}
+			if config.MaxFiles != 2 {
+				t.Error("policy must not modify the analyzer's config")
+			}
+		})
+	}
+}
+
+func TestValidateCodeChanges_PathRules(t *testing.T) {
+	ctx := context.Background()
+	pr := &github.PullRequest{User: &github.User{Login: github.String("testuser")}}
+
+	rules := &security.PathRules{
+		Deny:  []string{"**/testdata/**"},
+		Allow: []string{"docs/**"},
+		Overrides: []security.PathOverride{
+			{Pattern: "i18n/*.json", Type: security.OverrideData},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		filename   string
+		patch      *string
+		wantReason string
+	}{
+		{
+			name:       "denied path without patch",
+			filename:   "pkg/testdata/blob.bin",
+			wantReason: "File path is denied by policy",
+		},
+		{
+			name:     "allowed docs path bypasses script heuristic",
+			filename: "docs/scripts.md",
+			patch:    github.String("@@ -1 +1 @@\n-Teh scripts\n+The scripts"),
+		},
+		{
+			name:     "translation data",
+			filename: "i18n/fr.json",
+			patch:    github.String("@@ -1 +1 @@\n-  \"hello\": \"bonjour\"\n+  \"hello\": \"salut, l'ami\""),
+		},
+		{
+			name:       "shell scripts stay protected",
+			filename:   "docs/install.sh",
+			patch:      github.String("@@ -1 +1 @@\n-# old\n+# new"),
+			wantReason: "Shell script modifications require manual review",
+		},
+	}
+
+	config := DefaultConfig()
+	config.PathRules = rules
+	a, err := New(&mockGitHubAPI{}, nil, config)
+	if err != nil {
+		t.Fatalf("Failed to create analyzer: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []*github.CommitFile{{Filename: github.String(tt.filename), Patch: tt.patch}}
+			reason, details, _ := a.validateCodeChanges(ctx, pr, "owner", "repo", files, &Result{})
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q (details: %v)", reason, tt.wantReason, details)
+			}
+		})
+	}
+}
@@ -200,7 +200,7 @@ func TestSecurityEdgeCases(t *testing.T) {
 				Deletions: github.Int(1),
 			}},
 			wantApprovable: false,
-			wantReason:    "Code changes could alter program behavior",
+			wantReason:    "Suspicious Unicode in added lines",
 		},
 
 		// GitHub-specific attacks
@@ -445,7 +445,7 @@ func TestGitHubSpecificProtections(t *testing.T) {
 	analyzer, err := New(mockGitHub, nil, &Config{
 		MaxFiles:    10,
 		MaxLines:    1000,
-		UseGemini:   false,
+		UseAI:       false,
 		MinOpenTime: 0,
 		MaxOpenTime: 0,
 	})
@@ -506,7 +506,7 @@ func TestDependabotGoModBump(t *testing.T) {
 			Number:    github.Int(42),
 			State:     github.String("open"),
 			Draft:     github.Bool(false),
-			User:      &github.User{Login: github.String("dependabot[bot]")},
+			User:      prAuthor("dependabot[bot]"),
 			CreatedAt: &github.Timestamp{Time: createdAt},
 			UpdatedAt: &github.Timestamp{Time: updatedAt},
 			Title:     github.String("Bump github.com/google/go-github/v68 from 68.0.0 to 68.1.0"),
@@ -553,7 +553,7 @@ func TestDependabotGoModBump(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 	config.MinOpenTime = 1 * time.Minute  // Require at least 1 minute open
 	config.MaxOpenTime = 24 * time.Hour    // Max 24 hours
 	
@@ -635,7 +635,7 @@ func TestGitignoreChanges(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 	config.MinOpenTime = 1 * time.Minute
 	
 	analyzer, err := New(mockGitHub, mockGemini, config)
@@ -707,7 +707,7 @@ func TestEditorConfigChanges(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 	config.MinOpenTime = 1 * time.Minute
 	
 	analyzer, err := New(mockGitHub, mockGemini, config)
@@ -774,7 +774,7 @@ func TestMinikubeBotImageUpdate(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 	config.UseMultiModel = true
 	config.PrimaryModel = "gemini-2.0-flash-exp"
 	config.SecondaryModel = "gemini-2.0-flash-exp" 
@@ -819,7 +819,7 @@ func TestDependabotSecurityUpdate(t *testing.T) {
 			Number:    github.Int(43),
 			State:     github.String("open"),
 			Draft:     github.Bool(false),
-			User:      &github.User{Login: github.String("dependabot[bot]")},
+			User:      prAuthor("dependabot[bot]"),
 			CreatedAt: &github.Timestamp{Time: createdAt},
 			UpdatedAt: &github.Timestamp{Time: updatedAt},
 			Title:     github.String("[Security] Bump golang.org/x/crypto from 0.14.0 to 0.17.0"),
@@ -859,7 +859,7 @@ func TestDependabotSecurityUpdate(t *testing.T) {
 	}
 
 	config := DefaultConfig()
-	config.UseGemini = true
+	config.UseAI = true
 	config.MinOpenTime = 1 * time.Minute
 	
 	analyzer, err := New(mockGitHub, mockGemini, config)
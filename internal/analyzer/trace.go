@@ -0,0 +1,206 @@
+package analyzer
+
+import (
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// CheckStatus is the outcome of a single gate.
+type CheckStatus string
+
+// Check statuses.
+const (
+	CheckPass  CheckStatus = "pass"  // The gate was evaluated and passed
+	CheckFail  CheckStatus = "fail"  // The gate was evaluated and blocks approval
+	CheckSkip  CheckStatus = "skip"  // The gate does not apply to this PR or is disabled
+	CheckError CheckStatus = "error" // The gate could not be evaluated; blocks approval
+
+	// CheckPending blocks approval until the PR is analyzed again, e.g. while required
+	// checks are running
+	CheckPending CheckStatus = "pending"
+)
+
+// Stable check IDs, in the order AnalyzePullRequest evaluates them.
+const (
+	CheckPRState              = "pr.state"
+	CheckStaleApprovals       = "reviews.stale" // Recorded only if our approvals of earlier commits can't be listed
+	CheckPolicy               = "policy.load"
+	CheckOwnPR                = "pr.own"
+	CheckDraft                = "pr.draft"
+	CheckMinOpenTime          = "age.min_open_time"
+	CheckMaxOpenTime          = "age.max_open_time"
+	CheckMaxFiles             = "size.max_files"
+	CheckMaxLines             = "size.max_lines"
+	CheckPRInfo               = "pr.info"
+	CheckReviews              = "reviews.existing"
+	CheckCollaboratorComments = "comments.collaborators"
+	CheckFirstTime            = "author.first_time"
+	CheckFiles                = "files.fetch"
+	CheckFileDeleted          = "files.deleted"
+	CheckFileRenames          = "files.renames" // Old and new paths of renamed and copied files
+	CheckFileModes            = "files.modes"   // Mode changes of regular files, e.g. the executable bit
+	CheckFileSymlinks         = "files.symlinks"
+	CheckFileSubmodules       = "files.submodules"
+	CheckFileBinary           = "files.binary"        // Files without a patch are text within the size limit
+	CheckCodeOwners           = "reviews.code_owners" // The bot may approve for the owners of every file
+	CheckCodeNoOp             = "code.proved_noop"    // A Go file's syntax tree is unchanged
+	CheckCodeConsensus        = "code.consensus"
+	CheckCodeValidation       = "code.validation"
+	CheckBotProfile           = "author.bot_profile" // A trusted bot's files fit its profile
+	CheckDependencies         = "deps.bumps"         // Versions of dependency bumps, compared deterministically
+	CheckCIRequired           = "ci.required"        // Status checks required by the base branch, or all without any
+	CheckCIOptional           = "ci.optional"        // Informational: the other status checks
+	CheckAI                   = "ai.analysis"
+	CheckAIAnomaly            = "ai.anomaly"     // The model's response deviates from its history
+	CheckAIIncremental        = "ai.incremental" // The commits pushed since our approval, analyzed on their own
+
+	// AI flags, in rejection priority order.
+	CheckAIMalicious         = "ai.possibly_malicious"
+	CheckAIVandalism         = "ai.vandalism"
+	CheckAIInsecure          = "ai.insecure_change"
+	CheckAIMajorVersionBump  = "ai.major_version_bump"
+	CheckAIRisky             = "ai.risky"
+	CheckAITitleDescMismatch = "ai.title_desc_mismatch"
+	CheckAIAltersBehavior    = "ai.alters_behavior"
+	CheckAINotImprovement    = "ai.not_improvement"
+	CheckAINonTrivial        = "ai.non_trivial"
+	CheckAIConfusing         = "ai.confusing"
+	CheckAISuperfluous       = "ai.superfluous"
+	CheckAICategory          = "ai.category"
+)
+
+// Check records the outcome of one gate in AnalyzePullRequest.
+type Check struct {
+	ID        string        `json:"id"`
+	Status    CheckStatus   `json:"status"`
+	Value     interface{}   `json:"value,omitempty"`     // The measured value, if any
+	Threshold interface{}   `json:"threshold,omitempty"` // The limit the value was compared against, if any
+	Message   string        `json:"message,omitempty"`   // Why the check failed, errored or was skipped
+	Details   []string      `json:"details,omitempty"`
+	Findings  []Finding     `json:"findings,omitempty"`
+	Cached    bool          `json:"cached,omitempty"` // A model verdict was reused from the verdict cache
+	Duration  time.Duration `json:"duration"`
+}
+
+// Finding locates a validation failure within a file.
+type Finding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line,omitempty"`       // Line in the new file, if known
+	PatchLine int    `json:"patch_line,omitempty"` // Line within the patch, if known
+	Message   string `json:"message"`
+}
+
+// patchFindings converts a ValidatePatch error into findings.
+func patchFindings(err error) []Finding {
+	var patchErr *security.PatchError
+	if !stderrors.As(err, &patchErr) {
+		return nil
+	}
+	return []Finding{{
+		File:      patchErr.Filename,
+		Line:      patchErr.Line,
+		PatchLine: patchErr.PatchLine,
+		Message:   patchErr.Err.Error(),
+	}}
+}
+
+// Failed reports whether the check blocks approval.
+func (c Check) Failed() bool {
+	return c.Status == CheckFail || c.Status == CheckError || c.Status == CheckPending
+}
+
+// Check returns the recorded check with the given ID, or nil.
+func (r *Result) Check(id string) *Check {
+	for i := range r.Checks {
+		if r.Checks[i].ID == id {
+			return &r.Checks[i]
+		}
+	}
+	return nil
+}
+
+// record appends a check to the trace.
+func (r *Result) record(check Check) {
+	r.Checks = append(r.Checks, check)
+}
+
+func (r *Result) pass(id string, start time.Time, value, threshold interface{}, details ...string) {
+	r.record(Check{ID: id, Status: CheckPass, Value: value, Threshold: threshold, Details: details, Duration: time.Since(start)})
+}
+
+func (r *Result) fail(id string, start time.Time, message string, value, threshold interface{}, details ...string) {
+	r.record(Check{ID: id, Status: CheckFail, Value: value, Threshold: threshold, Message: message, Details: details, Duration: time.Since(start)})
+}
+
+func (r *Result) skip(id string, start time.Time, message string) {
+	r.record(Check{ID: id, Status: CheckSkip, Message: message, Duration: time.Since(start)})
+}
+
+func (r *Result) pending(id string, start time.Time, message string, value, threshold interface{}, details ...string) {
+	r.record(Check{ID: id, Status: CheckPending, Value: value, Threshold: threshold, Message: message, Details: details, Duration: time.Since(start)})
+}
+
+func (r *Result) errored(id string, start time.Time, message string, details ...string) {
+	r.record(Check{ID: id, Status: CheckError, Message: message, Details: details, Duration: time.Since(start)})
+}
+
+// failOrError records a failure, or an error when the reason reports that the gate
+// could not be evaluated (e.g. "error checking reviews ...").
+func (r *Result) failOrError(id string, start time.Time, reason string, value, threshold interface{}, details ...string) {
+	if strings.HasPrefix(reason, "error ") {
+		r.errored(id, start, reason, details...)
+		return
+	}
+	r.fail(id, start, reason, value, threshold, details...)
+}
+
+// Final reports whether the decision holds until the PR changes. A decision that was
+// deferred or could not be made is not final, nor is one resting on what changes on its
+// own: the PR's age and failing status checks, which can be re-run.
+func (r *Result) Final() bool {
+	for _, check := range r.Checks {
+		switch {
+		case check.Status == CheckError || check.Status == CheckPending:
+			return false
+		case check.Status == CheckFail && (check.ID == CheckMinOpenTime || check.ID == CheckCIRequired):
+			return false
+		}
+	}
+	return true
+}
+
+// derive sets Approvable, Reason and Details from the recorded checks.
+// The first failing check gives the reason, and defers the decision if it is pending;
+// details are concatenated in order.
+func (r *Result) derive() {
+	r.Approvable = true
+	r.Reason = ""
+	r.Details = nil
+	r.NeedsHuman = false
+	r.Anomalies = nil
+	r.Deferred = false
+	r.CachedVerdicts = nil
+
+	for _, check := range r.Checks {
+		r.Details = append(r.Details, check.Details...)
+		if r.Approvable && check.Failed() {
+			r.Approvable = false
+			r.Reason = check.Message
+			r.Deferred = check.Status == CheckPending
+		}
+		if check.ID == CheckAIAnomaly && check.Failed() {
+			r.NeedsHuman = true
+			r.Anomalies = append(r.Anomalies, check.Details...)
+		}
+		if check.Cached {
+			r.CachedVerdicts = append(r.CachedVerdicts, check.ID)
+		}
+	}
+
+	if r.Approvable && len(r.Details) > 0 {
+		r.Reason = "All checks passed"
+	}
+}
@@ -0,0 +1,198 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestResultDerive(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantOK     bool
+		wantReason string
+		wantDetail int
+		wantDefer  bool
+	}{
+		{
+			name:   "no checks",
+			wantOK: true,
+		},
+		{
+			name: "passes with details",
+			checks: []Check{
+				{ID: CheckPRState, Status: CheckPass},
+				{ID: CheckPRInfo, Status: CheckPass, Details: []string{"Title: Fix typo"}},
+				{ID: CheckDraft, Status: CheckSkip, Message: "draft PRs allowed"},
+			},
+			wantOK:     true,
+			wantReason: "All checks passed",
+			wantDetail: 1,
+		},
+		{
+			name: "first failure is the reason",
+			checks: []Check{
+				{ID: CheckPRInfo, Status: CheckPass, Details: []string{"Title: Fix typo"}},
+				{ID: CheckCIRequired, Status: CheckError, Message: "Unable to verify CI status", Details: []string{"CI status error: boom"}},
+				{ID: CheckAIRisky, Status: CheckFail, Message: "Changes are high risk"},
+			},
+			wantReason: "Unable to verify CI status",
+			wantDetail: 2,
+		},
+		{
+			name: "pending check defers",
+			checks: []Check{
+				{ID: CheckCIRequired, Status: CheckPending, Message: "Waiting for required checks"},
+				{ID: CheckCIOptional, Status: CheckPass},
+			},
+			wantReason: "Waiting for required checks",
+			wantDefer:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Result{Checks: tt.checks}
+			r.derive()
+			if r.Approvable != tt.wantOK {
+				t.Errorf("Approvable = %v, want %v", r.Approvable, tt.wantOK)
+			}
+			if r.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", r.Reason, tt.wantReason)
+			}
+			if len(r.Details) != tt.wantDetail {
+				t.Errorf("Details = %v, want %d entries", r.Details, tt.wantDetail)
+			}
+			if r.Deferred != tt.wantDefer {
+				t.Errorf("Deferred = %v, want %v", r.Deferred, tt.wantDefer)
+			}
+		})
+	}
+}
+
+func TestAnalyzePullRequest_Trace(t *testing.T) {
+	ctx := context.Background()
+
+	newPR := func() *github.PullRequest {
+		return &github.PullRequest{
+			State:             github.String("open"),
+			Draft:             github.Bool(false),
+			ChangedFiles:      github.Int(1),
+			Additions:         github.Int(1),
+			Deletions:         github.Int(1),
+			UpdatedAt:         &github.Timestamp{Time: time.Now().Add(-24 * time.Hour)},
+			User:              &github.User{Login: github.String("testuser")},
+			AuthorAssociation: github.String("CONTRIBUTOR"),
+			Head:              &github.PullRequestBranch{SHA: github.String("abc123")},
+		}
+	}
+	files := []*github.CommitFile{
+		{Filename: github.String("README.md"), Patch: github.String("@@ -1 +1 @@\n-Teh\n+The")},
+	}
+
+	config := DefaultConfig()
+	config.RequirePassingChecks = false
+
+	t.Run("approvable", func(t *testing.T) {
+		a, err := New(&mockGitHubAPI{pr: newPR(), files: files}, &mockGeminiAPI{result: &geminiAnalysisResult{Category: "documentation"}}, config)
+		if err != nil {
+			t.Fatalf("Failed to create analyzer: %v", err)
+		}
+
+		result, err := a.AnalyzePullRequest(ctx, "owner", "repo", 1)
+		if err != nil {
+			t.Fatalf("AnalyzePullRequest() error = %v", err)
+		}
+		if !result.Approvable {
+			t.Fatalf("Approvable = false, reason: %s", result.Reason)
+		}
+
+		want := []string{
+			CheckPRState, CheckPolicy, CheckOwnPR, CheckDraft, CheckMinOpenTime, CheckMaxOpenTime,
+			CheckMaxFiles, CheckMaxLines, CheckPRInfo, CheckReviews, CheckCollaboratorComments,
+			CheckFirstTime, CheckFiles, CheckFileDeleted, CheckFileRenames, CheckFileModes, CheckFileSymlinks,
+			CheckFileSubmodules, CheckFileBinary, CheckCodeOwners, CheckCodeValidation, CheckCIRequired, CheckCIOptional, CheckAI,
+			CheckAIAnomaly, CheckAIMalicious, CheckAIVandalism, CheckAIInsecure, CheckAIMajorVersionBump, CheckAIRisky,
+			CheckAITitleDescMismatch, CheckAIAltersBehavior, CheckAINotImprovement, CheckAINonTrivial,
+			CheckAIConfusing, CheckAISuperfluous, CheckAICategory, CheckAIIncremental,
+		}
+		if len(result.Checks) != len(want) {
+			t.Fatalf("got %d checks, want %d: %+v", len(result.Checks), len(want), result.Checks)
+		}
+		for i, check := range result.Checks {
+			if check.ID != want[i] {
+				t.Errorf("check %d = %s, want %s", i, check.ID, want[i])
+			}
+			if check.Failed() {
+				t.Errorf("check %s = %s: %s", check.ID, check.Status, check.Message)
+			}
+		}
+
+		if c := result.Check(CheckMaxFiles); c == nil || c.Value != 1 || c.Threshold != config.MaxFiles {
+			t.Errorf("size.max_files = %+v, want value 1 and threshold %d", c, config.MaxFiles)
+		}
+		if c := result.Check(CheckCIOptional); c == nil || c.Status != CheckSkip {
+			t.Errorf("ci.optional = %+v, want skip", c)
+		}
+	})
+
+	t.Run("AI flag fails", func(t *testing.T) {
+		a, err := New(&mockGitHubAPI{pr: newPR(), files: files},
+			&mockGeminiAPI{result: &geminiAnalysisResult{Category: "documentation", AltersBehavior: true}}, config)
+		if err != nil {
+			t.Fatalf("Failed to create analyzer: %v", err)
+		}
+
+		result, err := a.AnalyzePullRequest(ctx, "owner", "repo", 1)
+		if err != nil {
+			t.Fatalf("AnalyzePullRequest() error = %v", err)
+		}
+		if result.Approvable || result.Reason != "Changes alter application behavior" {
+			t.Errorf("Approvable = %v, Reason = %q", result.Approvable, result.Reason)
+		}
+		if c := result.Check(CheckAIAltersBehavior); c == nil || c.Status != CheckFail {
+			t.Errorf("ai.alters_behavior = %+v, want fail", c)
+		}
+	})
+
+	t.Run("AI error fails closed", func(t *testing.T) {
+		a, err := New(&mockGitHubAPI{pr: newPR(), files: files},
+			&mockGeminiAPI{err: fmt.Errorf("quota exceeded")}, config)
+		if err != nil {
+			t.Fatalf("Failed to create analyzer: %v", err)
+		}
+
+		result, err := a.AnalyzePullRequest(ctx, "owner", "repo", 1)
+		if err != nil {
+			t.Fatalf("AnalyzePullRequest() error = %v", err)
+		}
+		if result.Approvable {
+			t.Error("PR should not be approvable when AI analysis fails")
+		}
+		if c := result.Check(CheckAI); c == nil || c.Status != CheckError {
+			t.Errorf("ai.analysis = %+v, want error", c)
+		}
+	})
+
+	t.Run("stops at first failing gate", func(t *testing.T) {
+		pr := newPR()
+		pr.Draft = github.Bool(true)
+		a, err := New(&mockGitHubAPI{pr: pr, files: files}, &mockGeminiAPI{}, config)
+		if err != nil {
+			t.Fatalf("Failed to create analyzer: %v", err)
+		}
+
+		result, err := a.AnalyzePullRequest(ctx, "owner", "repo", 1)
+		if err != nil {
+			t.Fatalf("AnalyzePullRequest() error = %v", err)
+		}
+		last := result.Checks[len(result.Checks)-1]
+		if last.ID != CheckDraft || last.Status != CheckFail || result.Reason != "PR is a draft" {
+			t.Errorf("last check = %+v, reason = %q", last, result.Reason)
+		}
+	})
+}
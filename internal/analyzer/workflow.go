@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// workflowReason is the rejection reason for workflow changes that aren't pinned action bumps.
+const workflowReason = "GitHub Actions workflow changes require manual review"
+
+// validateWorkflow compares a workflow's full contents at the PR's base and head commits,
+// accepting only `uses:` bumps to pinned, commented commit SHAs. It returns a rejection
+// detail, or the bumps if the change is accepted.
+func (a *Analyzer) validateWorkflow(ctx context.Context, owner, repo string, pr *github.PullRequest, file *github.CommitFile, result *Result) ([]security.ActionBump, string) {
+	filename := file.GetFilename()
+	if pr.GetBase().GetSHA() == "" || pr.GetHead().GetSHA() == "" {
+		return nil, fmt.Sprintf("%s: the PR's commits are unknown", filename)
+	}
+
+	base, head, err := a.compareFile(ctx, owner, repo, pr, file, result)
+	if err != nil {
+		log.Printf("[ANALYZER] Failed to fetch contents of %s: %v", filename, err)
+		return nil, fmt.Sprintf("%s: fetching contents failed: %v", filename, err)
+	}
+
+	bumps, err := security.CheckWorkflowChange(base, head)
+	if err != nil {
+		return nil, fmt.Sprintf("%s: %v", filename, err)
+	}
+	if len(bumps) == 0 {
+		return nil, fmt.Sprintf("%s: no action bumps", filename)
+	}
+	for _, bump := range bumps {
+		log.Printf("[ANALYZER] %s: %s pinned to %s (%s)", filename, bump.Action, bump.To, bump.Version)
+	}
+	return bumps, ""
+}
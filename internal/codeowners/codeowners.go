@@ -0,0 +1,121 @@
+// Package codeowners parses GitHub CODEOWNERS files.
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// Paths are the locations GitHub reads a CODEOWNERS file from, in order of precedence.
+var Paths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern. A rule without owners makes the
+// matching paths unowned.
+type Rule struct {
+	Pattern string
+	Owners  []string // @user, @org/team or an email address
+	Line    int
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	Rules []Rule
+
+	// Errors are the invalid lines, which GitHub ignores as well.
+	Errors []error
+}
+
+// Parse parses a CODEOWNERS file. Comments, blank lines and invalid lines are skipped;
+// invalid lines, including those with an invalid owner, are reported in File.Errors.
+func Parse(data []byte) *File {
+	f := &File{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		pattern := strings.Replace(fields[0], `\#`, "#", 1)
+		if err := checkPattern(pattern); err != nil {
+			f.Errors = append(f.Errors, fmt.Errorf("line %d: %w", n, err))
+			continue
+		}
+
+		rule := Rule{Pattern: pattern, Line: n}
+		var invalid error
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			if !validOwner(owner) {
+				invalid = fmt.Errorf("line %d: invalid owner %q", n, owner)
+				break
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+		if invalid != nil {
+			f.Errors = append(f.Errors, invalid)
+			continue
+		}
+		f.Rules = append(f.Rules, rule)
+	}
+	return f
+}
+
+// checkPattern rejects the gitignore syntax that CODEOWNERS does not support.
+func checkPattern(pattern string) error {
+	switch {
+	case strings.HasPrefix(pattern, "!"):
+		return fmt.Errorf("negated pattern %q is not supported", pattern)
+	case strings.ContainsAny(pattern, "[]"):
+		return fmt.Errorf("character range in %q is not supported", pattern)
+	}
+	return nil
+}
+
+// validOwner reports whether owner is a @user, an @org/team or an email address.
+func validOwner(owner string) bool {
+	if name, ok := strings.CutPrefix(owner, "@"); ok {
+		org, team, isTeam := strings.Cut(name, "/")
+		return org != "" && (!isTeam || team != "") && !strings.Contains(team, "/")
+	}
+	local, domain, ok := strings.Cut(owner, "@")
+	return ok && local != "" && domain != ""
+}
+
+// Owners returns the owners of a path, from the last rule matching it as GitHub does.
+// It returns nil for unowned paths.
+func (f *File) Owners(name string) []string {
+	if rule := f.Match(name); rule != nil {
+		return rule.Owners
+	}
+	return nil
+}
+
+// Match returns the last rule matching a path, or nil.
+func (f *File) Match(name string) *Rule {
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if matchPattern(f.Rules[i].Pattern, name) {
+			return &f.Rules[i]
+		}
+	}
+	return nil
+}
+
+// matchPattern matches a path with gitignore rules, except that a trailing "/*" matches
+// the files directly in a directory but not those in its subdirectories.
+func matchPattern(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "/*") && !strings.Contains(pattern, "**") {
+		// Anchored at the root like any pattern containing a slash
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), name)
+		return ok
+	}
+	return security.MatchPath(pattern, name)
+}
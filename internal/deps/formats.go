@@ -0,0 +1,436 @@
+package deps
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reservedKeys are manifest keys whose values look like versions but are not dependencies.
+var reservedKeys = map[string]bool{
+	"version":         true,
+	"edition":         true,
+	"rust-version":    true,
+	"requires-python": true,
+	"python":          true,
+	"node":            true,
+	"npm":             true,
+}
+
+// versionChange returns a change for a dependency line, or lineInvalid if the key is
+// reserved or the value is not a version.
+func versionChange(key, name, value string) (change, lineResult) {
+	if reservedKeys[strings.ToLower(name)] {
+		return change{}, lineInvalid
+	}
+	if _, ok := parseVersion(value); !ok {
+		return change{}, lineInvalid
+	}
+	return change{key: key, name: name, version: value}, lineVersion
+}
+
+// blankOrComment reports whether a line is blank or starts with one of the comment prefixes.
+func blankOrComment(text string, prefixes ...string) bool {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// package.json: "name": "^1.2.3",
+var jsonEntryPattern = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*"([^"]*)"\s*,?\s*$`)
+
+func parsePackageJSON(text string) (change, lineResult) {
+	if strings.TrimSpace(text) == "" {
+		return change{}, lineIgnored
+	}
+	m := jsonEntryPattern.FindStringSubmatch(text)
+	if m == nil {
+		return change{}, lineInvalid
+	}
+	return versionChange(m[1], m[1], m[2])
+}
+
+// Lockfile entries, keyed by install path: "node_modules/@scope/name": {
+var lockEntryPattern = regexp.MustCompile(`^\s*"([^"]*)"\s*:\s*\{\s*$`)
+
+// npmRegistry is the only registry resolved URLs may point to.
+const npmRegistry = "https://registry.npmjs.org/"
+
+// lockEntry is what a patch changes in one package-lock.json entry.
+type lockEntry struct {
+	path             string
+	removed, added   []string // Versions
+	resolved         []string // Added resolved URLs
+	changesArtifacts bool     // A resolved URL or integrity is added or removed
+}
+
+// parsePackageLock parses package-lock.json, where the package name is on the line that
+// opens its entry and each field is on a line of its own. Only the version, resolved URL
+// and integrity of existing entries may change, the latter two only along with the
+// version, and the resolved URL must be the registry's tarball of the new version.
+func parsePackageLock(lines []diffLine) ([]change, bool) {
+	var changes []change
+	var entries []*lockEntry
+	var entry *lockEntry
+	hunk := -1
+	for _, line := range lines {
+		if line.hunk != hunk {
+			// A hunk may start inside an entry whose opening line it doesn't show
+			hunk, entry = line.hunk, nil
+		}
+		if line.op == ' ' {
+			if m := lockEntryPattern.FindStringSubmatch(line.text); m != nil {
+				entry = &lockEntry{path: strings.ReplaceAll(m[1], "node_modules/", "")}
+				entries = append(entries, entry)
+			}
+			continue
+		}
+		if strings.TrimSpace(line.text) == "" {
+			continue
+		}
+
+		m := jsonEntryPattern.FindStringSubmatch(line.text)
+		if m == nil || entry == nil {
+			return nil, false
+		}
+		switch m[1] {
+		case "version":
+			if entry.path == "" {
+				return nil, false // The project's own version
+			}
+			if _, ok := parseVersion(m[2]); !ok {
+				return nil, false
+			}
+			if line.op == '+' {
+				entry.added = append(entry.added, m[2])
+			} else {
+				entry.removed = append(entry.removed, m[2])
+			}
+			changes = append(changes, change{added: line.op == '+', key: entry.path, name: lockPackageName(entry.path), version: m[2]})
+		case "resolved":
+			entry.changesArtifacts = true
+			if line.op == '+' {
+				entry.resolved = append(entry.resolved, m[2])
+			}
+		case "integrity":
+			entry.changesArtifacts = true
+		default:
+			return nil, false
+		}
+	}
+
+	for _, e := range entries {
+		if !e.changesArtifacts {
+			continue
+		}
+		if len(e.removed) != 1 || len(e.added) != 1 {
+			return nil, false
+		}
+		for _, url := range e.resolved {
+			if url != npmTarball(lockPackageName(e.path), e.added[0]) {
+				return nil, false
+			}
+		}
+	}
+	return changes, true
+}
+
+// npmTarball returns the registry URL of a package version's tarball, such as
+// https://registry.npmjs.org/@scope/name/-/name-1.2.3.tgz.
+func npmTarball(name, version string) string {
+	base := name[strings.LastIndex(name, "/")+1:]
+	return npmRegistry + name + "/-/" + base + "-" + version + ".tgz"
+}
+
+// lockPackageName returns the package name at the end of an install path with its
+// node_modules segments removed, such as "@scope/b" for "a/@scope/b".
+func lockPackageName(path string) string {
+	parts := strings.Split(path, "/")
+	last := parts[len(parts)-1]
+	if len(parts) > 1 && strings.HasPrefix(parts[len(parts)-2], "@") {
+		return parts[len(parts)-2] + "/" + last
+	}
+	return last
+}
+
+// requirements.txt: name[extras]==1.2.3 with an optional line continuation or comment.
+var requirementPattern = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)(\[[A-Za-z0-9,._ -]*\])?\s*(===|==|>=|~=)\s*([0-9A-Za-z.+!-]+)\s*(\\)?\s*(#.*)?$`)
+
+func parseRequirement(text string) (change, lineResult) {
+	if blankOrComment(text, "#", "--hash") {
+		return change{}, lineIgnored
+	}
+	m := requirementPattern.FindStringSubmatch(text)
+	if m == nil {
+		return change{}, lineInvalid
+	}
+	op := m[3]
+	if op == "===" {
+		op = "=="
+	}
+	return versionChange(pythonKey(m[1])+m[2], m[1], op+m[4])
+}
+
+// pythonKey normalizes a Python package name, which is case-insensitive and treats
+// runs of "-", "_" and "." as equal.
+func pythonKey(name string) string {
+	return strings.ToLower(pythonSeparators.ReplaceAllString(name, "-"))
+}
+
+var pythonSeparators = regexp.MustCompile(`[-_.]+`)
+
+// pyproject.toml dependency arrays: "requests>=2.31.0",
+var quotedRequirementPattern = regexp.MustCompile(`^\s*["']([^"']+)["']\s*,?\s*$`)
+
+func parsePyproject(text string) (change, lineResult) {
+	if m := quotedRequirementPattern.FindStringSubmatch(text); m != nil {
+		return parseRequirement(m[1])
+	}
+	return parseTOMLDependency(text)
+}
+
+// TOML dependencies: name = "1.2.3" or name = { version = "1.2.3", features = [...] }
+var (
+	tomlStringPattern  = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+|"[^"]+")\s*=\s*"([^"]*)"\s*(#.*)?$`)
+	tomlTablePattern   = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+|"[^"]+")\s*=\s*\{(.*)\}\s*(#.*)?$`)
+	tomlVersionPattern = regexp.MustCompile(`(^|[\s,])version\s*=\s*"([^"]*)"`)
+)
+
+func parseTOMLDependency(text string) (change, lineResult) {
+	if blankOrComment(text, "#") {
+		return change{}, lineIgnored
+	}
+	if m := tomlStringPattern.FindStringSubmatch(text); m != nil {
+		name := strings.Trim(m[1], `"`)
+		return versionChange(name, name, m[2])
+	}
+	if m := tomlTablePattern.FindStringSubmatch(text); m != nil {
+		name := strings.Trim(m[1], `"`)
+		v := tomlVersionPattern.FindStringSubmatchIndex(m[2])
+		if v == nil {
+			return change{}, lineInvalid
+		}
+		// The rest of the table, such as features or the registry, must not change
+		rest := strings.TrimSpace(m[2][:v[0]] + m[2][v[1]:])
+		return versionChange(name+" {"+rest+"}", name, m[2][v[4]:v[5]])
+	}
+	return change{}, lineInvalid
+}
+
+var (
+	cargoNamePattern    = regexp.MustCompile(`^\s*name\s*=\s*"([^"]+)"\s*$`)
+	cargoVersionPattern = regexp.MustCompile(`^\s*version\s*=\s*"([^"]+)"\s*$`)
+	cargoFieldPattern   = regexp.MustCompile(`^\s*(checksum|source)\s*=\s*"([^"]*)"\s*$`)
+	cargoDepPattern     = regexp.MustCompile(`^\s*"([A-Za-z0-9_-]+) ([^" ]+)(?: \([^)]*\))?",?\s*$`)
+)
+
+// cratesIO is the only source bumped packages may come from.
+const cratesIO = "registry+https://github.com/rust-lang/crates.io-index"
+
+// parseCargoLock parses Cargo.lock, where each [[package]] has its name, version, source
+// and checksum on separate lines. Versions of the workspace's own packages, which have no
+// source, must not change.
+func parseCargoLock(lines []diffLine) ([]change, bool) {
+	var changes, pending []change
+	var name string
+	fromCratesIO := false
+	flush := func() bool {
+		if len(pending) > 0 && !fromCratesIO {
+			return false
+		}
+		changes = append(changes, pending...)
+		pending = nil
+		return true
+	}
+
+	for _, line := range lines {
+		text := line.text
+		if strings.TrimSpace(text) == "[[package]]" {
+			if !flush() {
+				return nil, false
+			}
+			name, fromCratesIO = "", false
+			if line.op != ' ' {
+				return nil, false
+			}
+			continue
+		}
+		if m := cargoNamePattern.FindStringSubmatch(text); m != nil {
+			if line.op != ' ' {
+				return nil, false
+			}
+			name = m[1]
+			continue
+		}
+		if m := cargoFieldPattern.FindStringSubmatch(text); m != nil {
+			if m[1] == "source" {
+				if m[2] != cratesIO {
+					if line.op != ' ' {
+						return nil, false
+					}
+					continue
+				}
+				fromCratesIO = true
+			}
+			continue
+		}
+		if line.op == ' ' || strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if m := cargoVersionPattern.FindStringSubmatch(text); m != nil {
+			if name == "" {
+				return nil, false
+			}
+			pending = append(pending, change{added: line.op == '+', key: name, name: name, version: m[1]})
+			continue
+		}
+		if m := cargoDepPattern.FindStringSubmatch(text); m != nil {
+			// A reference that names the version because several are in the lockfile
+			changes = append(changes, change{added: line.op == '+', key: m[1], name: m[1], version: m[2]})
+			continue
+		}
+		return nil, false
+	}
+	if !flush() {
+		return nil, false
+	}
+	return changes, true
+}
+
+var (
+	xmlElementPattern = regexp.MustCompile(`^\s*<([A-Za-z0-9_.-]+)>([^<]*)</([A-Za-z0-9_.-]+)>\s*$`)
+	xmlOpenPattern    = regexp.MustCompile(`^\s*<(/?)([A-Za-z0-9_.-]+)[\s>]`)
+)
+
+// pomSections are the elements whose <version> selects a dependency.
+var pomSections = map[string]bool{
+	"dependency": true,
+	"plugin":     true,
+	"extension":  true,
+	"parent":     true,
+}
+
+// parsePOM parses pom.xml. A <version> may change only inside a dependency, plugin,
+// extension or parent element opened in the patch, which excludes the project's own
+// version. Properties named *.version may also change.
+func parsePOM(lines []diffLine) ([]change, bool) {
+	var changes []change
+	section := ""
+	var groupID, artifactID string
+	for _, line := range lines {
+		if m := xmlElementPattern.FindStringSubmatch(line.text); m != nil && m[1] == m[3] {
+			tag, value := m[1], strings.TrimSpace(m[2])
+			switch {
+			case tag == "groupId" || tag == "artifactId":
+				if line.op != ' ' {
+					return nil, false
+				}
+				if tag == "groupId" {
+					groupID = value
+				} else {
+					artifactID = value
+				}
+			case line.op == ' ':
+			case tag == "version":
+				if section == "" || artifactID == "" {
+					return nil, false
+				}
+				key := groupID + ":" + artifactID
+				c, result := versionChange(key, key, value)
+				if result != lineVersion {
+					return nil, false
+				}
+				c.added = line.op == '+'
+				changes = append(changes, c)
+			case strings.HasSuffix(tag, ".version"):
+				c, result := versionChange(tag, strings.TrimSuffix(tag, ".version"), value)
+				if result != lineVersion {
+					return nil, false
+				}
+				c.added = line.op == '+'
+				changes = append(changes, c)
+			default:
+				return nil, false
+			}
+			continue
+		}
+
+		if line.op != ' ' {
+			if strings.TrimSpace(line.text) == "" {
+				continue
+			}
+			return nil, false
+		}
+		if m := xmlOpenPattern.FindStringSubmatch(line.text); m != nil {
+			switch {
+			case pomSections[m[2]] && m[1] == "":
+				section, groupID, artifactID = m[2], "", ""
+			case pomSections[m[2]] || m[2] == "project":
+				section, groupID, artifactID = "", "", ""
+			}
+		}
+	}
+	return changes, true
+}
+
+// Gradle dependencies and plugins:
+//
+//	implementation 'group:artifact:1.2.3'
+//	implementation("group:artifact:1.2.3")
+//	id("org.example.plugin") version "1.2.3"
+var (
+	gradleDependencyPattern = regexp.MustCompile(`^\s*[A-Za-z]+\s*\(?\s*["']([^:"'\s]+):([^:"'\s]+):([^:"'\s@]+)["']\s*\)?\s*(//.*)?$`)
+	gradlePluginPattern     = regexp.MustCompile(`^\s*id\s*\(?\s*["']([^"']+)["']\s*\)?\s+version\s+["']([^"']+)["']\s*(//.*)?$`)
+)
+
+func parseGradle(text string) (change, lineResult) {
+	if blankOrComment(text, "//") {
+		return change{}, lineIgnored
+	}
+	if m := gradleDependencyPattern.FindStringSubmatch(text); m != nil {
+		key := m[1] + ":" + m[2]
+		return versionChange(key, key, m[3])
+	}
+	if m := gradlePluginPattern.FindStringSubmatch(text); m != nil {
+		return versionChange("plugin "+m[1], m[1], m[2])
+	}
+	return change{}, lineInvalid
+}
+
+// go.mod requirements, alone or after "require": example.com/mod v1.2.3 // indirect
+var (
+	goModPattern = regexp.MustCompile(`^\s*(?:require\s+)?([A-Za-z0-9._~-]+(?:/[A-Za-z0-9._~+-]+)*)\s+(v[0-9][0-9A-Za-z.+-]*)\s*(//\s*indirect)?\s*$`)
+	goSumPattern = regexp.MustCompile(`^\s*([A-Za-z0-9._~-]+(?:/[A-Za-z0-9._~+-]+)*)\s+(v[0-9][0-9A-Za-z.+-]*?)(/go\.mod)?\s+h1:[A-Za-z0-9+/=]+\s*$`)
+
+	// goMajorSuffix is the major version suffix of a module path, which changes with major bumps.
+	goMajorSuffix = regexp.MustCompile(`/v[0-9]+$`)
+)
+
+func parseGoMod(text string) (change, lineResult) {
+	if blankOrComment(text, "//") {
+		return change{}, lineIgnored
+	}
+	m := goModPattern.FindStringSubmatch(text)
+	if m == nil {
+		return change{}, lineInvalid
+	}
+	return versionChange(goMajorSuffix.ReplaceAllString(m[1], ""), m[1], m[2])
+}
+
+func parseGoSum(text string) (change, lineResult) {
+	if strings.TrimSpace(text) == "" {
+		return change{}, lineIgnored
+	}
+	m := goSumPattern.FindStringSubmatch(text)
+	if m == nil {
+		return change{}, lineInvalid
+	}
+	return versionChange(goMajorSuffix.ReplaceAllString(m[1], ""), m[1], m[2])
+}
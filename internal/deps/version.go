@@ -0,0 +1,69 @@
+package deps
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPattern matches a version with an optional range operator, such as "^1.2.3",
+// "~> 0.9", ">=2.0" or "v1.2.3-rc.1". Anything else, including wildcards, URLs and
+// compound ranges, is not a version the bump levels can be computed for.
+var versionPattern = regexp.MustCompile(`^(\^|~>|~=|~|>=|==|=)?\s*v?(\d+(?:\.\d+){0,3})([-+.][0-9A-Za-z.+-]+)?$`)
+
+// prereleaseMarkers identify prerelease suffixes. Other suffixes, such as Maven's
+// ".Final" or "-jre", are build qualifiers.
+var prereleaseMarkers = []string{"alpha", "beta", "rc", "snapshot", "pre", "dev", "milestone"}
+
+// version is a parsed version.
+type version struct {
+	core       []int
+	prerelease bool
+}
+
+// parseVersion parses s as a version, reporting false if it is not one.
+func parseVersion(s string) (version, bool) {
+	m := versionPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return version{}, false
+	}
+
+	var v version
+	for _, part := range strings.Split(m[2], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version{}, false
+		}
+		v.core = append(v.core, n)
+	}
+
+	suffix := strings.ToLower(m[3])
+	for _, marker := range prereleaseMarkers {
+		if strings.Contains(suffix, marker) {
+			v.prerelease = true
+		}
+	}
+	return v, true
+}
+
+// part returns the ith component of the version, or 0 if it has fewer components.
+func (v version) part(i int) int {
+	if i < len(v.core) {
+		return v.core[i]
+	}
+	return 0
+}
+
+// compare compares the numeric components of v and w, ignoring suffixes.
+func (v version) compare(w version) int {
+	for i := 0; i < max(len(v.core), len(w.core)); i++ {
+		x, y := v.part(i), w.part(i)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -14,6 +14,9 @@ var (
 	// ErrNoGeminiKey indicates that the GEMINI_API_KEY environment variable is not set.
 	ErrNoGeminiKey = errors.New("GEMINI_API_KEY environment variable not set")
 
+	// ErrNoOpenAIKey indicates that the OPENAI_API_KEY environment variable is not set.
+	ErrNoOpenAIKey = errors.New("OPENAI_API_KEY environment variable not set")
+
 	// ErrInvalidPRURL indicates that the provided PR URL could not be parsed.
 	ErrInvalidPRURL = errors.New("invalid pull request URL format")
 
@@ -25,6 +28,12 @@ var (
 
 	// ErrBranchUpToDate indicates that the branch is already up to date.
 	ErrBranchUpToDate = errors.New("branch already up to date")
+
+	// ErrFileNotFound indicates that a file does not exist at the requested ref.
+	ErrFileNotFound = errors.New("file not found")
+
+	// ErrDiffTooLarge indicates that a PR's changes cannot be analyzed without truncating them.
+	ErrDiffTooLarge = errors.New("diff too large to analyze without truncation")
 )
 
 // ValidationError represents an error in configuration or input validation.
@@ -1,25 +1,17 @@
-// Package gemini provides interfaces and implementations for Gemini AI API operations.
+// Package gemini provides the Gemini backend for the llm package.
 package gemini
 
-import "context"
+import "github.com/thegroove/trivial-auto-approve/internal/llm"
 
 // PRContext contains context information about a pull request.
-type PRContext struct {
-	Title             string
-	Description       string
-	Author            string
-	AuthorAssociation string
-	Organization      string
-	Repository        string
-	PullRequestNumber int
-	URL               string
-}
+type PRContext = llm.PRContext
 
-// API defines the interface for Gemini AI operations.
-type API interface {
-	// AnalyzePRChanges analyzes PR changes to determine if they alter behavior.
-	AnalyzePRChanges(ctx context.Context, files []FileChange, prContext PRContext) (*AnalysisResult, error)
+// FileChange represents a file change in a PR with patch content and modification statistics.
+type FileChange = llm.FileChange
+
+// AnalysisResult represents the result of AI-powered PR analysis for behavior and triviality detection.
+type AnalysisResult = llm.AnalysisResult
 
-	// Close closes the Gemini client connection.
-	Close() error
-}
+// API defines the interface for Gemini AI operations.
+// It is the provider-neutral llm.Analyzer.
+type API = llm.Analyzer
@@ -2,9 +2,12 @@ package github
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os/exec"
+	"path"
 	"strings"
 	"time"
 
@@ -232,6 +235,31 @@ func (c *Client) PullRequestFiles(ctx context.Context, owner, repo string, numbe
 	return allFiles, nil
 }
 
+// CompareCommits retrieves the files changed between two commits, base...head.
+// GitHub lists at most 300 files in a comparison.
+func (c *Client) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]*github.CommitFile, error) {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 1*time.Minute)
+	defer cancel()
+
+	var comparison *github.CommitsComparison
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var err error
+			comparison, _, err = c.client.Repositories.CompareCommits(ctx, owner, repo, base, head, nil)
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "Repositories.CompareCommits", err)
+		},
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s after retries: %w", base, head, err)
+	}
+
+	return comparison.Files, nil
+}
+
 // CombinedStatus retrieves the combined status for a PR.
 func (c *Client) CombinedStatus(ctx context.Context, owner, repo, ref string) (*github.CombinedStatus, error) {
 	var status *github.CombinedStatus
@@ -291,8 +319,9 @@ func (c *Client) ListCheckRunsForRef(ctx context.Context, owner, repo, ref strin
 	return allCheckRuns, nil
 }
 
-// ApprovePullRequest approves a pull request.
-func (c *Client) ApprovePullRequest(ctx context.Context, owner, repo string, number int, body string) error {
+// ApprovePullRequest approves a pull request at commitID, the head commit that was analyzed.
+// GitHub rejects the review if the head has moved since.
+func (c *Client) ApprovePullRequest(ctx context.Context, owner, repo string, number int, commitID, body string) error {
 	// Add timeout for this operation
 	ctx, cancel := withTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -301,6 +330,9 @@ func (c *Client) ApprovePullRequest(ctx context.Context, owner, repo string, num
 		Body:  github.String(body),
 		Event: github.String(constants.ReviewEventApprove),
 	}
+	if commitID != "" {
+		review.CommitID = github.String(commitID)
+	}
 
 	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
 		func() error {
@@ -318,6 +350,30 @@ func (c *Client) ApprovePullRequest(ctx context.Context, owner, repo string, num
 	return nil
 }
 
+// DismissReview dismisses a review on a pull request.
+func (c *Client) DismissReview(ctx context.Context, owner, repo string, number int, reviewID int64, message string) error {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	dismissal := &github.PullRequestReviewDismissalRequest{Message: github.String(message)}
+
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			_, _, err := c.client.PullRequests.DismissReview(ctx, owner, repo, number, reviewID, dismissal)
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "PullRequests.DismissReview", err)
+		},
+	))
+	if err != nil {
+		return fmt.Errorf("failed to dismiss review %d after retries: %w", reviewID, err)
+	}
+
+	return nil
+}
+
 // EnableAutoMerge enables auto-merge for a pull request.
 func (c *Client) EnableAutoMerge(ctx context.Context, owner, repo string, number int) error {
 	// First, get the PR to check if auto-merge is already enabled
@@ -588,6 +644,231 @@ func (c *Client) ListRepoPullRequests(ctx context.Context, owner, repo string) (
 	return allPRs, nil
 }
 
+// FileContents retrieves the contents of a file at a git ref.
+// It returns errors.ErrFileNotFound if the file does not exist at that ref.
+func (c *Client) FileContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	opt := &github.RepositoryContentGetOptions{Ref: ref}
+
+	var file *github.RepositoryContent
+	notFound := false
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var resp *github.Response
+			var err error
+			file, _, resp, err = c.client.Repositories.GetContents(ctx, owner, repo, path, opt)
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				notFound = true
+				return nil
+			}
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "Repositories.GetContents", err)
+		},
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s@%s after retries: %w", path, ref, err)
+	}
+	if notFound {
+		return nil, errors.ErrFileNotFound
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s@%s is not a file", path, ref)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s@%s: %w", path, ref, err)
+	}
+	return []byte(content), nil
+}
+
+// CompareFile retrieves a file's contents at a base and a head ref, for changes whose patch
+// GitHub omits. basePath differs from headPath for renamed files. A side is nil if the
+// file does not exist at that ref, as for added and removed files.
+func (c *Client) CompareFile(ctx context.Context, owner, repo, basePath, headPath, baseRef, headRef string) (base, head []byte, err error) {
+	// Add timeout for the whole comparison; each fetch retries on its own
+	ctx, cancel := withTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	base, err = c.FileContents(ctx, owner, repo, basePath, baseRef)
+	if err != nil && !stderrors.Is(err, errors.ErrFileNotFound) {
+		return nil, nil, err
+	}
+	head, err = c.FileContents(ctx, owner, repo, headPath, headRef)
+	if err != nil && !stderrors.Is(err, errors.ErrFileNotFound) {
+		return nil, nil, err
+	}
+	if base == nil && head == nil {
+		return nil, nil, fmt.Errorf("%s not found at %s or %s: %w", headPath, baseRef, headRef, errors.ErrFileNotFound)
+	}
+	return base, head, nil
+}
+
+// FileModes retrieves the git modes of paths at a git ref. It walks the trees of the
+// paths' directories rather than fetching the whole tree recursively, which GitHub
+// truncates for large repositories.
+func (c *Client) FileModes(ctx context.Context, owner, repo, ref string, paths []string) (map[string]string, error) {
+	// Add timeout for the whole walk; each tree fetch retries on its own
+	ctx, cancel := withTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// Tree entries by directory, "" being the root
+	trees := make(map[string][]*github.TreeEntry)
+	var entries func(dir string) ([]*github.TreeEntry, error)
+	entries = func(dir string) ([]*github.TreeEntry, error) {
+		if tree, ok := trees[dir]; ok {
+			return tree, nil
+		}
+		sha := ref
+		if dir != "" {
+			parent, name := path.Split(dir)
+			parentEntries, err := entries(strings.TrimSuffix(parent, "/"))
+			if err != nil {
+				return nil, err
+			}
+			sha = ""
+			for _, e := range parentEntries {
+				if e.GetPath() == name && e.GetType() == "tree" {
+					sha = e.GetSHA()
+				}
+			}
+			if sha == "" {
+				trees[dir] = nil // The directory does not exist at ref
+				return nil, nil
+			}
+		}
+
+		var tree *github.Tree
+		err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+			func() error {
+				var err error
+				tree, _, err = c.client.Git.GetTree(ctx, owner, repo, sha, false)
+				return err
+			},
+			func(err error) error {
+				return errors.API("GitHub", "Git.GetTree", err)
+			},
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tree of %q at %s after retries: %w", dir, ref, err)
+		}
+		if tree.GetTruncated() {
+			return nil, fmt.Errorf("tree of %q at %s is truncated", dir, ref)
+		}
+		trees[dir] = tree.Entries
+		return tree.Entries, nil
+	}
+
+	modes := make(map[string]string)
+	for _, p := range paths {
+		dir, name := path.Split(p)
+		dirEntries, err := entries(strings.TrimSuffix(dir, "/"))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range dirEntries {
+			if e.GetPath() == name {
+				modes[p] = e.GetMode()
+			}
+		}
+	}
+	return modes, nil
+}
+
+// BranchProtection retrieves the protection of a branch, or nil if it is not protected
+// or its protection cannot be read.
+func (c *Client) BranchProtection(ctx context.Context, owner, repo, branch string) (*github.Protection, error) {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var protection *github.Protection
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var err error
+			protection, _, err = c.client.Repositories.GetBranchProtection(ctx, owner, repo, branch)
+			if stderrors.Is(err, github.ErrBranchNotProtected) {
+				protection = nil
+				return nil
+			}
+			// Reading branch protection needs admin access. Without it, the branch is
+			// treated as unprotected and callers rely on rulesets, which any reader can see
+			var errResp *github.ErrorResponse
+			if stderrors.As(err, &errResp) && errResp.Response != nil &&
+				(errResp.Response.StatusCode == http.StatusForbidden || errResp.Response.StatusCode == http.StatusNotFound) {
+				log.Printf("[GITHUB] Cannot read protection of %s/%s@%s (HTTP %d), relying on rulesets", owner, repo, branch, errResp.Response.StatusCode)
+				protection = nil
+				return nil
+			}
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "Repositories.GetBranchProtection", err)
+		},
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get protection of %s/%s@%s after retries: %w", owner, repo, branch, err)
+	}
+	return protection, nil
+}
+
+// BranchRules retrieves the ruleset rules that apply to a branch.
+func (c *Client) BranchRules(ctx context.Context, owner, repo, branch string) ([]*github.RepositoryRule, error) {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var rules []*github.RepositoryRule
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var err error
+			rules, _, err = c.client.Repositories.GetRulesForBranch(ctx, owner, repo, branch)
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "Repositories.GetRulesForBranch", err)
+		},
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules for %s/%s@%s after retries: %w", owner, repo, branch, err)
+	}
+	return rules, nil
+}
+
+// IsTeamMember reports whether a user is an active member of an organization's team.
+// Pending invitations don't count.
+func (c *Client) IsTeamMember(ctx context.Context, org, team, user string) (bool, error) {
+	// Add timeout for this operation
+	ctx, cancel := withTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var membership *github.Membership
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var resp *github.Response
+			var err error
+			membership, resp, err = c.client.Teams.GetTeamMembershipBySlug(ctx, org, team, user)
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				membership = nil
+				return nil
+			}
+			return err
+		},
+		func(err error) error {
+			return errors.API("GitHub", "Teams.GetTeamMembershipBySlug", err)
+		},
+	))
+	if err != nil {
+		return false, fmt.Errorf("failed to get membership of %s in %s/%s after retries: %w", user, org, team, err)
+	}
+	return membership.GetState() == "active", nil
+}
+
 // UpdateBranch updates the PR branch by rebasing or merging with the base branch.
 func (c *Client) UpdateBranch(ctx context.Context, owner, repo string, number int) error {
 	// Add timeout for this operation
@@ -1,7 +1,13 @@
 package github
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+
+	"github.com/google/go-github/v68/github"
 )
 
 func TestParsePullRequestURL(t *testing.T) {
@@ -67,3 +73,42 @@ func TestParsePullRequestURL(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchProtectionWithoutAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"not protected", http.StatusNotFound, `{"message": "Branch not protected"}`, false},
+		{"no admin access", http.StatusNotFound, `{"message": "Not Found"}`, false},
+		{"forbidden", http.StatusForbidden, `{"message": "Resource not accessible by integration"}`, false},
+		{"bad request", http.StatusUnprocessableEntity, `{"message": "Validation Failed"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/owner/repo/branches/main/protection" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			gh := github.NewClient(nil)
+			gh.BaseURL, _ = url.Parse(srv.URL + "/")
+			c := &Client{client: gh}
+
+			protection, err := c.BranchProtection(context.Background(), "owner", "repo", "main")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BranchProtection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if protection != nil {
+				t.Errorf("BranchProtection() = %+v, want nil", protection)
+			}
+		})
+	}
+}
@@ -25,6 +25,9 @@ type API interface {
 	// PullRequestFiles retrieves the files changed in a pull request.
 	PullRequestFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error)
 
+	// CompareCommits retrieves the files changed between two commits, base...head.
+	CompareCommits(ctx context.Context, owner, repo, base, head string) ([]*github.CommitFile, error)
+
 	// CombinedStatus retrieves the combined status for a PR.
 	CombinedStatus(ctx context.Context, owner, repo, ref string) (*github.CombinedStatus, error)
 
@@ -40,8 +43,11 @@ type API interface {
 	// ListPullRequestComments lists all PR review comments for a pull request.
 	ListPullRequestComments(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestComment, error)
 
-	// ApprovePullRequest approves a pull request.
-	ApprovePullRequest(ctx context.Context, owner, repo string, number int, body string) error
+	// ApprovePullRequest approves a pull request at commitID, the head commit that was analyzed.
+	ApprovePullRequest(ctx context.Context, owner, repo string, number int, commitID, body string) error
+
+	// DismissReview dismisses a review on a pull request.
+	DismissReview(ctx context.Context, owner, repo string, number int, reviewID int64, message string) error
 
 	// EnableAutoMerge enables auto-merge for a pull request.
 	EnableAutoMerge(ctx context.Context, owner, repo string, number int) error
@@ -63,4 +69,26 @@ type API interface {
 
 	// ListUserPullRequests lists all open pull requests for repositories owned by a specific user.
 	ListUserPullRequests(ctx context.Context, user string) ([]*github.PullRequest, error)
+
+	// FileContents retrieves the contents of a file at a git ref.
+	// It returns errors.ErrFileNotFound if the file does not exist at that ref.
+	FileContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error)
+
+	// CompareFile retrieves a file's contents at a base and a head ref.
+	// A side is nil if the file does not exist at that ref; it is an error if neither exists.
+	CompareFile(ctx context.Context, owner, repo, basePath, headPath, baseRef, headRef string) (base, head []byte, err error)
+
+	// FileModes retrieves the git modes (100644, 100755, 120000 for symlinks, 160000 for
+	// submodules) of paths at a git ref. Paths that do not exist at that ref are omitted.
+	FileModes(ctx context.Context, owner, repo, ref string, paths []string) (map[string]string, error)
+
+	// BranchProtection retrieves the protection of a branch, or nil if it is not protected
+	// or its protection cannot be read without admin access.
+	BranchProtection(ctx context.Context, owner, repo, branch string) (*github.Protection, error)
+
+	// BranchRules retrieves the ruleset rules that apply to a branch.
+	BranchRules(ctx context.Context, owner, repo, branch string) ([]*github.RepositoryRule, error)
+
+	// IsTeamMember reports whether a user is an active member of an organization's team.
+	IsTeamMember(ctx context.Context, org, team, user string) (bool, error)
 }
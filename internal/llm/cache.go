@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long a cached verdict is reused.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache stores model verdicts on disk, one file per key. Keys are content hashes of
+// everything the model sees, so identical changes, such as the same dependency bump
+// across repositories, are analyzed once. It is safe for concurrent use, also by
+// several processes. A nil Cache stores nothing.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// cacheEntry is a cached verdict.
+type cacheEntry struct {
+	Model    string          `json:"model"`
+	CachedAt time.Time       `json:"cached_at"`
+	Result   *AnalysisResult `json:"result"`
+}
+
+// NewCache creates a cache in dir, which is created if needed, reusing verdicts for ttl.
+func NewCache(dir string, ttl time.Duration) (*Cache, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("verdict cache TTL must be positive, got %v", ttl)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating verdict cache: %w", err)
+	}
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// path returns the file of a key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the verdict cached for key, if it has not expired. Expired and
+// unreadable entries are removed.
+func (c *Cache) Get(key string) (*AnalysisResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil || time.Since(entry.CachedAt) > c.ttl {
+		_ = os.Remove(c.path(key))
+		return nil, false
+	}
+	entry.Result.Cached = true
+	entry.Result.CachedAt = entry.CachedAt
+	return entry.Result, true
+}
+
+// Put caches the verdict of model for key. A write failure is logged, not returned:
+// the verdict is only asked for again.
+func (c *Cache) Put(key, model string, result *AnalysisResult) {
+	if c == nil {
+		return
+	}
+	if err := c.write(key, cacheEntry{Model: model, CachedAt: time.Now().UTC(), Result: result}); err != nil {
+		log.Printf("[LLM] Warning: failed to cache verdict: %v", err)
+	}
+}
+
+// write writes an entry atomically, so readers never see a truncated file.
+func (c *Cache) write(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".verdict-*.json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
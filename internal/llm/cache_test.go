@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingCompleter answers every prompt with response and counts the calls.
+type countingCompleter struct {
+	model    string
+	response string
+	calls    int
+	prompts  []string
+}
+
+func (c *countingCompleter) Complete(ctx context.Context, system, prompt string) (string, error) {
+	c.calls++
+	c.prompts = append(c.prompts, prompt)
+	return c.response, nil
+}
+
+func (c *countingCompleter) Name() string  { return "Test" }
+func (c *countingCompleter) Model() string { return c.model }
+func (c *countingCompleter) Close() error  { return nil }
+
+func TestClientCache(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	completer := &countingCompleter{model: "test/model", response: trivialResponse}
+	client := NewClient(completer, false)
+	client.SetCache(cache)
+
+	first, err := client.AnalyzePRChanges(context.Background(), typoFix, PRContext{Title: "Fix typo", Organization: "org", Repository: "one"})
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if first.Cached {
+		t.Error("first verdict should come from the model")
+	}
+
+	// The same change in another repository reuses the verdict
+	second, err := client.AnalyzePRChanges(context.Background(), typoFix, PRContext{Title: "Fix typo", Organization: "org", Repository: "two"})
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if !second.Cached || second.CachedAt.IsZero() || second.Category != "typo" || completer.calls != 1 {
+		t.Errorf("second verdict = %+v after %d calls, want the cached typo verdict after 1", second, completer.calls)
+	}
+
+	// A different title changes the prompt
+	if _, err := client.AnalyzePRChanges(context.Background(), typoFix, PRContext{Title: "Update README"}); err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if completer.calls != 2 {
+		t.Errorf("calls = %d, want 2 for a different prompt", completer.calls)
+	}
+
+	// Another model does not reuse the verdict
+	other := &countingCompleter{model: "test/other", response: trivialResponse}
+	otherClient := NewClient(other, false)
+	otherClient.SetCache(cache)
+	if _, err := otherClient.AnalyzePRChanges(context.Background(), typoFix, PRContext{Title: "Fix typo"}); err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if other.calls != 1 {
+		t.Errorf("calls = %d, want 1 for another model", other.calls)
+	}
+}
+
+func TestClientCacheSkipsUnparseable(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	completer := &countingCompleter{model: "test/model", response: `{"alters_behavior": false, "category": "typo"`}
+	client := NewClient(completer, false)
+	client.SetCache(cache)
+
+	for i := 0; i < 2; i++ {
+		result, err := client.AnalyzePRChanges(context.Background(), typoFix, typoContext)
+		if err != nil {
+			t.Fatalf("AnalyzePRChanges() error = %v", err)
+		}
+		if result.Cached || !result.AltersBehavior {
+			t.Errorf("result = %+v, want conservative defaults from the model", result)
+		}
+	}
+	if completer.calls != 2 {
+		t.Errorf("calls = %d, want 2: conservative defaults must not be cached", completer.calls)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+
+	if err := cache.write("old", cacheEntry{Model: "test/model", CachedAt: time.Now().Add(-2 * time.Hour), Result: &AnalysisResult{Category: "typo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("old"); ok {
+		t.Error("Get() returned an expired verdict")
+	}
+
+	cache.Put("new", "test/model", &AnalysisResult{Category: "typo"})
+	if result, ok := cache.Get("new"); !ok || !result.Cached || result.Category != "typo" {
+		t.Errorf("Get() = %+v, %v, want the cached verdict", result, ok)
+	}
+
+	var disabled *Cache
+	disabled.Put("key", "test/model", &AnalysisResult{})
+	if _, ok := disabled.Get("key"); ok {
+		t.Error("a nil cache should store nothing")
+	}
+}
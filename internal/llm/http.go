@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds a single completion request.
+// Local models on CPU can take a while to produce a response.
+const requestTimeout = 2 * time.Minute
+
+// maxResponseSize bounds the size of a completion response body.
+const maxResponseSize = 1 << 20
+
+// maxOutputTokens limits the size of the model's answer, matching the Gemini backend.
+const maxOutputTokens = 500
+
+// message is a chat message in the OpenAI and Ollama chat APIs.
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatMessages returns the system and user messages for a completion.
+func chatMessages(system, prompt string) []message {
+	return []message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: prompt},
+	}
+}
+
+// postJSON sends req as JSON to url and decodes the response into resp.
+// Non-2xx responses are returned as errors including the status code, so
+// rate limits and server errors are retried.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		httpReq.Header[k] = v
+	}
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %d %s: %s", url, httpResp.StatusCode,
+			http.StatusText(httpResp.StatusCode), strings.TrimSpace(string(data)))
+	}
+
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,372 @@
+// Package llm provides a provider-neutral interface for AI analysis of pull requests.
+// Backends only implement Completer; prompt construction, input sanitization,
+// response validation and parsing are shared by every provider.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/constants"
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+	"github.com/thegroove/trivial-auto-approve/internal/retry"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// Provider identifies an LLM backend.
+type Provider string
+
+// Supported providers.
+const (
+	ProviderGemini Provider = "gemini"
+	ProviderOpenAI Provider = "openai" // Any OpenAI-compatible chat completions endpoint
+	ProviderOllama Provider = "ollama" // A local Ollama server
+)
+
+// Providers lists the supported providers.
+var Providers = []Provider{ProviderGemini, ProviderOpenAI, ProviderOllama}
+
+// ParseProvider returns the provider named s. An empty string selects Gemini.
+func ParseProvider(s string) (Provider, error) {
+	if s == "" {
+		return ProviderGemini, nil
+	}
+	for _, p := range Providers {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown provider %q (want gemini, openai or ollama)", s)
+}
+
+// ParseModel splits a "provider:model" spec such as "ollama:llama3".
+// Specs without a known provider prefix use def, so Ollama tags like
+// "llama3:8b" need no prefix when def is ProviderOllama.
+func ParseModel(spec string, def Provider) (Provider, string) {
+	if prefix, model, ok := strings.Cut(spec, ":"); ok {
+		if p, err := ParseProvider(prefix); err == nil && prefix != "" {
+			return p, model
+		}
+	}
+	return def, spec
+}
+
+// PRContext contains context information about a pull request.
+type PRContext struct {
+	Title             string
+	Description       string
+	Author            string
+	AuthorAssociation string
+	Organization      string
+	Repository        string
+	PullRequestNumber int
+	URL               string
+
+	// BotProfile is the profile of the trusted bot that authored the PR, if any
+	// (e.g. "dependency-only").
+	BotProfile string
+}
+
+// FileChange represents a file change in a PR with patch content and modification statistics.
+type FileChange struct {
+	Filename  string
+	Patch     string
+	Additions int
+	Deletions int
+}
+
+// AnalysisResult represents the result of AI-powered PR analysis for behavior and triviality detection.
+type AnalysisResult struct {
+	Reason            string // Analysis reason/explanation
+	Category          string // "typo", "comment", "markdown", "lint", etc.
+	AltersBehavior    bool
+	NotImprovement    bool    // True if change is NOT an improvement
+	NonTrivial        bool    // True if change is NOT trivial
+	Risky             bool    // True if change is high risk
+	InsecureChange    bool    // True if may introduce security problems
+	PossiblyMalicious bool    // True if change appears malicious
+	Superfluous       bool    // True if change is unnecessary/redundant
+	Vandalism         bool    // True if change is destructive/harmful
+	Confidence        float64 // Confidence level of the analysis (0.0-1.0)
+	Confusing         bool    // True if change reduces clarity
+	TitleDescMismatch bool    // True if title/description doesn't match diff
+	MajorVersionBump  bool    // True if change includes major version bump
+
+	// Parts is the number of requests the changes were split into, if more than one.
+	Parts int
+
+	// Cached is set when the verdict was reused from the Cache, given at CachedAt.
+	Cached   bool      `json:"-"`
+	CachedAt time.Time `json:"-"`
+}
+
+// Analyzer analyzes pull request changes with a language model.
+type Analyzer interface {
+	// AnalyzePRChanges analyzes PR changes to determine if they alter behavior.
+	AnalyzePRChanges(ctx context.Context, files []FileChange, prContext PRContext) (*AnalysisResult, error)
+
+	// Model identifies the provider and model, e.g. "ollama/llama3".
+	Model() string
+
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// Completer is the single operation a backend must provide.
+type Completer interface {
+	// Complete sends the system and user prompts and returns the model's text response.
+	Complete(ctx context.Context, system, prompt string) (string, error)
+
+	// Name identifies the backend in logs and errors, e.g. "Ollama".
+	Name() string
+
+	// Model identifies the provider and model, e.g. "ollama/llama3".
+	Model() string
+
+	// Close releases the backend's resources.
+	Close() error
+}
+
+// Client implements Analyzer on top of a Completer.
+type Client struct {
+	completer Completer
+	debug     bool
+	defense   *security.AIDefense
+	validator *security.ResponseValidator
+	cache     *Cache
+}
+
+// ensure Client implements Analyzer interface.
+var _ Analyzer = (*Client)(nil)
+
+// NewClient creates an analyzer that sends the shared prompt through completer.
+func NewClient(completer Completer, debug bool) *Client {
+	return &Client{
+		completer: completer,
+		debug:     debug,
+		defense:   security.NewAIDefense(true), // Enable strict mode
+		validator: security.NewResponseValidator(),
+	}
+}
+
+// SetCache sets the cache verdicts are reused from. A nil cache disables caching.
+func (c *Client) SetCache(cache *Cache) {
+	c.cache = cache
+}
+
+// Model returns the backend's provider and model.
+func (c *Client) Model() string {
+	return c.completer.Model()
+}
+
+// Close closes the backend.
+func (c *Client) Close() error {
+	return c.completer.Close()
+}
+
+// AnalyzePRChanges analyzes PR changes to determine if they alter behavior. Changes
+// too large for one request are split between files or hunks, and any flag raised on
+// a part is raised for the PR. Changes that can't be analyzed without truncating them
+// fail with errors.ErrDiffTooLarge.
+func (c *Client) AnalyzePRChanges(ctx context.Context, files []FileChange, prContext PRContext) (*AnalysisResult, error) {
+	// Sanitize inputs before building prompt
+	sanitizedContext := c.sanitizePRContext(prContext)
+	sanitizedFiles, err := c.sanitizeFileChanges(files)
+	if err != nil {
+		log.Printf("[LLM] Not analyzing PR: %v", err)
+		return nil, err
+	}
+
+	// Check for security threats
+	if c.detectThreats(sanitizedContext, sanitizedFiles) {
+		log.Printf("[LLM] Security threat detected in PR content, returning conservative result")
+		return &AnalysisResult{
+			AltersBehavior:    true,
+			PossiblyMalicious: true,
+			Risky:             true,
+			Category:          "suspicious",
+			Reason:            "Security threat detected in PR content",
+		}, nil
+	}
+
+	// Large PRs are split into several requests rather than truncated
+	requests, err := planRequests(sanitizedFiles, sanitizedContext, promptBudget(c.Model()))
+	if err != nil {
+		log.Printf("[LLM] Not analyzing PR with %s: %v", c.Model(), err)
+		return nil, err
+	}
+	if len(requests) > 1 {
+		log.Printf("[LLM] Splitting %d files into %d %s requests", len(sanitizedFiles), len(requests), c.Model())
+	}
+
+	results := make([]*AnalysisResult, len(requests))
+	for i, request := range requests {
+		result, err := c.analyze(ctx, request, sanitizedContext, i+1, len(requests))
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return mergeResults(results), nil
+}
+
+// analyze asks the model about part of parts of a PR's sanitized changes.
+func (c *Client) analyze(ctx context.Context, files []FileChange, prContext PRContext, part, parts int) (*AnalysisResult, error) {
+	name := c.completer.Name()
+	prompt := buildPrompt(files, prContext, part, parts)
+
+	key := c.cacheKey(files, prContext, part, parts)
+	if cached, ok := c.cache.Get(key); ok {
+		log.Printf("[LLM] Reusing %s verdict cached at %s", c.Model(), cached.CachedAt.Format(time.RFC3339))
+		return cached, nil
+	}
+
+	if c.debug {
+		log.Printf("\n=== DEBUG: %s Request Summary ===", name)
+		log.Printf("Prompt length: %d characters", len(prompt))
+		log.Printf("Number of files analyzed: %d (part %d of %d)", len(files), part, parts)
+		// Security: Don't log the full prompt in production as it contains code
+		// Only log first 200 chars for debugging if needed
+		if len(prompt) > 200 {
+			log.Printf("Prompt preview: %s...", prompt[:200])
+		} else {
+			log.Printf("Prompt preview: %s", prompt)
+		}
+		log.Printf("=== END %s Request Summary ===", name)
+	}
+
+	var text string
+	err := retry.Do(ctx, constants.MaxRetryAttempts, retry.WithRetryableCheck(
+		func() error {
+			var err error
+			text, err = c.completer.Complete(ctx, SystemPrompt, prompt)
+			return err
+		},
+		func(err error) error {
+			return errors.API(name, "Complete", err)
+		},
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.debug {
+		log.Printf("\n=== DEBUG: %s Response ===", name)
+		log.Println(text)
+		log.Printf("=== END %s Response ===", name)
+	}
+
+	// Validate response structure
+	if err := c.validator.ValidateResponse(text); err != nil {
+		log.Printf("[LLM] Invalid %s response structure: %v", name, err)
+		return conservativeDefaults(err), nil
+	}
+
+	result, err := parseAnalysisResponse(text)
+	if err != nil {
+		// Conservative defaults are not the model's verdict, so they are not cached
+		log.Printf("[LLM] Unparseable %s response: %v", name, err)
+		return conservativeDefaults(err), nil
+	}
+	c.cache.Put(key, c.Model(), result)
+	return result, nil
+}
+
+// cacheKey hashes everything that determines the model's verdict: the model, the prompt
+// version, and the prompt built from the sanitized changes and context. The PR's
+// location is left out, so the same change in another repository or PR hits the cache.
+func (c *Client) cacheKey(files []FileChange, prContext PRContext, part, parts int) string {
+	prContext.URL = ""
+	prContext.Organization = ""
+	prContext.Repository = ""
+	prContext.PullRequestNumber = 0
+	return c.defense.HashContent(strings.Join([]string{
+		c.Model(),
+		strconv.Itoa(PromptVersion),
+		SystemPrompt,
+		buildPrompt(files, prContext, part, parts),
+	}, "\x00"))
+}
+
+// sanitizePRContext sanitizes PR context for security.
+func (c *Client) sanitizePRContext(ctx PRContext) PRContext {
+	titleResult := c.defense.SanitizePRTitle(ctx.Title)
+	descResult := c.defense.SanitizePRDescription(ctx.Description)
+
+	if titleResult.ThreatDetected || descResult.ThreatDetected {
+		log.Printf("[LLM] Security threats detected in PR metadata")
+		if titleResult.ThreatDetected {
+			log.Printf("[LLM]   Title: %v", titleResult.ThreatDetails)
+		}
+		if descResult.ThreatDetected {
+			log.Printf("[LLM]   Description: %v", descResult.ThreatDetails)
+		}
+	}
+
+	return PRContext{
+		URL:               ctx.URL,
+		Title:             titleResult.Sanitized,
+		Description:       descResult.Sanitized,
+		Author:            ctx.Author,
+		AuthorAssociation: ctx.AuthorAssociation,
+		Organization:      ctx.Organization,
+		Repository:        ctx.Repository,
+		BotProfile:        ctx.BotProfile,
+	}
+}
+
+// sanitizeFileChanges sanitizes file changes for security. Patches over
+// security.MaxPatchSize are first split between hunks, since sanitizing truncates them.
+func (c *Client) sanitizeFileChanges(files []FileChange) ([]FileChange, error) {
+	sanitized := make([]FileChange, 0, len(files))
+
+	for _, f := range files {
+		pieces, err := splitPatch(f, func(patch string) bool { return len(patch) <= security.MaxPatchSize })
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errors.ErrDiffTooLarge, err)
+		}
+
+		for _, file := range pieces {
+			patchResult := c.defense.SanitizePatch(file.Patch, file.Filename)
+
+			if patchResult.ThreatDetected {
+				log.Printf("[LLM] Security threat in patch for %s: %v",
+					file.Filename, patchResult.ThreatDetails)
+			}
+
+			sanitized = append(sanitized, FileChange{
+				Filename:  file.Filename,
+				Patch:     patchResult.Sanitized,
+				Additions: file.Additions,
+				Deletions: file.Deletions,
+			})
+		}
+	}
+
+	return sanitized, nil
+}
+
+// detectThreats checks if any sanitization detected threats.
+func (c *Client) detectThreats(ctx PRContext, files []FileChange) bool {
+	// Re-check context for threats
+	titleResult := c.defense.SanitizePRTitle(ctx.Title)
+	descResult := c.defense.SanitizePRDescription(ctx.Description)
+
+	if titleResult.ThreatDetected || descResult.ThreatDetected {
+		return true
+	}
+
+	// Check patches
+	for _, file := range files {
+		patchResult := c.defense.SanitizePatch(file.Patch, file.Filename)
+		if patchResult.ThreatDetected {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// trivialResponse is a model answer approving a typo fix.
+const trivialResponse = `{"alters_behavior": false, "not_improvement": false, "non_trivial": false, "category": "typo", "risky": false, "insecure_change": false, "possibly_malicious": false, "superfluous": false, "vandalism": false, "confusing": false, "title_desc_mismatch": false, "major_version_bump": false, "reason": "Fixes a typo in a comment"}`
+
+var typoFix = []FileChange{{
+	Filename:  "README.md",
+	Patch:     "@@ -1 +1 @@\n-Teh project\n+The project",
+	Additions: 1,
+	Deletions: 1,
+}}
+
+var typoContext = PRContext{Title: "Fix typo", Description: "Fixes a typo in the README"}
+
+func TestOpenAI(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	var got openAIRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": trivialResponse}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	completer, err := NewOpenAI(srv.URL+"/v1/", "gpt-test")
+	if err != nil {
+		t.Fatalf("NewOpenAI() error = %v", err)
+	}
+	result, err := NewClient(completer, false).AnalyzePRChanges(context.Background(), typoFix, typoContext)
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+
+	if result.AltersBehavior || result.Category != "typo" {
+		t.Errorf("result = %+v, want unaltered typo", result)
+	}
+	if got.Model != "gpt-test" || got.Temperature != 0 || len(got.Messages) != 2 {
+		t.Fatalf("request = %+v", got)
+	}
+	if got.Messages[0].Role != "system" || got.Messages[0].Content != SystemPrompt {
+		t.Errorf("system message = %+v", got.Messages[0])
+	}
+	if !strings.Contains(got.Messages[1].Content, "Teh project") {
+		t.Error("user message should contain the patch")
+	}
+}
+
+func TestOllama(t *testing.T) {
+	var got ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": trivialResponse},
+			"done":    true,
+		})
+	}))
+	defer srv.Close()
+
+	completer, err := NewOllama(srv.URL, "llama3")
+	if err != nil {
+		t.Fatalf("NewOllama() error = %v", err)
+	}
+	result, err := NewClient(completer, false).AnalyzePRChanges(context.Background(), typoFix, typoContext)
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+
+	if result.AltersBehavior || result.Category != "typo" {
+		t.Errorf("result = %+v, want unaltered typo", result)
+	}
+	if got.Model != "llama3" || got.Stream || got.Format != "json" {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+		check   func(*testing.T, *AnalysisResult)
+	}{
+		{
+			name:    "client error is not retried",
+			status:  http.StatusBadRequest,
+			body:    `{"error": "model not found"}`,
+			wantErr: true,
+		},
+		{
+			name:   "invalid response structure is conservative",
+			status: http.StatusOK,
+			body:   `{"message": {"role": "assistant", "content": "{\"category\": \"typo\"}"}}`,
+			check: func(t *testing.T, r *AnalysisResult) {
+				if !r.AltersBehavior || !r.Risky {
+					t.Errorf("result = %+v, want conservative defaults", r)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			completer, _ := NewOllama(srv.URL, "llama3")
+			result, err := NewClient(completer, false).AnalyzePRChanges(context.Background(), typoFix, typoContext)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if calls != 1 {
+					t.Errorf("calls = %d, want 1", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, result)
+		})
+	}
+}
+
+func TestNewOpenAIRequiresKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+
+	if _, err := NewOpenAI("", "gpt-test"); err == nil {
+		t.Error("NewOpenAI() with the default URL and no key should fail")
+	}
+	if _, err := NewOpenAI("http://localhost:8000/v1", "local"); err != nil {
+		t.Errorf("NewOpenAI() with a custom URL and no key error = %v", err)
+	}
+}
+
+func TestParseProvider(t *testing.T) {
+	for _, p := range Providers {
+		if got, err := ParseProvider(string(p)); err != nil || got != p {
+			t.Errorf("ParseProvider(%q) = %q, %v", p, got, err)
+		}
+	}
+	if got, err := ParseProvider(""); err != nil || got != ProviderGemini {
+		t.Errorf("ParseProvider(\"\") = %q, %v; want gemini", got, err)
+	}
+	if _, err := ParseProvider("claude"); err == nil {
+		t.Error("ParseProvider(\"claude\") should fail")
+	}
+}
+
+func TestParseModel(t *testing.T) {
+	tests := []struct {
+		spec         string
+		def          Provider
+		wantProvider Provider
+		wantModel    string
+	}{
+		{"gemini-2.0-flash", ProviderGemini, ProviderGemini, "gemini-2.0-flash"},
+		{"ollama:llama3", ProviderGemini, ProviderOllama, "llama3"},
+		{"ollama:llama3:8b", ProviderGemini, ProviderOllama, "llama3:8b"},
+		{"llama3:8b", ProviderOllama, ProviderOllama, "llama3:8b"},
+		{"openai:gpt-4o", ProviderOllama, ProviderOpenAI, "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		p, model := ParseModel(tt.spec, tt.def)
+		if p != tt.wantProvider || model != tt.wantModel {
+			t.Errorf("ParseModel(%q, %q) = %q, %q; want %q, %q", tt.spec, tt.def, p, model, tt.wantProvider, tt.wantModel)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+)
+
+// DefaultOllamaURL is the address of a local Ollama server.
+const DefaultOllamaURL = "http://localhost:11434"
+
+// Ollama sends prompts to an Ollama server, keeping diffs on infrastructure we control.
+type Ollama struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// ensure Ollama implements Completer interface.
+var _ Completer = (*Ollama)(nil)
+
+// NewOllama creates an Ollama backend. An empty baseURL uses the local server.
+func NewOllama(baseURL, model string) (*Ollama, error) {
+	if model == "" {
+		return nil, errors.Validation("model", model, "must not be empty")
+	}
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+
+	return &Ollama{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		client:  &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Name returns the provider name.
+func (o *Ollama) Name() string {
+	return "Ollama"
+}
+
+// Model returns the provider and model.
+func (o *Ollama) Model() string {
+	return string(ProviderOllama) + "/" + o.model
+}
+
+// Close does nothing; the HTTP client holds no resources that need releasing.
+func (o *Ollama) Close() error {
+	return nil
+}
+
+// ollamaRequest is an /api/chat request.
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format"`
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict"`
+	NumCtx      int     `json:"num_ctx"`
+}
+
+// ollamaResponse is the subset of an /api/chat response we use.
+type ollamaResponse struct {
+	Message message `json:"message"`
+}
+
+// Complete generates a JSON response with temperature 0.
+func (o *Ollama) Complete(ctx context.Context, system, prompt string) (string, error) {
+	var resp ollamaResponse
+	err := postJSON(ctx, o.client, o.baseURL+"/api/chat", nil, ollamaRequest{
+		Model:    o.model,
+		Messages: chatMessages(system, prompt),
+		Stream:   false,
+		Format:   "json",
+		Options:  ollamaOptions{Temperature: 0, NumPredict: maxOutputTokens, NumCtx: ollamaContextWindow},
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("Ollama API returned empty response content")
+	}
+	return resp.Message.Content, nil
+}
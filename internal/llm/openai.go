@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+)
+
+// DefaultOpenAIURL is the base URL of the OpenAI API.
+const DefaultOpenAIURL = "https://api.openai.com/v1"
+
+// OpenAI sends prompts to an OpenAI-compatible chat completions endpoint.
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// ensure OpenAI implements Completer interface.
+var _ Completer = (*OpenAI)(nil)
+
+// NewOpenAI creates an OpenAI-compatible backend. An empty baseURL uses the OpenAI API.
+// The API key is read from OPENAI_API_KEY and is only required for the OpenAI API itself,
+// since self-hosted compatible servers often run without authentication.
+func NewOpenAI(baseURL, model string) (*OpenAI, error) {
+	if model == "" {
+		return nil, errors.Validation("model", model, "must not be empty")
+	}
+	if baseURL == "" {
+		baseURL = DefaultOpenAIURL
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" && baseURL == DefaultOpenAIURL {
+		return nil, errors.ErrNoOpenAIKey
+	}
+
+	return &OpenAI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Name returns the provider name.
+func (o *OpenAI) Name() string {
+	return "OpenAI"
+}
+
+// Model returns the provider and model.
+func (o *OpenAI) Model() string {
+	return string(ProviderOpenAI) + "/" + o.model
+}
+
+// Close does nothing; the HTTP client holds no resources that need releasing.
+func (o *OpenAI) Close() error {
+	return nil
+}
+
+// openAIRequest is a chat completions request.
+type openAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	MaxTokens   int       `json:"max_tokens"`
+}
+
+// openAIResponse is the subset of a chat completions response we use.
+type openAIResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete generates a response with temperature 0.
+func (o *OpenAI) Complete(ctx context.Context, system, prompt string) (string, error) {
+	var header http.Header
+	if o.apiKey != "" {
+		header = http.Header{"Authorization": []string{"Bearer " + o.apiKey}}
+	}
+
+	var resp openAIResponse
+	err := postJSON(ctx, o.client, o.baseURL+"/chat/completions", header, openAIRequest{
+		Model:       o.model,
+		Messages:    chatMessages(system, prompt),
+		Temperature: 0,
+		MaxTokens:   maxOutputTokens,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("OpenAI API returned no response choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
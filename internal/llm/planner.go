@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// MaxRequests bounds the number of requests a PR's changes are split into.
+const MaxRequests = 10
+
+// charsPerToken estimates token counts. Code tokenizes worse than prose, so this
+// is lower than the usual four characters per token.
+const charsPerToken = 3
+
+// defaultContextWindow is the context size, in tokens, assumed for unknown models.
+const defaultContextWindow = 8192
+
+// ollamaContextWindow is the context size requested from Ollama, whose own default
+// is small enough to silently drop the start of a prompt.
+const ollamaContextWindow = 8192
+
+// contextWindows are the context sizes, in tokens, of models by Model() prefix.
+// The longest matching prefix wins.
+var contextWindows = map[string]int{
+	string(ProviderGemini) + "/":        1_000_000,
+	string(ProviderOpenAI) + "/gpt-4o":  128_000,
+	string(ProviderOpenAI) + "/gpt-4.1": 1_000_000,
+	string(ProviderOpenAI) + "/gpt-3.5": 16_385,
+	string(ProviderOllama) + "/":        ollamaContextWindow,
+}
+
+// EstimateTokens estimates the number of tokens in s.
+func EstimateTokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// ContextWindow returns the context size, in tokens, of model (e.g. "ollama/llama3").
+func ContextWindow(model string) int {
+	window, longest := defaultContextWindow, -1
+	for prefix, tokens := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longest {
+			window, longest = tokens, len(prefix)
+		}
+	}
+	return window
+}
+
+// promptBudget returns the number of tokens a request to model may use: its context
+// window less room for the answer, and never more than security.MaxTotalPromptSize.
+func promptBudget(model string) int {
+	return min(ContextWindow(model)-2*maxOutputTokens, security.MaxTotalPromptSize/charsPerToken)
+}
+
+// fileTokens estimates the tokens of a file's section of the prompt.
+func fileTokens(f FileChange) int {
+	return EstimateTokens(f.Filename) + EstimateTokens(f.Patch) + 20 // Headers and fences
+}
+
+// planRequests splits files into requests whose prompts, with the system prompt, fit
+// in budget tokens. Files are kept whole where possible and split between hunks
+// otherwise. Nothing is ever truncated: a hunk that doesn't fit on its own, or changes
+// needing more than MaxRequests requests, fail with errors.ErrDiffTooLarge.
+func planRequests(files []FileChange, prContext PRContext, budget int) ([][]FileChange, error) {
+	if EstimateTokens(SystemPrompt)+EstimateTokens(BuildAnalysisPrompt(files, prContext)) <= budget {
+		return [][]FileChange{files}, nil
+	}
+
+	// Room left for changes in each request, with the part note the prompt then carries
+	available := budget - EstimateTokens(SystemPrompt) - EstimateTokens(buildPrompt(nil, prContext, MaxRequests, MaxRequests))
+	if available <= 0 {
+		return nil, fmt.Errorf("%w: the PR title and description alone exceed the %d token budget", errors.ErrDiffTooLarge, budget)
+	}
+
+	var pieces []FileChange
+	for _, f := range files {
+		split, err := splitPatch(f, func(patch string) bool {
+			return fileTokens(FileChange{Filename: f.Filename, Patch: patch}) <= available
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v (budget %d tokens)", errors.ErrDiffTooLarge, err, budget)
+		}
+		pieces = append(pieces, split...)
+	}
+
+	var requests [][]FileChange
+	var current []FileChange
+	used := 0
+	for _, piece := range pieces {
+		tokens := fileTokens(piece)
+		if len(current) > 0 && used+tokens > available {
+			requests = append(requests, current)
+			current, used = nil, 0
+		}
+		current = append(current, piece)
+		used += tokens
+	}
+	if len(current) > 0 {
+		requests = append(requests, current)
+	}
+
+	if len(requests) > MaxRequests {
+		return nil, fmt.Errorf("%w: the changes need %d requests, at most %d are made", errors.ErrDiffTooLarge, len(requests), MaxRequests)
+	}
+	return requests, nil
+}
+
+// splitPatch returns f whole if fits accepts its patch, and otherwise splits it into
+// pieces of consecutive hunks that each fit. A hunk that doesn't fit is an error.
+func splitPatch(f FileChange, fits func(patch string) bool) ([]FileChange, error) {
+	if fits(f.Patch) {
+		return []FileChange{f}, nil
+	}
+
+	var pieces []FileChange
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			pieces = append(pieces, patchPiece(f.Filename, strings.Join(current, "\n")))
+			current = nil
+		}
+	}
+	for _, hunk := range splitHunks(f.Patch) {
+		if !fits(hunk) {
+			header, _, _ := strings.Cut(hunk, "\n")
+			return nil, fmt.Errorf("hunk %q of %s is too large to analyze", header, f.Filename)
+		}
+		if !fits(strings.Join(append(current, hunk), "\n")) {
+			flush()
+		}
+		current = append(current, hunk)
+	}
+	flush()
+	return pieces, nil
+}
+
+// splitHunks splits a unified diff at its "@@" hunk headers.
+func splitHunks(patch string) []string {
+	var hunks []string
+	var current []string
+	for _, line := range strings.Split(patch, "\n") {
+		if strings.HasPrefix(line, "@@") && len(current) > 0 {
+			hunks = append(hunks, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	return append(hunks, strings.Join(current, "\n"))
+}
+
+// patchPiece returns part of a file's patch, counting its own additions and deletions.
+func patchPiece(filename, patch string) FileChange {
+	piece := FileChange{Filename: filename, Patch: patch}
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			piece.Additions++
+		case strings.HasPrefix(line, "-"):
+			piece.Deletions++
+		}
+	}
+	return piece
+}
+
+// mergeResults combines the verdicts of the requests a PR was split into. Any flag
+// raised by one request is raised for the PR; the category is the one all requests
+// agree on, "other" if they disagree, or none if any request has none.
+func mergeResults(results []*AnalysisResult) *AnalysisResult {
+	if len(results) == 1 {
+		return results[0]
+	}
+
+	merged := &AnalysisResult{Category: results[0].Category, Confidence: results[0].Confidence, Cached: true, Parts: len(results)}
+	reasons := make([]string, len(results))
+	for i, r := range results {
+		merged.AltersBehavior = merged.AltersBehavior || r.AltersBehavior
+		merged.NotImprovement = merged.NotImprovement || r.NotImprovement
+		merged.NonTrivial = merged.NonTrivial || r.NonTrivial
+		merged.Risky = merged.Risky || r.Risky
+		merged.InsecureChange = merged.InsecureChange || r.InsecureChange
+		merged.PossiblyMalicious = merged.PossiblyMalicious || r.PossiblyMalicious
+		merged.Superfluous = merged.Superfluous || r.Superfluous
+		merged.Vandalism = merged.Vandalism || r.Vandalism
+		merged.Confusing = merged.Confusing || r.Confusing
+		merged.TitleDescMismatch = merged.TitleDescMismatch || r.TitleDescMismatch
+		merged.MajorVersionBump = merged.MajorVersionBump || r.MajorVersionBump
+		merged.Confidence = min(merged.Confidence, r.Confidence)
+
+		switch {
+		case r.Category == "" || merged.Category == "":
+			merged.Category = ""
+		case r.Category != merged.Category:
+			merged.Category = "other"
+		}
+
+		// The verdict is cached only if every part was, as of the oldest part
+		merged.Cached = merged.Cached && r.Cached
+		if r.Cached && (merged.CachedAt.IsZero() || r.CachedAt.Before(merged.CachedAt)) {
+			merged.CachedAt = r.CachedAt
+		}
+		reasons[i] = fmt.Sprintf("part %d/%d: %s", i+1, len(results), r.Reason)
+	}
+	merged.Reason = strings.Join(reasons, "; ")
+	if !merged.Cached {
+		merged.CachedAt = time.Time{}
+	}
+	return merged
+}
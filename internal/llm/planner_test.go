@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thegroove/trivial-auto-approve/internal/errors"
+	"github.com/thegroove/trivial-auto-approve/internal/security"
+)
+
+// hunks returns a patch of n hunks, each adding lines of about size bytes in total.
+func hunks(n, size int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "@@ -%d,0 +%d,1 @@", i*100, i*100)
+		for j := 0; j < size/40; j++ {
+			fmt.Fprintf(&b, "\n+Changelog entry %04d for release %04d.", j, i)
+		}
+	}
+	return b.String()
+}
+
+func TestPlanRequests(t *testing.T) {
+	const budget = 4000
+	small := FileChange{Filename: "README.md", Patch: hunks(1, 200)}
+	medium := FileChange{Filename: "CHANGELOG.md", Patch: hunks(1, 6000)}
+	large := FileChange{Filename: "docs/guide.md", Patch: hunks(4, 6000)}
+
+	tests := []struct {
+		name      string
+		files     []FileChange
+		wantParts int
+		wantErr   bool
+	}{
+		{"fits in one request", []FileChange{small, small}, 1, false},
+		{"split between files", []FileChange{medium, medium, small}, 2, false},
+		{"split between hunks", []FileChange{large}, 4, false},
+		{"hunk too large", []FileChange{{Filename: "big.md", Patch: hunks(1, 30000)}}, 0, true},
+		{"too many requests", []FileChange{large, large, large}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, err := planRequests(tt.files, typoContext, budget)
+			if tt.wantErr {
+				if !stderrors.Is(err, errors.ErrDiffTooLarge) {
+					t.Fatalf("planRequests() error = %v, want ErrDiffTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planRequests() error = %v", err)
+			}
+			if len(requests) != tt.wantParts {
+				t.Fatalf("planRequests() = %d requests, want %d", len(requests), tt.wantParts)
+			}
+
+			// Every request fits, and together they carry every line of every patch
+			patches := make(map[string][]string)
+			for i, request := range requests {
+				prompt := buildPrompt(request, typoContext, i+1, len(requests))
+				if tokens := EstimateTokens(SystemPrompt) + EstimateTokens(prompt); tokens > budget {
+					t.Errorf("request %d needs %d tokens, budget %d", i+1, tokens, budget)
+				}
+				for _, f := range request {
+					patches[f.Filename] = append(patches[f.Filename], f.Patch)
+				}
+			}
+			for _, f := range tt.files {
+				if got := strings.Join(patches[f.Filename], "\n"); !strings.Contains(got, f.Patch) {
+					t.Errorf("%s: patch not carried whole by the requests", f.Filename)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	merged := mergeResults([]*AnalysisResult{
+		{Category: "markdown", Reason: "Docs", Confidence: 0.9},
+		{Category: "markdown", Reason: "More docs", Risky: true, Confidence: 0.8},
+	})
+	if !merged.Risky || merged.AltersBehavior || merged.Category != "markdown" || merged.Parts != 2 || merged.Confidence != 0.8 {
+		t.Errorf("mergeResults() = %+v, want risky markdown in 2 parts", merged)
+	}
+	if merged.Reason != "part 1/2: Docs; part 2/2: More docs" {
+		t.Errorf("Reason = %q", merged.Reason)
+	}
+
+	if merged := mergeResults([]*AnalysisResult{{Category: "typo"}, {Category: "markdown"}}); merged.Category != "other" {
+		t.Errorf("Category = %q, want other for disagreeing parts", merged.Category)
+	}
+	if merged := mergeResults([]*AnalysisResult{{Category: "typo"}, {Category: ""}}); merged.Category != "" {
+		t.Errorf("Category = %q, want none when a part has none", merged.Category)
+	}
+}
+
+func TestClientSplitsLargeDiff(t *testing.T) {
+	// Ollama models get a small budget, so three large files need three requests
+	completer := &countingCompleter{model: "ollama/test", response: trivialResponse}
+	files := []FileChange{
+		{Filename: "a.md", Patch: hunks(1, 12000)},
+		{Filename: "b.md", Patch: hunks(1, 12000)},
+		{Filename: "c.md", Patch: hunks(1, 12000)},
+	}
+	result, err := NewClient(completer, false).AnalyzePRChanges(context.Background(), files, typoContext)
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if completer.calls != 3 || result.Parts != 3 || result.Category != "typo" {
+		t.Errorf("result = %+v after %d calls, want a typo verdict in 3 parts", result, completer.calls)
+	}
+	for i, prompt := range completer.prompts {
+		if want := fmt.Sprintf("This is part %d of 3", i+1); !strings.Contains(prompt, want) {
+			t.Errorf("prompt %d does not say %q", i+1, want)
+		}
+	}
+}
+
+func TestClientNeverTruncates(t *testing.T) {
+	// A patch over MaxPatchSize is split between hunks rather than truncated
+	completer := &countingCompleter{model: "gemini/test", response: trivialResponse}
+	patch := hunks(2, security.MaxPatchSize*3/4)
+	result, err := NewClient(completer, false).AnalyzePRChanges(context.Background(),
+		[]FileChange{{Filename: "CHANGELOG.md", Patch: patch}}, typoContext)
+	if err != nil {
+		t.Fatalf("AnalyzePRChanges() error = %v", err)
+	}
+	if result.Category != "typo" || completer.calls == 0 || strings.Contains(strings.Join(completer.prompts, ""), "truncated") {
+		t.Errorf("result = %+v after %d calls, want the model's verdict on the whole patch", result, completer.calls)
+	}
+
+	// A hunk over MaxPatchSize can't be analyzed whole, so it isn't analyzed at all
+	completer = &countingCompleter{model: "gemini/test", response: trivialResponse}
+	_, err = NewClient(completer, false).AnalyzePRChanges(context.Background(),
+		[]FileChange{{Filename: "CHANGELOG.md", Patch: hunks(1, security.MaxPatchSize+1000)}}, typoContext)
+	if !stderrors.Is(err, errors.ErrDiffTooLarge) || completer.calls != 0 {
+		t.Errorf("AnalyzePRChanges() error = %v after %d calls, want ErrDiffTooLarge without calls", err, completer.calls)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gemini/gemini-2.0-flash": 1_000_000,
+		"openai/gpt-4o-mini":      128_000,
+		"openai/gpt-3.5-turbo":    16_385,
+		"ollama/llama3.1":         ollamaContextWindow,
+		"openai/unknown":          defaultContextWindow,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
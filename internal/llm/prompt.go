@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PromptVersion identifies the prompts and response parsing. Increment it when they
+// change in a way that could change verdicts, so cached verdicts are not reused.
+const PromptVersion = 1
+
+// SystemPrompt is the system instruction shared by every provider.
+const SystemPrompt = `You are a skeptical and critical software engineer analyzing open-source pull request changes for security and quality.
+Your task is to evaluate multiple aspects of the changes:
+
+1. Behavior: Does this alter application behavior?
+2. Improvement: Is this change an improvement or just garbage?
+3. Triviality: Is this a trivial change (typo, comment, formatting, minor dependency update, security fix, or version bump)?
+4. Risk Level: Is this a low-risk change?
+5. Security: Could this introduce security vulnerabilities?
+6. Maliciousness: Could this be a malicious change?
+7. Necessity: Is this change useful (not superfluous)?
+8. Vandalism: Could this be vandalism or destructive?
+9. Clarity: Could this introduce confusion or reduce code clarity?
+10. Accuracy: Is the PR title/description useful and accurately represent the changes?
+11. Major Version Bump: Does this include a major version bump in any dependency?
+
+For dependency updates, pay special attention to version changes:
+- Major version bumps (e.g., v1.x.x to v2.x.x) often include breaking changes
+- Minor and patch updates are typically safer
+- Check package.json, go.mod, pom.xml, requirements.txt, Gemfile, etc.
+
+IMPORTANT: For PRs whose Author Bot Profile is dependency-only or lockfile-only (such as dependabot[bot] and renovate[bot]):
+- Dependency updates that are NOT major version bumps should be marked as alters_behavior: false
+- Only major version bumps from these bots should be marked as alters_behavior: true
+- Minor and patch version updates from these bots do not alter application behavior
+
+For PRs whose Author Bot Profile is formatting-only, whitespace and formatting changes do not alter behavior.
+For PRs whose Author Bot Profile is generated-files, regenerated files are expected; judge whether the regenerated content is plausible.
+
+Analyze conservatively - when in doubt:
+- Assume higher risk, unless the PR is by a bot with an Author Bot Profile
+- Flag potential security issues
+- Flag suspicious or unnecessary changes
+- Minor or patch-level updates to dependencies should be considered trivial and not behavior changing
+- Major version bumps should always be flagged
+
+Focus on the actual impact and intent of changes, not just syntax.
+
+Pull requests by dependency bots are normally low risk, trivial, dependency changes that do not alter program behavior unless the major version changes.
+`
+
+var analysisPromptTemplate = template.Must(template.New("analysis").Parse(`
+Analyze the following pull request:
+
+PR URL: {{.Context.URL}}
+PR Title: {{.Context.Title}}
+PR Description: {{.Context.Description}}
+PR Author: {{.Context.Author}}
+Author Association: {{.Context.AuthorAssociation}}
+{{if .Context.BotProfile}}Author Bot Profile: {{.Context.BotProfile}}
+{{end}}Repository: {{.Context.Organization}}/{{.Context.Repository}}
+{{if gt .Parts 1}}
+This is part {{.Part}} of {{.Parts}} of the PR's changes; the other parts are analyzed separately. Judge only the changes shown, and set title_desc_mismatch only if they contradict the title and description.
+{{end}}
+Changes:
+{{range .Files}}
+File: {{.Filename}}
+Additions: {{.Additions}}, Deletions: {{.Deletions}}
+Patch:
+` + "```" + `
+{{.Patch}}
+` + "```" + `
+
+{{end}}
+Return ONLY this JSON (set flags to true only if they apply, false is default):
+{"alters_behavior":bool,"not_improvement":bool,"non_trivial":bool,"category":"typo|comment|markdown|lint|dependency|config|refactor|bugfix|feature|other","risky":bool,"insecure_change":bool,"possibly_malicious":bool,"superfluous":bool,"vandalism":bool,"confusing":bool,"title_desc_mismatch":bool,"major_version_bump":bool,"reason":"brief explanation"}
+`))
+
+// BuildAnalysisPrompt builds the user prompt for a PR, ending with the expected JSON schema.
+func BuildAnalysisPrompt(files []FileChange, prContext PRContext) string {
+	return buildPrompt(files, prContext, 1, 1)
+}
+
+// buildPrompt builds the user prompt for part of parts of a PR's changes.
+func buildPrompt(files []FileChange, prContext PRContext, part, parts int) string {
+	var sb strings.Builder
+	data := struct {
+		Context     PRContext
+		Files       []FileChange
+		Part, Parts int
+	}{
+		Context: prContext,
+		Files:   files,
+		Part:    part,
+		Parts:   parts,
+	}
+
+	if err := analysisPromptTemplate.Execute(&sb, data); err != nil {
+		// Fallback to manual formatting
+		return buildManualPrompt(files, prContext, part, parts)
+	}
+
+	return sb.String()
+}
+
+// buildManualPrompt creates prompt without template.
+func buildManualPrompt(files []FileChange, prContext PRContext, part, parts int) string {
+	var sb strings.Builder
+
+	sb.WriteString("Analyze the following pull request:\n\n")
+	sb.WriteString(fmt.Sprintf("PR URL: %s\n", prContext.URL))
+	sb.WriteString(fmt.Sprintf("PR Title: %s\n", prContext.Title))
+	sb.WriteString(fmt.Sprintf("PR Description: %s\n", prContext.Description))
+	sb.WriteString(fmt.Sprintf("PR Author: %s\n", prContext.Author))
+	sb.WriteString(fmt.Sprintf("Author Association: %s\n", prContext.AuthorAssociation))
+	if prContext.BotProfile != "" {
+		sb.WriteString(fmt.Sprintf("Author Bot Profile: %s\n", prContext.BotProfile))
+	}
+	sb.WriteString(fmt.Sprintf("Repository: %s/%s\n\n", prContext.Organization, prContext.Repository))
+	if parts > 1 {
+		sb.WriteString(fmt.Sprintf("This is part %d of %d of the PR's changes; the other parts are analyzed separately. ", part, parts))
+		sb.WriteString("Judge only the changes shown, and set title_desc_mismatch only if they contradict the title and description.\n\n")
+	}
+	sb.WriteString("Changes:\n")
+
+	for _, file := range files {
+		sb.WriteString(fmt.Sprintf("File: %s\n", file.Filename))
+		sb.WriteString(fmt.Sprintf("Additions: %d, Deletions: %d\n", file.Additions, file.Deletions))
+		sb.WriteString("Patch:\n```\n")
+		sb.WriteString(file.Patch)
+		sb.WriteString("\n```\n\n")
+	}
+
+	sb.WriteString("\nPlease analyze these changes and respond with a JSON object containing the following fields:\n")
+	sb.WriteString(`{
+  "alters_behavior": boolean,
+  "not_improvement": boolean,
+  "non_trivial": boolean,
+  "category": string,
+  "risky": boolean,
+  "insecure_change": boolean,
+  "possibly_malicious": boolean,
+  "superfluous": boolean,
+  "vandalism": boolean,
+  "confusing": boolean,
+  "title_desc_mismatch": boolean,
+  "major_version_bump": boolean,
+  "reason": string
+}
+Return ONLY the JSON object, no additional text.`)
+
+	return sb.String()
+}
+
+// jsonResponse is the structure we expect from the model.
+type jsonResponse struct {
+	AltersBehavior    bool   `json:"alters_behavior"`
+	NotImprovement    bool   `json:"not_improvement"`
+	NonTrivial        bool   `json:"non_trivial"`
+	Category          string `json:"category"`
+	Risky             bool   `json:"risky"`
+	InsecureChange    bool   `json:"insecure_change"`
+	PossiblyMalicious bool   `json:"possibly_malicious"`
+	Superfluous       bool   `json:"superfluous"`
+	Vandalism         bool   `json:"vandalism"`
+	Confusing         bool   `json:"confusing"`
+	TitleDescMismatch bool   `json:"title_desc_mismatch"`
+	MajorVersionBump  bool   `json:"major_version_bump"`
+	Reason            string `json:"reason"`
+}
+
+// ParseAnalysisResponse parses a model response. Unparseable responses yield conservative defaults.
+func ParseAnalysisResponse(response string) (*AnalysisResult, error) {
+	result, err := parseAnalysisResponse(response)
+	if err != nil {
+		// Return conservative defaults on parse failure
+		return conservativeDefaults(err), nil
+	}
+	return result, nil
+}
+
+// parseAnalysisResponse parses a model response, failing if it is not valid JSON.
+func parseAnalysisResponse(response string) (*AnalysisResult, error) {
+	// Clean up response
+	response = cleanJSONResponse(response)
+
+	// Try to parse JSON
+	var jsonResp jsonResponse
+	if err := json.Unmarshal([]byte(response), &jsonResp); err != nil {
+		return nil, fmt.Errorf("failed to parse model JSON response: %w", err)
+	}
+
+	return jsonResponseToResult(&jsonResp), nil
+}
+
+// cleanJSONResponse removes markdown code blocks from response.
+func cleanJSONResponse(response string) string {
+	response = strings.TrimSpace(response)
+
+	// Remove markdown code blocks
+	if strings.HasPrefix(response, "```json") {
+		response = strings.TrimPrefix(response, "```json")
+		response = strings.TrimSuffix(response, "```")
+	} else if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```")
+		response = strings.TrimSuffix(response, "```")
+	}
+
+	return strings.TrimSpace(response)
+}
+
+// defaultConfidence is assigned to parsed responses, since models don't report confidence.
+// Unparseable responses keep zero confidence.
+const defaultConfidence = 0.9
+
+// jsonResponseToResult converts JSON response to AnalysisResult.
+func jsonResponseToResult(resp *jsonResponse) *AnalysisResult {
+	return &AnalysisResult{
+		AltersBehavior:    resp.AltersBehavior,
+		NotImprovement:    resp.NotImprovement,
+		NonTrivial:        resp.NonTrivial,
+		Category:          resp.Category,
+		Risky:             resp.Risky,
+		InsecureChange:    resp.InsecureChange,
+		PossiblyMalicious: resp.PossiblyMalicious,
+		Superfluous:       resp.Superfluous,
+		Vandalism:         resp.Vandalism,
+		Confusing:         resp.Confusing,
+		TitleDescMismatch: resp.TitleDescMismatch,
+		MajorVersionBump:  resp.MajorVersionBump,
+		Reason:            resp.Reason,
+		Confidence:        defaultConfidence,
+	}
+}
+
+// conservativeDefaults returns safe defaults that will reject the PR.
+func conservativeDefaults(err error) *AnalysisResult {
+	return &AnalysisResult{
+		AltersBehavior:    true,  // Assume it alters behavior
+		NotImprovement:    true,  // Assume it's not an improvement
+		NonTrivial:        true,  // Assume it's non-trivial
+		Risky:             true,  // Assume it's risky
+		InsecureChange:    false, // Don't falsely accuse of security issues
+		PossiblyMalicious: false, // Don't falsely accuse of malicious intent
+		Superfluous:       true,  // Assume it's unnecessary
+		Vandalism:         false, // Don't falsely accuse of vandalism
+		Confusing:         true,  // Assume it's confusing
+		TitleDescMismatch: true,  // Assume mismatch
+		MajorVersionBump:  true,  // Assume major version bump (safer)
+		Category:          "",    // No category = will be rejected
+		Reason:            fmt.Sprintf("Failed to parse model response: %v", err),
+	}
+}
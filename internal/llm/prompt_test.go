@@ -1,4 +1,4 @@
-package gemini
+package llm
 
 import (
 	"strings"
@@ -178,20 +178,20 @@ func TestParseAnalysisResponse(t *testing.T) {
 				Confusing:         true,
 				TitleDescMismatch: true,
 				MajorVersionBump:  true,
-				Reason:            "Failed to parse Gemini response: failed to parse Gemini JSON response: invalid character 'T' looking for beginning of value",
+				Reason:            "Failed to parse model response: failed to parse model JSON response: invalid character 'T' looking for beginning of value",
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseAnalysisResponse(tt.response)
+			got, err := ParseAnalysisResponse(tt.response)
 			if err != nil {
-				t.Errorf("parseAnalysisResponse() error = %v", err)
+				t.Errorf("ParseAnalysisResponse() error = %v", err)
 				return
 			}
 			if got == nil {
-				t.Fatal("parseAnalysisResponse() returned nil")
+				t.Fatal("ParseAnalysisResponse() returned nil")
 			}
 
 			if got.AltersBehavior != tt.want.AltersBehavior {
@@ -258,7 +258,7 @@ func TestBuildAnalysisPrompt(t *testing.T) {
 		URL:               "https://github.com/testorg/testrepo/pull/123",
 	}
 
-	prompt := buildAnalysisPrompt(files, prContext)
+	prompt := BuildAnalysisPrompt(files, prContext)
 
 	// Check that prompt contains expected elements
 	if !strings.Contains(prompt, "PR Title: Fix typo") {
@@ -291,6 +291,14 @@ func TestBuildAnalysisPrompt(t *testing.T) {
 	if !strings.Contains(prompt, "Return ONLY this JSON") || !strings.Contains(prompt, "major_version_bump") {
 		t.Errorf("Prompt missing JSON format instructions with major_version_bump field")
 	}
+	if strings.Contains(prompt, "Author Bot Profile") {
+		t.Errorf("Prompt has a bot profile for a human author")
+	}
+
+	prContext.BotProfile = "dependency-only"
+	if prompt := BuildAnalysisPrompt(files, prContext); !strings.Contains(prompt, "Author Bot Profile: dependency-only\n") {
+		t.Errorf("Prompt missing bot profile")
+	}
 }
 
 func TestCleanJSONResponse(t *testing.T) {
@@ -0,0 +1,326 @@
+// Package processor acts on analyzer results.
+// It separates deciding whether a PR is approvable (the analyzer's job) from
+// acting on that decision: dismissing our approvals of earlier commits that
+// the new ones don't deserve, approving, enabling auto-merge, merging directly
+// when the PR is already mergeable, and updating the branch.
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+	appErrors "github.com/thegroove/trivial-auto-approve/internal/errors"
+	githubAPI "github.com/thegroove/trivial-auto-approve/internal/github"
+)
+
+// Options controls which actions the processor performs.
+type Options struct {
+	// DryRun records every action as skipped instead of performing it.
+	DryRun bool
+
+	// AutoMerge enables auto-merge, merging directly if the PR is already mergeable.
+	AutoMerge bool
+
+	// AutoRebase updates the PR branch with the base branch.
+	AutoRebase bool
+
+	// ApprovalBody is the review body used when approving.
+	// If empty, a default body including the analyzer's reason is used.
+	ApprovalBody string
+}
+
+// Action identifies a single step the processor can take on a PR.
+type Action string
+
+// Actions in the order the processor considers them.
+const (
+	ActionDismissStale    Action = "dismiss_stale_approval"
+	ActionApprove         Action = "approve"
+	ActionEnableAutoMerge Action = "enable_auto_merge"
+	ActionMerge           Action = "merge"
+	ActionUpdateBranch    Action = "update_branch"
+)
+
+// Status is the outcome of a single step.
+type Status string
+
+// Step statuses.
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+// Step records what happened for one action.
+type Step struct {
+	Action Action
+	Status Status
+	Reason string // Why the step was skipped or how it failed
+	Err    error  // Set when Status is StatusFailed
+}
+
+// Outcome records what the processor did for a single PR.
+type Outcome struct {
+	Owner      string
+	Repo       string
+	Number     int
+	Approvable bool
+	Deferred   bool   // Required checks are pending; the PR should be processed again later
+	Reason     string // The analyzer's reason
+	Steps      []Step
+}
+
+// Attempted reports whether the processor called the GitHub API for the action.
+func (o *Outcome) Attempted(action Action) bool {
+	for _, s := range o.Steps {
+		if s.Action == action && s.Status != StatusSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// Succeeded reports whether the action completed successfully.
+func (o *Outcome) Succeeded(action Action) bool {
+	for _, s := range o.Steps {
+		if s.Action == action && s.Status == StatusSucceeded {
+			return true
+		}
+	}
+	return false
+}
+
+// Skipped reports whether the action was considered but not attempted.
+func (o *Outcome) Skipped(action Action) bool {
+	for _, s := range o.Steps {
+		if s.Action == action && s.Status == StatusSkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the error of the first failed step, or nil.
+func (o *Outcome) Err() error {
+	for _, s := range o.Steps {
+		if s.Status == StatusFailed {
+			return fmt.Errorf("%s: %w", s.Action, s.Err)
+		}
+	}
+	return nil
+}
+
+// String returns a one-line summary of the outcome.
+func (o *Outcome) String() string {
+	summary := fmt.Sprintf("%s/%s#%d:", o.Owner, o.Repo, o.Number)
+	if len(o.Steps) == 0 {
+		return summary + " no actions"
+	}
+	for _, s := range o.Steps {
+		summary += fmt.Sprintf(" %s=%s", s.Action, s.Status)
+	}
+	return summary
+}
+
+// state is a node in the processor's state machine.
+type state int
+
+const (
+	stateDismissStale state = iota
+	stateApprove
+	stateEnableAutoMerge
+	stateMerge
+	stateUpdateBranch
+	stateDone
+)
+
+// Processor runs the approve/merge/rebase sequence against the GitHub API.
+type Processor struct {
+	gh   githubAPI.API
+	opts Options
+}
+
+// New creates a new processor.
+func New(gh githubAPI.API, opts Options) (*Processor, error) {
+	if gh == nil {
+		return nil, fmt.Errorf("github client is required")
+	}
+	return &Processor{gh: gh, opts: opts}, nil
+}
+
+// Process acts on an analyzer result for a PR and returns what was done.
+// The returned error is the first failed step, if any; the outcome is
+// always returned so callers can report partial progress.
+func (p *Processor) Process(ctx context.Context, owner, repo string, number int, result *analyzer.Result) (*Outcome, error) {
+	if result == nil {
+		return nil, fmt.Errorf("analysis result is required")
+	}
+
+	out := &Outcome{
+		Owner:      owner,
+		Repo:       repo,
+		Number:     number,
+		Approvable: result.Approvable,
+		Deferred:   result.Deferred,
+		Reason:     result.Reason,
+	}
+
+	for s := stateDismissStale; s != stateDone; {
+		s = p.step(ctx, s, result, out)
+	}
+
+	for _, s := range out.Steps {
+		if s.Status == StatusFailed {
+			log.Printf("[PROCESSOR] %s/%s#%d: %s failed: %v", owner, repo, number, s.Action, s.Err)
+		}
+	}
+	log.Printf("[PROCESSOR] %s", out)
+
+	return out, out.Err()
+}
+
+// step performs the action for the given state and returns the next state.
+func (p *Processor) step(ctx context.Context, s state, result *analyzer.Result, out *Outcome) state {
+	switch s {
+	case stateDismissStale:
+		return p.dismissStale(ctx, result, out)
+	case stateApprove:
+		return p.approve(ctx, result, out)
+	case stateEnableAutoMerge:
+		return p.enableAutoMerge(ctx, result, out)
+	case stateMerge:
+		return p.merge(ctx, out)
+	case stateUpdateBranch:
+		return p.updateBranch(ctx, result, out)
+	default:
+		return stateDone
+	}
+}
+
+// dismissStale dismisses our approvals of earlier commits if the PR is no longer
+// approvable. It records no step if there are none.
+func (p *Processor) dismissStale(ctx context.Context, result *analyzer.Result, out *Outcome) state {
+	switch {
+	case len(result.StaleApprovals) == 0:
+		return stateApprove
+	case result.Approvable:
+		out.skip(ActionDismissStale, "PR is still approvable")
+		return stateApprove
+	case p.opts.DryRun:
+		out.skip(ActionDismissStale, "dry run")
+		return stateApprove
+	}
+
+	message := fmt.Sprintf("New commits are not trivially approvable: %s", result.Reason)
+	for _, id := range result.StaleApprovals {
+		if err := p.gh.DismissReview(ctx, out.Owner, out.Repo, out.Number, id, message); err != nil {
+			out.fail(ActionDismissStale, err)
+			return stateDone
+		}
+	}
+	out.succeed(ActionDismissStale)
+	return stateApprove
+}
+
+func (p *Processor) approve(ctx context.Context, result *analyzer.Result, out *Outcome) state {
+	switch {
+	case result.Deferred:
+		out.skip(ActionApprove, "waiting for required checks")
+	case !result.Approvable:
+		out.skip(ActionApprove, "PR is not approvable")
+	case result.IsOwnPR:
+		out.skip(ActionApprove, "cannot approve own PR")
+	case result.AlreadyApprovedByUs:
+		out.skip(ActionApprove, "already approved by us")
+	case p.opts.DryRun:
+		out.skip(ActionApprove, "dry run")
+	default:
+		body := p.opts.ApprovalBody
+		if body == "" {
+			body = fmt.Sprintf("Auto-approved by trivial-auto-approve: %s", result.Reason)
+		}
+		if err := p.gh.ApprovePullRequest(ctx, out.Owner, out.Repo, out.Number, result.HeadSHA, body); err != nil {
+			out.fail(ActionApprove, err)
+			return stateDone
+		}
+		out.succeed(ActionApprove)
+	}
+	return stateEnableAutoMerge
+}
+
+func (p *Processor) enableAutoMerge(ctx context.Context, result *analyzer.Result, out *Outcome) state {
+	switch {
+	case !p.opts.AutoMerge:
+		out.skip(ActionEnableAutoMerge, "auto-merge not requested")
+		return stateUpdateBranch
+	case !result.Approvable:
+		out.skip(ActionEnableAutoMerge, "PR is not approvable")
+		return stateUpdateBranch
+	case p.opts.DryRun:
+		out.skip(ActionEnableAutoMerge, "dry run")
+		return stateUpdateBranch
+	}
+
+	err := p.gh.EnableAutoMerge(ctx, out.Owner, out.Repo, out.Number)
+	switch {
+	case errors.Is(err, appErrors.ErrPRReadyToMerge):
+		out.skip(ActionEnableAutoMerge, "PR is already mergeable")
+		return stateMerge
+	case err != nil:
+		out.fail(ActionEnableAutoMerge, err)
+		return stateDone
+	default:
+		out.succeed(ActionEnableAutoMerge)
+		return stateUpdateBranch
+	}
+}
+
+func (p *Processor) merge(ctx context.Context, out *Outcome) state {
+	if err := p.gh.MergePullRequest(ctx, out.Owner, out.Repo, out.Number); err != nil {
+		out.fail(ActionMerge, err)
+		return stateDone
+	}
+	out.succeed(ActionMerge)
+	// A merged PR has no branch left to update
+	return stateDone
+}
+
+func (p *Processor) updateBranch(ctx context.Context, result *analyzer.Result, out *Outcome) state {
+	switch {
+	case !p.opts.AutoRebase:
+		out.skip(ActionUpdateBranch, "auto-rebase not requested")
+		return stateDone
+	case !result.Approvable:
+		out.skip(ActionUpdateBranch, "PR is not approvable")
+		return stateDone
+	case p.opts.DryRun:
+		out.skip(ActionUpdateBranch, "dry run")
+		return stateDone
+	}
+
+	err := p.gh.UpdateBranch(ctx, out.Owner, out.Repo, out.Number)
+	switch {
+	case errors.Is(err, appErrors.ErrBranchUpToDate):
+		out.skip(ActionUpdateBranch, "branch already up to date")
+	case err != nil:
+		out.fail(ActionUpdateBranch, err)
+	default:
+		out.succeed(ActionUpdateBranch)
+	}
+	return stateDone
+}
+
+func (o *Outcome) skip(action Action, reason string) {
+	o.Steps = append(o.Steps, Step{Action: action, Status: StatusSkipped, Reason: reason})
+}
+
+func (o *Outcome) succeed(action Action) {
+	o.Steps = append(o.Steps, Step{Action: action, Status: StatusSucceeded})
+}
+
+func (o *Outcome) fail(action Action, err error) {
+	o.Steps = append(o.Steps, Step{Action: action, Status: StatusFailed, Reason: err.Error(), Err: err})
+}
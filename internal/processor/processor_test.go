@@ -0,0 +1,335 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+	appErrors "github.com/thegroove/trivial-auto-approve/internal/errors"
+)
+
+// recordingGitHubAPI implements the github.API interface and records write calls.
+type recordingGitHubAPI struct {
+	calls          []string
+	approvedCommit string
+	dismissErr     error
+	approveErr     error
+	autoMergeErr   error
+	mergeErr       error
+	updateErr      error
+}
+
+func (m *recordingGitHubAPI) AuthenticatedUser(ctx context.Context) (*github.User, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) PullRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListOrgPullRequests(ctx context.Context, org string) ([]*github.PullRequest, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListRepoPullRequests(ctx context.Context, owner, repo string) ([]*github.PullRequest, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) PullRequestFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) CompareCommits(ctx context.Context, owner, repo, base, head string) ([]*github.CommitFile, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) CombinedStatus(ctx context.Context, owner, repo, ref string) (*github.CombinedStatus, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListCheckRunsForRef(ctx context.Context, owner, repo, ref string) ([]*github.CheckRun, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListReviews(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]*github.IssueComment, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListPullRequestComments(ctx context.Context, owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ApprovePullRequest(ctx context.Context, owner, repo string, number int, commitID, body string) error {
+	m.calls = append(m.calls, "approve")
+	m.approvedCommit = commitID
+	return m.approveErr
+}
+
+func (m *recordingGitHubAPI) DismissReview(ctx context.Context, owner, repo string, number int, reviewID int64, message string) error {
+	m.calls = append(m.calls, fmt.Sprintf("dismiss %d", reviewID))
+	return m.dismissErr
+}
+
+func (m *recordingGitHubAPI) EnableAutoMerge(ctx context.Context, owner, repo string, number int) error {
+	m.calls = append(m.calls, "auto-merge")
+	return m.autoMergeErr
+}
+
+func (m *recordingGitHubAPI) MergePullRequest(ctx context.Context, owner, repo string, number int) error {
+	m.calls = append(m.calls, "merge")
+	return m.mergeErr
+}
+
+func (m *recordingGitHubAPI) GetUserPermissionLevel(ctx context.Context, owner, repo, username string) (string, error) {
+	return "read", nil
+}
+
+func (m *recordingGitHubAPI) UpdateBranch(ctx context.Context, owner, repo string, number int) error {
+	m.calls = append(m.calls, "update-branch")
+	return m.updateErr
+}
+
+func (m *recordingGitHubAPI) ListAppInstallations(ctx context.Context) ([]*github.Installation, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListUserRepositories(ctx context.Context, user string) ([]*github.Repository, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) ListUserPullRequests(ctx context.Context, user string) ([]*github.PullRequest, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) FileContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	return nil, appErrors.ErrFileNotFound
+}
+
+func (m *recordingGitHubAPI) CompareFile(ctx context.Context, owner, repo, basePath, headPath, baseRef, headRef string) ([]byte, []byte, error) {
+	return nil, nil, appErrors.ErrFileNotFound
+}
+
+func (m *recordingGitHubAPI) FileModes(ctx context.Context, owner, repo, ref string, paths []string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (m *recordingGitHubAPI) BranchProtection(ctx context.Context, owner, repo, branch string) (*github.Protection, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) BranchRules(ctx context.Context, owner, repo, branch string) ([]*github.RepositoryRule, error) {
+	return nil, nil
+}
+
+func (m *recordingGitHubAPI) IsTeamMember(ctx context.Context, org, team, user string) (bool, error) {
+	return false, nil
+}
+
+func TestProcess(t *testing.T) {
+	approvable := &analyzer.Result{Approvable: true, Reason: "All checks passed"}
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		opts      Options
+		result    *analyzer.Result
+		gh        *recordingGitHubAPI
+		wantCalls []string
+		wantSteps []Status
+		wantErr   bool
+	}{
+		{
+			name:      "approve only",
+			result:    approvable,
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"approve"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "not approvable does nothing",
+			opts:      Options{AutoMerge: true, AutoRebase: true},
+			result:    &analyzer.Result{Approvable: false, Reason: "CI checks not passing"},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: nil,
+			wantSteps: []Status{StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "deferred does nothing",
+			opts:      Options{AutoMerge: true, AutoRebase: true},
+			result:    &analyzer.Result{Approvable: false, Deferred: true, Reason: "Waiting for required checks"},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: nil,
+			wantSteps: []Status{StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "dry run does nothing",
+			opts:      Options{DryRun: true, AutoMerge: true, AutoRebase: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{},
+			wantCalls: nil,
+			wantSteps: []Status{StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "approve, auto-merge and rebase",
+			opts:      Options{AutoMerge: true, AutoRebase: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"approve", "auto-merge", "update-branch"},
+			wantSteps: []Status{StatusSucceeded, StatusSucceeded, StatusSucceeded},
+		},
+		{
+			name:      "falls back to merge when ready",
+			opts:      Options{AutoMerge: true, AutoRebase: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{autoMergeErr: appErrors.ErrPRReadyToMerge},
+			wantCalls: []string{"approve", "auto-merge", "merge"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusSucceeded},
+		},
+		{
+			name:      "own PR skips approval but still merges",
+			opts:      Options{AutoMerge: true},
+			result:    &analyzer.Result{Approvable: true, IsOwnPR: true},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"auto-merge"},
+			wantSteps: []Status{StatusSkipped, StatusSucceeded, StatusSkipped},
+		},
+		{
+			name:      "already approved skips approval",
+			result:    &analyzer.Result{Approvable: true, AlreadyApprovedByUs: true},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: nil,
+			wantSteps: []Status{StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "stale approval of an approvable PR is replaced",
+			result:    &analyzer.Result{Approvable: true, HeadSHA: "head", StaleApprovals: []int64{7}},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"approve"},
+			wantSteps: []Status{StatusSkipped, StatusSucceeded, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "stale approvals dismissed when not approvable",
+			opts:      Options{AutoMerge: true},
+			result:    &analyzer.Result{Approvable: false, Reason: "Too many lines changed", StaleApprovals: []int64{7, 9}},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"dismiss 7", "dismiss 9"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "stale approvals dismissed while deferred",
+			result:    &analyzer.Result{Approvable: false, Deferred: true, StaleApprovals: []int64{7}},
+			gh:        &recordingGitHubAPI{},
+			wantCalls: []string{"dismiss 7"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "dismissal failure stops processing",
+			result:    &analyzer.Result{Approvable: false, StaleApprovals: []int64{7}},
+			gh:        &recordingGitHubAPI{dismissErr: errBoom},
+			wantCalls: []string{"dismiss 7"},
+			wantSteps: []Status{StatusFailed},
+			wantErr:   true,
+		},
+		{
+			name:      "approval failure stops processing",
+			opts:      Options{AutoMerge: true, AutoRebase: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{approveErr: errBoom},
+			wantCalls: []string{"approve"},
+			wantSteps: []Status{StatusFailed},
+			wantErr:   true,
+		},
+		{
+			name:      "branch up to date is skipped",
+			opts:      Options{AutoRebase: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{updateErr: appErrors.ErrBranchUpToDate},
+			wantCalls: []string{"approve", "update-branch"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusSkipped},
+		},
+		{
+			name:      "merge failure is reported",
+			opts:      Options{AutoMerge: true},
+			result:    approvable,
+			gh:        &recordingGitHubAPI{autoMergeErr: appErrors.ErrPRReadyToMerge, mergeErr: errBoom},
+			wantCalls: []string{"approve", "auto-merge", "merge"},
+			wantSteps: []Status{StatusSucceeded, StatusSkipped, StatusFailed},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.gh, tt.opts)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			out, err := p.Process(context.Background(), "owner", "repo", 1, tt.result)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if out == nil {
+				t.Fatal("Process() returned nil outcome")
+			}
+
+			if !reflect.DeepEqual(tt.gh.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", tt.gh.calls, tt.wantCalls)
+			}
+			if tt.gh.approvedCommit != tt.result.HeadSHA {
+				t.Errorf("approved commit = %q, want %q", tt.gh.approvedCommit, tt.result.HeadSHA)
+			}
+
+			var gotSteps []Status
+			for _, s := range out.Steps {
+				gotSteps = append(gotSteps, s.Status)
+			}
+			if !reflect.DeepEqual(gotSteps, tt.wantSteps) {
+				t.Errorf("steps = %v, want %v (%s)", gotSteps, tt.wantSteps, out)
+			}
+		})
+	}
+}
+
+func TestOutcomeQueries(t *testing.T) {
+	p, err := New(&recordingGitHubAPI{autoMergeErr: appErrors.ErrPRReadyToMerge}, Options{AutoMerge: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	out, err := p.Process(context.Background(), "owner", "repo", 1, &analyzer.Result{Approvable: true})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if !out.Succeeded(ActionApprove) || !out.Succeeded(ActionMerge) {
+		t.Errorf("expected approve and merge to succeed: %s", out)
+	}
+	if !out.Skipped(ActionEnableAutoMerge) || out.Attempted(ActionEnableAutoMerge) {
+		t.Errorf("expected auto-merge to be skipped: %s", out)
+	}
+	if out.Attempted(ActionUpdateBranch) {
+		t.Errorf("update branch should not be attempted after merge: %s", out)
+	}
+}
+
+func TestProcessRequiresResult(t *testing.T) {
+	p, err := New(&recordingGitHubAPI{}, Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := p.Process(context.Background(), "owner", "repo", 1, nil); err == nil {
+		t.Error("expected error for nil result")
+	}
+	if _, err := New(nil, Options{}); err == nil {
+		t.Error("expected error for nil client")
+	}
+}
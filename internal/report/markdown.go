@@ -0,0 +1,103 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/thegroove/trivial-auto-approve/internal/processor"
+)
+
+// writeMarkdown writes records as a digest suitable for pasting into an issue.
+func writeMarkdown(w io.Writer, records []Record) error {
+	var b strings.Builder
+
+	approvable, errored := 0, 0
+	for i := range records {
+		switch {
+		case records[i].Error != "":
+			errored++
+		case records[i].Approvable:
+			approvable++
+		}
+	}
+
+	b.WriteString("## Auto-approve report\n\n")
+	fmt.Fprintf(&b, "%d PRs analyzed: %d approvable, %d not approvable, %d errors.\n",
+		len(records), approvable, len(records)-approvable-errored, errored)
+
+	if len(records) > 0 {
+		b.WriteString("\n| PR | Result | Reason | Category | Actions |\n")
+		b.WriteString("|----|--------|--------|----------|---------|\n")
+		for i := range records {
+			rec := &records[i]
+			fmt.Fprintf(&b, "| [%s](https://github.com/%s/%s/pull/%d) | %s | %s | %s | %s |\n",
+				escapeMarkdown(rec.Name()), rec.Owner, rec.Repo, rec.Number,
+				markdownResult(rec), escapeMarkdown(markdownReason(rec)),
+				escapeMarkdown(markdownCategory(rec)), escapeMarkdown(markdownActions(rec)))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownResult returns a short result label for a record.
+func markdownResult(rec *Record) string {
+	switch {
+	case rec.Error != "":
+		return "error"
+	case rec.Approvable:
+		return "approvable"
+	case rec.Deferred:
+		return "deferred"
+	case rec.NeedsHuman:
+		return "needs human"
+	default:
+		return "not approvable"
+	}
+}
+
+// markdownReason returns the error or reason, with failing check IDs appended.
+func markdownReason(rec *Record) string {
+	if rec.Error != "" {
+		return rec.Error
+	}
+	reason := rec.Reason
+	if len(rec.FailedChecks) > 0 {
+		ids := make([]string, 0, len(rec.FailedChecks))
+		for _, check := range rec.FailedChecks {
+			ids = append(ids, "`"+check.ID+"`")
+		}
+		reason += " (" + strings.Join(ids, ", ") + ")"
+	}
+	return reason
+}
+
+// markdownCategory returns the AI category, noting a verdict reused from the cache.
+func markdownCategory(rec *Record) string {
+	if rec.Category != "" && len(rec.CachedVerdicts) > 0 {
+		return rec.Category + " (cached)"
+	}
+	return rec.Category
+}
+
+// markdownActions lists the actions that were not skipped.
+func markdownActions(rec *Record) string {
+	var actions []string
+	for _, a := range rec.Actions {
+		if a.Status != processor.StatusSkipped {
+			actions = append(actions, fmt.Sprintf("%s %s", a.Action, a.Status))
+		}
+	}
+	if len(actions) == 0 {
+		return "none"
+	}
+	return strings.Join(actions, ", ")
+}
+
+// escapeMarkdown makes s safe for a single table cell.
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,267 @@
+// Package report writes per-PR analysis records in machine- and human-readable formats.
+// Streaming formats (text, ndjson) are written as records are added; document
+// formats (json, markdown, sarif) are buffered and written on Flush.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+	"github.com/thegroove/trivial-auto-approve/internal/processor"
+)
+
+// Format is an output format.
+type Format string
+
+// Supported formats.
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatMarkdown Format = "markdown"
+	FormatSARIF    Format = "sarif"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatText, FormatJSON, FormatNDJSON, FormatMarkdown, FormatSARIF}
+
+// ParseFormat returns the format named s. An empty string selects text.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return FormatText, nil
+	}
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (want text, json, ndjson, markdown or sarif)", s)
+}
+
+// Streaming reports whether the format writes each record as it is added.
+func (f Format) Streaming() bool {
+	return f == FormatText || f == FormatNDJSON
+}
+
+// Action records one step the processor took or skipped.
+type Action struct {
+	Action processor.Action `json:"action"`
+	Status processor.Status `json:"status"`
+	Reason string           `json:"reason,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// Record is the report for a single PR.
+type Record struct {
+	Owner  string    `json:"owner"`
+	Repo   string    `json:"repo"`
+	Number int       `json:"number"`
+	Time   time.Time `json:"time"`
+
+	Approvable          bool     `json:"approvable"`
+	Deferred            bool     `json:"deferred,omitempty"` // Waiting for required checks
+	HeadSHA             string   `json:"head_sha,omitempty"` // The commit analyzed
+	Reason              string   `json:"reason,omitempty"`
+	Details             []string `json:"details,omitempty"`
+	AlreadyApprovedByUs bool     `json:"already_approved_by_us,omitempty"`
+	IsOwnPR             bool     `json:"is_own_pr,omitempty"`
+
+	// Category and Flags come from the AI analysis, if it ran.
+	Category string   `json:"category,omitempty"`
+	Flags    []string `json:"flags,omitempty"`
+
+	// ProvedNoOp lists files whose changes were proved not to alter behavior.
+	ProvedNoOp []string `json:"proved_noop,omitempty"`
+
+	// Disagreements lists where multi-model consensus models disagreed.
+	Disagreements []string `json:"disagreements,omitempty"`
+
+	// NeedsHuman is set when a model response was anomalous; Anomalies says how.
+	NeedsHuman bool     `json:"needs_human,omitempty"`
+	Anomalies  []string `json:"anomalies,omitempty"`
+
+	// CachedVerdicts lists the checks whose model verdict was reused from the verdict cache.
+	CachedVerdicts []string `json:"cached_verdicts,omitempty"`
+
+	FailedChecks []analyzer.Check `json:"failed_checks,omitempty"`
+	Actions      []Action         `json:"actions,omitempty"`
+
+	// Error is set when the PR could not be analyzed or processed.
+	Error string `json:"error,omitempty"`
+}
+
+// NewRecord builds a record from an analysis result and processor outcome.
+// Either may be nil if the corresponding stage did not run.
+func NewRecord(owner, repo string, number int, result *analyzer.Result, outcome *processor.Outcome, err error) Record {
+	rec := Record{
+		Owner:  owner,
+		Repo:   repo,
+		Number: number,
+		Time:   time.Now().UTC(),
+	}
+
+	if result != nil {
+		rec.Approvable = result.Approvable
+		rec.Deferred = result.Deferred
+		rec.HeadSHA = result.HeadSHA
+		rec.Reason = result.Reason
+		rec.Details = result.Details
+		rec.AlreadyApprovedByUs = result.AlreadyApprovedByUs
+		rec.IsOwnPR = result.IsOwnPR
+		rec.ProvedNoOp = result.ProvedNoOp
+		rec.Disagreements = result.Disagreements
+		rec.NeedsHuman = result.NeedsHuman
+		rec.Anomalies = result.Anomalies
+		rec.CachedVerdicts = result.CachedVerdicts
+
+		for _, check := range result.Checks {
+			if check.ID == analyzer.CheckAI && check.Status == analyzer.CheckPass {
+				rec.Category, _ = check.Value.(string)
+			}
+			if check.Failed() {
+				rec.FailedChecks = append(rec.FailedChecks, check)
+				if strings.HasPrefix(check.ID, "ai.") && check.ID != analyzer.CheckAI && check.ID != analyzer.CheckAICategory {
+					rec.Flags = append(rec.Flags, strings.TrimPrefix(check.ID, "ai."))
+				}
+			}
+		}
+	}
+
+	if outcome != nil {
+		for _, step := range outcome.Steps {
+			action := Action{Action: step.Action, Status: step.Status, Reason: step.Reason}
+			if step.Err != nil {
+				action.Error = step.Err.Error()
+			}
+			rec.Actions = append(rec.Actions, action)
+		}
+	}
+
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	return rec
+}
+
+// Name returns the PR's owner/repo#number.
+func (r *Record) Name() string {
+	return fmt.Sprintf("%s/%s#%d", r.Owner, r.Repo, r.Number)
+}
+
+// Findings returns the code validation findings of the record's failed checks.
+func (r *Record) Findings() []analyzer.Finding {
+	var findings []analyzer.Finding
+	for _, check := range r.FailedChecks {
+		findings = append(findings, check.Findings...)
+	}
+	return findings
+}
+
+// Reporter writes records in a single format. It is safe for concurrent use.
+type Reporter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	format  Format
+	records []Record
+}
+
+// New creates a reporter writing to w.
+func New(w io.Writer, format Format) *Reporter {
+	return &Reporter{w: w, format: format}
+}
+
+// Format returns the reporter's format.
+func (r *Reporter) Format() Format {
+	return r.format
+}
+
+// Add writes the record for streaming formats or buffers it until Flush.
+func (r *Reporter) Add(rec Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.format {
+	case FormatText:
+		return writeText(r.w, &rec)
+	case FormatNDJSON:
+		return json.NewEncoder(r.w).Encode(rec)
+	default:
+		r.records = append(r.records, rec)
+		return nil
+	}
+}
+
+// Flush writes buffered records as a single document and clears the buffer.
+// It does nothing for streaming formats.
+func (r *Reporter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := r.records
+	r.records = nil
+
+	switch r.format {
+	case FormatJSON:
+		if records == nil {
+			records = []Record{}
+		}
+		enc := json.NewEncoder(r.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case FormatMarkdown:
+		return writeMarkdown(r.w, records)
+	case FormatSARIF:
+		return writeSARIF(r.w, records)
+	default:
+		return nil
+	}
+}
+
+// writeText writes a record as a short human-readable block.
+func writeText(w io.Writer, rec *Record) error {
+	var b strings.Builder
+
+	switch {
+	case rec.Error != "":
+		fmt.Fprintf(&b, "%s: error: %s\n", rec.Name(), rec.Error)
+	case rec.Approvable:
+		fmt.Fprintf(&b, "%s: approvable: %s\n", rec.Name(), rec.Reason)
+	case rec.Deferred:
+		fmt.Fprintf(&b, "%s: deferred: %s\n", rec.Name(), rec.Reason)
+	case rec.NeedsHuman:
+		fmt.Fprintf(&b, "%s: needs human: %s\n", rec.Name(), rec.Reason)
+	default:
+		fmt.Fprintf(&b, "%s: not approvable: %s\n", rec.Name(), rec.Reason)
+	}
+	for _, d := range rec.Details {
+		fmt.Fprintf(&b, "  - %s\n", d)
+	}
+	for _, a := range rec.Anomalies {
+		fmt.Fprintf(&b, "  ! anomaly: %s\n", a)
+	}
+	for _, d := range rec.Disagreements {
+		fmt.Fprintf(&b, "  ! %s\n", d)
+	}
+	if len(rec.CachedVerdicts) > 0 {
+		fmt.Fprintf(&b, "  cached verdicts: %s\n", strings.Join(rec.CachedVerdicts, ", "))
+	}
+	for _, a := range rec.Actions {
+		fmt.Fprintf(&b, "  %s: %s", a.Action, a.Status)
+		if a.Reason != "" {
+			fmt.Fprintf(&b, " (%s)", a.Reason)
+		}
+		if a.Error != "" {
+			fmt.Fprintf(&b, ": %s", a.Error)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+	"github.com/thegroove/trivial-auto-approve/internal/processor"
+)
+
+// rejected is a record for a PR rejected by code validation with one finding.
+func rejected() Record {
+	result := &analyzer.Result{
+		Reason: "Code changes contain security risks",
+		Checks: []analyzer.Check{
+			{ID: analyzer.CheckPRState, Status: analyzer.CheckPass},
+			{
+				ID:      analyzer.CheckCodeValidation,
+				Status:  analyzer.CheckFail,
+				Message: "Code changes contain security risks",
+				Findings: []analyzer.Finding{
+					{File: "main.go", Line: 14, PatchLine: 5, Message: "command substitution pattern detected"},
+				},
+			},
+		},
+	}
+	outcome := &processor.Outcome{Steps: []processor.Step{
+		{Action: processor.ActionApprove, Status: processor.StatusSkipped, Reason: "not approvable"},
+	}}
+	return NewRecord("owner", "repo", 7, result, outcome, nil)
+}
+
+// approved is a record for an approved PR with AI analysis.
+func approved() Record {
+	result := &analyzer.Result{
+		Approvable: true,
+		Reason:     "All checks passed",
+		Checks: []analyzer.Check{
+			{ID: analyzer.CheckAI, Status: analyzer.CheckPass, Value: "documentation"},
+			{ID: analyzer.CheckAIRisky, Status: analyzer.CheckPass, Value: false},
+		},
+	}
+	outcome := &processor.Outcome{Steps: []processor.Step{
+		{Action: processor.ActionApprove, Status: processor.StatusSucceeded},
+	}}
+	return NewRecord("owner", "repo", 8, result, outcome, nil)
+}
+
+func TestNewRecord(t *testing.T) {
+	result := &analyzer.Result{
+		Reason: "Changes are high risk",
+		Checks: []analyzer.Check{
+			{ID: analyzer.CheckAI, Status: analyzer.CheckPass, Value: "refactor"},
+			{ID: analyzer.CheckAIRisky, Status: analyzer.CheckFail, Message: "Changes are high risk"},
+			{ID: analyzer.CheckAIAltersBehavior, Status: analyzer.CheckFail, Message: "Changes alter application behavior"},
+			{ID: analyzer.CheckAICategory, Status: analyzer.CheckPass, Value: "refactor"},
+		},
+	}
+	outcome := &processor.Outcome{Steps: []processor.Step{
+		{Action: processor.ActionApprove, Status: processor.StatusFailed, Err: errors.New("forbidden")},
+	}}
+
+	rec := NewRecord("owner", "repo", 1, result, outcome, errors.New("processing PR: forbidden"))
+
+	if rec.Category != "refactor" {
+		t.Errorf("Category = %q, want refactor", rec.Category)
+	}
+	if strings.Join(rec.Flags, ",") != "risky,alters_behavior" {
+		t.Errorf("Flags = %v, want [risky alters_behavior]", rec.Flags)
+	}
+	if len(rec.FailedChecks) != 2 {
+		t.Errorf("FailedChecks = %+v, want 2", rec.FailedChecks)
+	}
+	if len(rec.Actions) != 1 || rec.Actions[0].Error != "forbidden" {
+		t.Errorf("Actions = %+v", rec.Actions)
+	}
+	if rec.Error != "processing PR: forbidden" {
+		t.Errorf("Error = %q", rec.Error)
+	}
+}
+
+func TestReporterStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, FormatNDJSON)
+	for _, rec := range []Record{rejected(), approved()} {
+		if err := r.Add(rec); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if rec.Number != 8 || !rec.Approvable || rec.Category != "documentation" {
+		t.Errorf("decoded record = %+v", rec)
+	}
+
+	buf.Reset()
+	if err := r.Flush(); err != nil || buf.Len() != 0 {
+		t.Errorf("Flush() wrote %q, err = %v; want nothing", buf.String(), err)
+	}
+}
+
+func TestReporterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, FormatJSON)
+
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty report = %q, want []", buf.String())
+	}
+
+	buf.Reset()
+	_ = r.Add(rejected())
+	if buf.Len() != 0 {
+		t.Error("JSON records should be buffered until Flush")
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var records []Record
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(records) != 1 || records[0].FailedChecks[0].ID != analyzer.CheckCodeValidation {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestReporterMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, FormatMarkdown)
+	_ = r.Add(rejected())
+	_ = r.Add(approved())
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"2 PRs analyzed: 1 approvable, 1 not approvable, 0 errors.",
+		"| [owner/repo#7](https://github.com/owner/repo/pull/7) | not approvable | Code changes contain security risks (`code.validation`) |  | none |",
+		"| [owner/repo#8](https://github.com/owner/repo/pull/8) | approvable | All checks passed | documentation | approve succeeded |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReporterSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, FormatSARIF)
+	_ = r.Add(rejected())
+	_ = r.Add(approved())
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF log = %+v", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	loc := results[0].Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "main.go" || loc.Region == nil || loc.Region.StartLine != 14 {
+		t.Errorf("location = %+v", loc)
+	}
+	if results[0].RuleID != analyzer.CheckCodeValidation {
+		t.Errorf("RuleID = %q", results[0].RuleID)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		if got, err := ParseFormat(string(f)); err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %q, %v", f, got, err)
+		}
+	}
+	if got, err := ParseFormat(""); err != nil || got != FormatText {
+		t.Errorf("ParseFormat(\"\") = %q, %v; want text", got, err)
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat(\"yaml\") should fail")
+	}
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/thegroove/trivial-auto-approve/internal/analyzer"
+)
+
+// SARIF constants for the code scanning upload.
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "auto-approve"
+	sarifToolURI  = "https://github.com/thegroove/trivial-auto-approve"
+)
+
+// sarifLog is the subset of SARIF 2.1.0 accepted by GitHub code scanning.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// writeSARIF writes the code validation findings of all records as a SARIF log.
+// File paths are relative to each PR's repository, so uploads should cover a single repository.
+func writeSARIF(w io.Writer, records []Record) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			InformationURI: sarifToolURI,
+			Rules: []sarifRule{{
+				ID:               analyzer.CheckCodeValidation,
+				ShortDescription: sarifMessage{Text: "Patch line failed code validation"},
+			}},
+		}},
+		Results: []sarifResult{},
+	}
+
+	for i := range records {
+		rec := &records[i]
+		for _, f := range rec.Findings() {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File},
+			}}
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    analyzer.CheckCodeValidation,
+				Level:     "error",
+				Message:   sarifMessage{Text: fmt.Sprintf("%s: %s", rec.Name(), f.Message)},
+				Locations: []sarifLocation{location},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -20,7 +21,6 @@ const (
 	MaxTotalPromptSize   = 100000
 
 	// Suspicious pattern thresholds
-	MaxUnicodeComplexity = 0.1  // Max 10% non-ASCII characters
 	MaxRepetitionRatio   = 0.3  // Max 30% repeated content
 	MaxControlChars      = 10   // Max control characters allowed
 )
@@ -60,6 +60,9 @@ func (d *AIDefense) SanitizePRTitle(title string) SanitizationResult {
 		title = title[:MaxTitleLength]
 	}
 
+	// Never forward credentials to the model
+	title = d.redactSecrets(title, &result)
+
 	// Detect prompt injection attempts
 	if threats := d.detectPromptInjection(title); len(threats) > 0 {
 		result.ThreatDetected = true
@@ -100,6 +103,9 @@ func (d *AIDefense) SanitizePRDescription(description string) SanitizationResult
 		description = description[:MaxDescriptionLength]
 	}
 
+	// Never forward credentials to the model
+	description = d.redactSecrets(description, &result)
+
 	// Detect prompt injection attempts
 	if threats := d.detectPromptInjection(description); len(threats) > 0 {
 		result.ThreatDetected = true
@@ -134,6 +140,9 @@ func (d *AIDefense) SanitizePatch(patch string, filename string) SanitizationRes
 		patch = patch[:MaxPatchSize] + "\n... [truncated for security]"
 	}
 
+	// Never forward credentials to the model
+	patch = d.redactSecrets(patch, &result)
+
 	// Detect embedded prompt instructions in code comments
 	if threats := d.detectCodeCommentInjection(patch); len(threats) > 0 {
 		result.ThreatDetected = true
@@ -155,6 +164,17 @@ func (d *AIDefense) SanitizePatch(patch string, filename string) SanitizationRes
 	return result
 }
 
+// redactSecrets redacts credentials in text and records them as a threat
+func (d *AIDefense) redactSecrets(text string, result *SanitizationResult) string {
+	redacted, n := RedactSecrets(text)
+	if n > 0 {
+		result.ThreatDetected = true
+		result.ThreatType = "secret"
+		result.ThreatDetails = append(result.ThreatDetails, fmt.Sprintf("%d possible secrets redacted", n))
+	}
+	return redacted
+}
+
 // detectPromptInjection detects common prompt injection patterns
 func (d *AIDefense) detectPromptInjection(text string) []string {
 	var threats []string
@@ -172,7 +192,7 @@ func (d *AIDefense) detectPromptInjection(text string) []string {
 		{regexp.MustCompile(`(?i)###\s*(system|instruction|important)`), "Markdown instruction injection"},
 		{regexp.MustCompile(`(?i)approved:\s*true`), "Direct approval injection"},
 		{regexp.MustCompile(`(?i)(always|must|should)\s+(approve|accept|merge)`), "Forced approval attempt"},
-		{regexp.MustCompile(`\x00|\x1b\[|\u202e|\ufeff`), "Control character injection"},
+		{regexp.MustCompile(`\x00|\x1b\[|\x{202e}|\x{feff}`), "Control character injection"},
 		{regexp.MustCompile(`(?i)json.*approved.*true`), "JSON injection attempt"},
 	}
 
@@ -213,34 +233,21 @@ func (d *AIDefense) detectCodeCommentInjection(code string) []string {
 	return threats
 }
 
-// hasSuspiciousUnicode checks for Unicode-based attacks
+// hasSuspiciousUnicode checks for Unicode-based attacks. Non-ASCII text on its own
+// is fine, since titles may be written in any language.
 func (d *AIDefense) hasSuspiciousUnicode(text string) bool {
 	if !utf8.ValidString(text) {
 		return true
 	}
 
-	nonASCII := 0
-	total := 0
 	for _, r := range text {
-		total++
-		if r > 127 {
-			nonASCII++
-		}
-		// Check for specific dangerous Unicode characters
-		if r == '\u202e' || // Right-to-left override
-			r == '\ufeff' || // Zero-width no-break space
-			r == '\u200b' || // Zero-width space
-			r == '\u2060' || // Word joiner
+		if unicode.Is(bidiControls, r) ||
+			(unicode.Is(invisibleChars, r) && !unicode.Is(proseInvisibleChars, r)) ||
 			(r >= '\ue000' && r <= '\uf8ff') { // Private use area
 			return true
 		}
 	}
 
-	// Check ratio of non-ASCII characters
-	if total > 0 && float64(nonASCII)/float64(total) > MaxUnicodeComplexity {
-		return true
-	}
-
 	return false
 }
 
@@ -0,0 +1,108 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AnomalyHistory keeps an AnomalyDetector per key (typically repository and model)
+// and persists their history to a JSON file, so drift is detected across runs.
+type AnomalyHistory struct {
+	mu         sync.Mutex
+	path       string
+	maxHistory int
+	detectors  map[string]*AnomalyDetector
+}
+
+// LoadAnomalyHistory loads the history stored at path. A missing file starts empty.
+func LoadAnomalyHistory(path string, maxHistory int) (*AnomalyHistory, error) {
+	h := &AnomalyHistory{
+		path:       path,
+		maxHistory: maxHistory,
+		detectors:  make(map[string]*AnomalyDetector),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading anomaly history: %w", err)
+	}
+
+	var stored map[string][]ModelAnalysis
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("parsing anomaly history %s: %w", path, err)
+	}
+	for key, history := range stored {
+		detector := NewAnomalyDetector(maxHistory)
+		for _, analysis := range history {
+			detector.addToHistory(analysis)
+		}
+		h.detectors[key] = detector
+	}
+
+	return h, nil
+}
+
+// Detect checks a response against the history for key and records it.
+// The history is saved before returning; a save failure is logged, not returned,
+// since the detection result is still valid.
+func (h *AnomalyHistory) Detect(key string, analysis ModelAnalysis) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Raw responses may contain code from the PR and aren't needed for detection
+	analysis.RawResponse = ""
+
+	detector, ok := h.detectors[key]
+	if !ok {
+		detector = NewAnomalyDetector(h.maxHistory)
+		h.detectors[key] = detector
+	}
+
+	anomalies := detector.Detect(analysis)
+	if len(anomalies) > 0 {
+		log.Printf("[ANOMALY] Detected anomalies for %s: %v", key, anomalies)
+	}
+
+	if err := h.save(); err != nil {
+		log.Printf("[ANOMALY] Warning: failed to save anomaly history: %v", err)
+	}
+	return anomalies
+}
+
+// save writes the history atomically, so a crash never leaves a truncated file.
+func (h *AnomalyHistory) save() error {
+	stored := make(map[string][]ModelAnalysis, len(h.detectors))
+	for key, detector := range h.detectors {
+		stored[key] = detector.responseHistory
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".anomalies-*.json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
@@ -0,0 +1,60 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnomalyHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "anomalies.json")
+
+	h, err := LoadAnomalyHistory(path, 100)
+	if err != nil {
+		t.Fatalf("LoadAnomalyHistory() on missing file error = %v", err)
+	}
+
+	rejection := ModelAnalysis{Provider: ModelOllama, Model: "llama3", AltersBehavior: true, Category: "refactor", Confidence: 0.9, RawResponse: "{...}"}
+	for i := 0; i < 10; i++ {
+		if anomalies := h.Detect("owner/repo ollama/llama3", rejection); len(anomalies) != 0 {
+			t.Fatalf("Detect() with short history = %v, want none", anomalies)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("history not saved: %v", err)
+	}
+	if strings.Contains(string(data), "{...}") {
+		t.Error("raw responses should not be persisted")
+	}
+
+	// A new run sees the saved history
+	h, err = LoadAnomalyHistory(path, 100)
+	if err != nil {
+		t.Fatalf("LoadAnomalyHistory() error = %v", err)
+	}
+
+	approval := rejection
+	approval.AltersBehavior = false
+	anomalies := h.Detect("owner/repo ollama/llama3", approval)
+	if len(anomalies) != 1 || anomalies[0] != "Unusual approval" {
+		t.Errorf("Detect() = %v, want [Unusual approval]", anomalies)
+	}
+
+	// History is kept per key
+	if anomalies := h.Detect("other/repo ollama/llama3", approval); len(anomalies) != 0 {
+		t.Errorf("Detect() for another repository = %v, want none", anomalies)
+	}
+}
+
+func TestLoadAnomalyHistoryCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anomalies.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAnomalyHistory(path, 100); err == nil {
+		t.Error("LoadAnomalyHistory() should fail on a corrupt file")
+	}
+}
@@ -2,16 +2,21 @@
 package security
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/thegroove/trivial-auto-approve/internal/deps"
 )
 
 // CodeValidator validates code changes for security risks
 type CodeValidator struct {
 	strictMode bool
+	rules      *PathRules
 }
 
 // NewCodeValidator creates a new code validator
@@ -21,6 +26,19 @@ func NewCodeValidator(strictMode bool) *CodeValidator {
 	}
 }
 
+// NewCodeValidatorWithRules creates a new code validator that applies path rules
+func NewCodeValidatorWithRules(strictMode bool, rules *PathRules) *CodeValidator {
+	return &CodeValidator{
+		strictMode: strictMode,
+		rules:      rules,
+	}
+}
+
+// FileTypeConfig returns the validation rules for a file, including path rule overrides
+func (v *CodeValidator) FileTypeConfig(filename string) FileTypeConfig {
+	return v.rules.FileTypeConfig(filename)
+}
+
 // ShellControlCharacters that pose security risks
 var ShellControlCharacters = map[rune]string{
 	'\'': "single quote (command injection risk)",
@@ -84,6 +102,7 @@ type FileTypeConfig struct {
 	AllowApostrophes    bool
 	MaxLineLength       int
 	ForbiddenCharacters map[rune]bool
+	AllowedScripts      []string // Unicode scripts allowed besides Latin, e.g. Han for localized docs
 }
 
 // GetFileTypeConfig returns configuration for a file type
@@ -335,13 +354,20 @@ func (v *CodeValidator) ValidatePatchLine(line string, filename string, isAdditi
 		return nil
 	}
 	
-	config := GetFileTypeConfig(filename)
+	config := v.FileTypeConfig(filename)
 	
 	// Check line length for additions
 	if isAddition && len(line) > config.MaxLineLength {
 		return fmt.Errorf("line exceeds maximum length %d characters", config.MaxLineLength)
 	}
 	
+	// Check for Trojan Source and homoglyph attacks in additions
+	if isAddition {
+		if err := CheckUnicode(line, config); err != nil {
+			return err
+		}
+	}
+	
 	// For GitHub Actions, check for dangerous patterns first (before character checks)
 	if strings.Contains(filename, ".github/workflows") {
 		if strings.Contains(line, "${{ github.event") || 
@@ -453,11 +479,54 @@ func (v *CodeValidator) checkCommandInjection(line string) error {
 	return nil
 }
 
-// ValidatePatch validates an entire patch for security issues
+// ErrBehaviorChange is returned by ValidatePatch when a code or config file has non-comment changes.
+// Unlike other validation errors, it does not indicate a dangerous pattern.
+var ErrBehaviorChange = errors.New("could alter program behavior")
+
+// PatchError is returned by ValidatePatch when a line of the patch fails validation.
+type PatchError struct {
+	Filename  string
+	PatchLine int // 1-based line within the patch
+	Line      int // 1-based line in the new file, or 0 if the hunk header was missing
+	Err       error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.PatchLine, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// hunkHeader matches a unified diff hunk header and captures the new file's start line.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ValidatePatch validates an entire patch for security issues.
+// Line failures are returned as a *PatchError.
 func (v *CodeValidator) ValidatePatch(patch string, filename string) error {
+	// Credentials block approval in every file type, including dependency files
+	if findings := ScanSecrets(patch); len(findings) > 0 {
+		f := findings[0]
+		log.Printf("[CODE VALIDATOR] Line %d in %s failed validation: %v", f.PatchLine, filename, f)
+		return &PatchError{Filename: filename, PatchLine: f.PatchLine, Line: f.Line, Err: f}
+	}
+
+	// Dependency version bumps are validated line by line by the deps parser
+	if _, ok := deps.Parse(filename, patch); ok {
+		log.Printf("[CODE VALIDATOR] Dependency update in %s doesn't alter behavior", filename)
+		return nil
+	}
+
 	lines := strings.Split(patch, "\n")
+	newLine := 0 // Line number in the new file of the next added or context line
 	
 	for i, line := range lines {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			newLine, _ = strconv.Atoi(m[1])
+			continue
+		}
+
 		// Skip empty lines and diff headers
 		if line == "" || strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
 			continue
@@ -475,7 +544,11 @@ func (v *CodeValidator) ValidatePatch(patch string, filename string) error {
 		// Validate the line
 		if err := v.ValidatePatchLine(content, filename, isAddition, isRemoval); err != nil {
 			log.Printf("[CODE VALIDATOR] Line %d in %s failed validation: %v", i+1, filename, err)
-			return fmt.Errorf("line %d: %w", i+1, err)
+			return &PatchError{Filename: filename, PatchLine: i + 1, Line: newLine, Err: err}
+		}
+
+		if !isRemoval && newLine > 0 {
+			newLine++
 		}
 	}
 	
@@ -489,50 +562,14 @@ func (v *CodeValidator) ValidatePatch(patch string, filename string) error {
 
 // checkBehaviorChange checks if patch might alter program behavior
 func (v *CodeValidator) checkBehaviorChange(patch string, filename string) error {
-	config := GetFileTypeConfig(filename)
-	
-	// Special case: go.mod and go.sum dependency updates don't alter behavior
-	base := strings.ToLower(filepath.Base(filename))
-	if base == "go.mod" || base == "go.sum" {
-		if isDependencyUpdate(patch) {
-			log.Printf("[CODE VALIDATOR] Dependency update in %s doesn't alter behavior", filename)
-			return nil
-		}
-	}
+	config := v.FileTypeConfig(filename)
 	
 	// Any change to code or config files could alter behavior
 	if config.IsCode || config.IsConfig {
-		// Count actual changes (not just whitespace or comments)
-		hasNonCommentChanges := false
-		
-		lines := strings.Split(patch, "\n")
-		for _, line := range lines {
-			// Skip diff headers
-			if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "+++") || 
-			   strings.HasPrefix(line, "---") {
-				continue
-			}
-			
-			if strings.HasPrefix(line, "+") {
-				content := strings.TrimSpace(line[1:])
-				
-				// Skip empty lines
-				if content == "" {
-					continue
-				}
-				
-				// Check if it's a comment
-				if !isSafeCommentLine(content) {
-					hasNonCommentChanges = true
-					break
-				}
-			}
-		}
-		
-		// Any non-comment change to code/config could alter behavior
-		if hasNonCommentChanges {
-			return fmt.Errorf("changes to %s file could alter program behavior", 
-				map[bool]string{true: "code", false: "config"}[config.IsCode])
+		// Any change other than comments and whitespace, by token kind, could alter behavior
+		if addsCode(patch, filename) && !WhitespaceOnly(patch, filename) {
+			return fmt.Errorf("changes to %s file %w", 
+				map[bool]string{true: "code", false: "config"}[config.IsCode], ErrBehaviorChange)
 		}
 	}
 	
@@ -547,107 +584,23 @@ func (v *CodeValidator) IsSafeChange(patch string, filename string) bool {
 		return false
 	}
 	
-	config := GetFileTypeConfig(filename)
+	config := v.FileTypeConfig(filename)
 	
 	// Markdown changes are generally safe if they pass validation
 	if config.IsMarkdown {
 		return true
 	}
 	
-	// Special case: go.mod and go.sum dependency updates are safe
-	base := strings.ToLower(filepath.Base(filename))
-	if base == "go.mod" || base == "go.sum" {
-		if isDependencyUpdate(patch) {
-			log.Printf("[CODE VALIDATOR] Dependency update in %s is safe", filename)
-			return true
-		}
-	}
-	
-	// For code and config files, only allow very specific safe changes
-	lines := strings.Split(patch, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "+") {
-			content := strings.TrimSpace(line[1:])
-			
-			// Allow only comments and whitespace changes
-			if content == "" {
-				continue
-			}
-			
-			// Check if it's a comment line
-			if !isSafeCommentLine(content) {
-				log.Printf("[CODE VALIDATOR] Non-comment change in %s is not safe", filename)
-				return false
-			}
-		}
-	}
-	
-	return true
-}
-
-// isSafeCommentLine checks if a line is a safe comment
-func isSafeCommentLine(line string) bool {
-	trimmed := strings.TrimSpace(line)
-	
-	// Common comment patterns
-	commentPrefixes := []string{
-		"//", "#", "/*", "*", "*/", "<!--", "-->",
-		"\"\"\"", "'''", "rem", "REM", "::",
-	}
-	
-	for _, prefix := range commentPrefixes {
-		if strings.HasPrefix(trimmed, prefix) {
-			return true
-		}
+	// Dependency version bumps are safe
+	if _, ok := deps.Parse(filename, patch); ok {
+		log.Printf("[CODE VALIDATOR] Dependency update in %s is safe", filename)
+		return true
 	}
 	
-	return false
-}
-
-// isDependencyUpdate checks if a patch is a dependency version update
-func isDependencyUpdate(patch string) bool {
-	lines := strings.Split(patch, "\n")
-	
-	// Pattern for dependency updates:
-	// - Only changes version numbers
-	// - In expected dependency format
-	versionPattern := regexp.MustCompile(`v?\d+\.\d+\.\d+(-[a-zA-Z0-9.-]+)?`)
-	
-	for _, line := range lines {
-		// Skip headers and context lines
-		if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "+++") || 
-		   strings.HasPrefix(line, "---") || (!strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-")) {
-			continue
-		}
-		
-		// Get the actual content
-		if len(line) < 2 {
-			continue
-		}
-		content := line[1:]
-		
-		// Skip empty lines
-		if strings.TrimSpace(content) == "" {
-			continue
-		}
-		
-		// Check if it looks like a dependency line
-		// go.mod: module/package v1.2.3
-		// go.sum: module/package v1.2.3 h1:hash
-		// package.json: "package": "1.2.3",
-		// requirements.txt: package==1.2.3
-		
-		// For go.mod/go.sum specifically
-		if strings.Contains(content, " v") || strings.Contains(content, "/go.mod") {
-			// Check if only version changed
-			if !versionPattern.MatchString(content) && !strings.Contains(content, "h1:") {
-				// Has non-version changes
-				return false
-			}
-		} else {
-			// Unknown format, be conservative
-			return false
-		}
+	// For code and config files, allow only comments and whitespace changes
+	if addsCode(patch, filename) && !WhitespaceOnly(patch, filename) {
+		log.Printf("[CODE VALIDATOR] Non-comment change in %s is not safe", filename)
+		return false
 	}
 	
 	return true
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -210,6 +211,20 @@ func TestValidatePatch(t *testing.T) {
 	}
 }
 
+func TestValidatePatchLocation(t *testing.T) {
+	v := NewCodeValidator(true)
+	patch := "@@ -10,3 +12,4 @@ func main() {\n // setup\n-old := 1\n+x := 1\n+y := $(rm -rf /)\n }"
+
+	err := v.ValidatePatch(patch, "main.go")
+	var patchErr *PatchError
+	if !errors.As(err, &patchErr) {
+		t.Fatalf("ValidatePatch() error = %v, want *PatchError", err)
+	}
+	if patchErr.Filename != "main.go" || patchErr.PatchLine != 5 || patchErr.Line != 14 {
+		t.Errorf("PatchError = %+v, want main.go patch line 5, file line 14", patchErr)
+	}
+}
+
 func TestIsSafeChange(t *testing.T) {
 	v := NewCodeValidator(true)
 
@@ -235,6 +250,15 @@ func TestIsSafeChange(t *testing.T) {
  func main() {
 -    fmt.Println("Hello")
 +    fmt.Println("World")
+ }`,
+			filename: "main.go",
+			want:     false,
+		},
+		{
+			name: "Pointer assignment starting with an asterisk",
+			patch: `@@ -1,3 +1,4 @@
+ func reset(p *int) {
++    *p = 0
  }`,
 			filename: "main.go",
 			want:     false,
@@ -0,0 +1,134 @@
+package security
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around a synthesized hunk.
+const diffContext = 3
+
+// DiffPatch returns a unified diff hunk turning base into head, for files whose patch
+// GitHub omits. Every line between the common prefix and suffix is treated as changed,
+// so the hunk may be larger than a minimal diff but never hides a change.
+// It returns "" if the contents have the same lines.
+func DiffPatch(base, head string) string {
+	a, b := splitLines(base), splitLines(head)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+
+	start := max(prefix-diffContext, 0)
+	after := min(suffix, diffContext)
+	endA, endB := len(a)-suffix, len(b)-suffix
+
+	var patch strings.Builder
+	fmt.Fprintf(&patch, "@@ -%s +%s @@\n", hunkRange(start, endA+after-start), hunkRange(start, endB+after-start))
+	for _, line := range a[start:prefix] {
+		patch.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix:endA] {
+		patch.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix:endB] {
+		patch.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[endA : endA+after] {
+		patch.WriteString(" " + line + "\n")
+	}
+	return patch.String()
+}
+
+// WhitespaceOnly reports whether a patch changes nothing but whitespace: the removed and
+// added lines hold the same words in the same order. In files where indentation is
+// significant (Python, YAML and Makefiles), only trailing whitespace and blank lines may change.
+func WhitespaceOnly(patch, filename string) bool {
+	var removed, added []string
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			// File header
+		case strings.HasPrefix(line, "-"):
+			removed = append(removed, line[1:])
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line[1:])
+		}
+	}
+	if len(removed) == 0 && len(added) == 0 {
+		return false
+	}
+
+	if indentationSignificant(filename) {
+		return slices.Equal(nonBlankLines(removed), nonBlankLines(added))
+	}
+	return slices.Equal(strings.Fields(strings.Join(removed, "\n")), strings.Fields(strings.Join(added, "\n")))
+}
+
+// indentationSignificant reports whether leading whitespace affects the meaning of a file.
+func indentationSignificant(filename string) bool {
+	switch DetectLanguage(filename) {
+	case LanguagePython, LanguageYAML:
+		return true
+	}
+	base := filepath.Base(filename)
+	return base == "Makefile" || base == "GNUmakefile" || strings.HasSuffix(base, ".mk")
+}
+
+// nonBlankLines returns the non-blank lines with trailing whitespace removed.
+func nonBlankLines(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if line = strings.TrimRight(line, " \t\r"); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// hunkRange formats the range of a hunk covering n lines from the 0-based line start.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// addedLines calls fn for each added line of a patch, with its 1-based line within the
+// patch, its line in the new file (0 if the hunk header was missing) and its content.
+func addedLines(patch string, fn func(patchLine, line int, content string)) {
+	newLine := 0
+	for i, line := range strings.Split(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			newLine, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			fn(i+1, newLine, line[1:])
+		}
+		if !strings.HasPrefix(line, "-") && newLine > 0 {
+			newLine++
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package security
+
+import (
+	"testing"
+)
+
+func TestDiffPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		head string
+		want string
+	}{
+		{
+			name: "unchanged",
+			base: "a\nb\n",
+			head: "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed middle line",
+			base: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			head: "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "added file",
+			base: "",
+			head: "a\nb\n",
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "removed file",
+			base: "a\n",
+			head: "",
+			want: "@@ -1,1 +0,0 @@\n-a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffPatch(tt.base, tt.head); got != tt.want {
+				t.Errorf("DiffPatch() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhitespaceOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		filename string
+		want     bool
+	}{
+		{
+			name:     "trailing whitespace",
+			patch:    "@@ -1,2 +1,2 @@\n x := 1\n-y := 2   \n+y := 2",
+			filename: "main.js",
+			want:     true,
+		},
+		{
+			name:     "rewrapped call",
+			patch:    "@@ -1,1 +1,3 @@\n-call(a, b)\n+call(a,\n+     b)",
+			filename: "main.js",
+			want:     true,
+		},
+		{
+			name:     "joined words",
+			patch:    "@@ -1,1 +1,1 @@\n-s = \"a b\"\n+s = \"ab\"",
+			filename: "main.js",
+			want:     false,
+		},
+		{
+			name:     "Python indentation",
+			patch:    "@@ -1,2 +1,2 @@\n if x:\n-    y()\n+y()",
+			filename: "main.py",
+			want:     false,
+		},
+		{
+			name:     "Python blank line",
+			patch:    "@@ -1,2 +1,3 @@\n import os\n+\n def f():",
+			filename: "main.py",
+			want:     true,
+		},
+		{
+			name:     "no changes",
+			patch:    "@@ -1,1 +1,1 @@\n x",
+			filename: "main.js",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WhitespaceOnly(tt.patch, tt.filename); got != tt.want {
+				t.Errorf("WhitespaceOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
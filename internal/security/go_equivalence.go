@@ -0,0 +1,181 @@
+package security
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// GoEquivalent reports whether two versions of a Go source file have the same syntax tree,
+// ignoring positions, comments and the order of imports. Such a change can only be
+// formatting, comments, doc comments or import reordering.
+//
+// Comments that affect the build are still compared: directives such as //go:build,
+// //go:embed and //go:noinline, including the node each one precedes, and the cgo
+// preamble of import "C".
+func GoEquivalent(base, head []byte) (bool, error) {
+	baseFile, err := parseGo(base)
+	if err != nil {
+		return false, fmt.Errorf("parsing base: %w", err)
+	}
+	headFile, err := parseGo(head)
+	if err != nil {
+		return false, fmt.Errorf("parsing head: %w", err)
+	}
+
+	if !slices.Equal(goImports(baseFile), goImports(headFile)) {
+		return false, nil
+	}
+	stripImports(baseFile)
+	stripImports(headFile)
+
+	if !slices.Equal(goDirectives(baseFile), goDirectives(headFile)) {
+		return false, nil
+	}
+
+	return equalNodes(reflect.ValueOf(baseFile), reflect.ValueOf(headFile)), nil
+}
+
+func parseGo(src []byte) (*ast.File, error) {
+	return parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments|parser.SkipObjectResolution)
+}
+
+// goImports returns the file's imports as sorted "name path" keys.
+// The key of import "C" includes its cgo preamble.
+func goImports(f *ast.File) []string {
+	var keys []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			key := imp.Path.Value
+			if imp.Name != nil {
+				key = imp.Name.Name + " " + key
+			}
+			if imp.Path.Value == `"C"` {
+				key += "\n" + rawComment(gen.Doc) + rawComment(imp.Doc)
+			}
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// stripImports removes the import declarations from f.
+func stripImports(f *ast.File) {
+	f.Imports = nil
+	f.Decls = slices.DeleteFunc(f.Decls, func(decl ast.Decl) bool {
+		gen, ok := decl.(*ast.GenDecl)
+		return ok && gen.Tok == token.IMPORT
+	})
+}
+
+// directivePrefixes start comments that affect the build.
+var directivePrefixes = []string{"//go:", "//line ", "/*line ", "// +build", "//export "}
+
+// goDirectives returns the file's directives, each keyed by the index of the
+// syntax node that follows it, so moving a directive is a change.
+func goDirectives(f *ast.File) []string {
+	var nodes []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		nodes = append(nodes, n)
+		return true
+	})
+
+	var directives []string
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			if !slices.ContainsFunc(directivePrefixes, func(p string) bool { return strings.HasPrefix(c.Text, p) }) {
+				continue
+			}
+			next := len(nodes)
+			for i, n := range nodes {
+				if n.Pos() >= c.End() {
+					next = i
+					break
+				}
+			}
+			directives = append(directives, fmt.Sprintf("%d %s", next, c.Text))
+		}
+	}
+	return directives
+}
+
+// rawComment returns the comment group's text with comment markers intact.
+func rawComment(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range group.List {
+		b.WriteString(c.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Types ignored when comparing syntax trees.
+var (
+	posType           = reflect.TypeOf(token.NoPos)
+	commentGroupType  = reflect.TypeOf((*ast.CommentGroup)(nil))
+	commentGroupsType = reflect.TypeOf([]*ast.CommentGroup(nil))
+	objectType        = reflect.TypeOf((*ast.Object)(nil))
+	scopeType         = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// equalNodes compares two syntax trees, ignoring positions, comments and object resolution.
+// Values of kinds that syntax trees do not contain compare unequal.
+func equalNodes(a, b reflect.Value) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Type() {
+	case posType, commentGroupType, commentGroupsType, objectType, scopeType:
+		return true
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalNodes(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalNodes(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalNodes(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+package security
+
+import (
+	"testing"
+)
+
+func TestGoEquivalent(t *testing.T) {
+	const base = `package main
+
+import (
+	"os"
+	"fmt"
+)
+
+// run prints its arguments
+func run(args []string) {
+	fmt.Println(args)
+	os.Exit(0)
+}
+`
+
+	tests := []struct {
+		name    string
+		head    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "gofmt and import order",
+			head: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// run prints its arguments.
+func run(args []string) {
+	fmt.Println( args )
+	os.Exit(0)
+}
+`,
+			want: true,
+		},
+		{
+			name: "comment added inside body",
+			head: `package main
+
+import (
+	"os"
+	"fmt"
+)
+
+func run(args []string) {
+	// Print before exiting
+	fmt.Println(args)
+	os.Exit(0)
+}
+`,
+			want: true,
+		},
+		{
+			name: "literal changed",
+			head: `package main
+
+import (
+	"os"
+	"fmt"
+)
+
+func run(args []string) {
+	fmt.Println(args)
+	os.Exit(1)
+}
+`,
+			want: false,
+		},
+		{
+			name: "import added",
+			head: `package main
+
+import (
+	"os"
+	"fmt"
+	_ "net/http/pprof"
+)
+
+func run(args []string) {
+	fmt.Println(args)
+	os.Exit(0)
+}
+`,
+			want: false,
+		},
+		{
+			name: "directive added",
+			head: `package main
+
+import (
+	"os"
+	"fmt"
+)
+
+//go:noinline
+func run(args []string) {
+	fmt.Println(args)
+	os.Exit(0)
+}
+`,
+			want: false,
+		},
+		{
+			name:    "unparsable head",
+			head:    "package main\n\nfunc run(",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GoEquivalent([]byte(base), []byte(tt.head))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GoEquivalent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GoEquivalent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoEquivalentMovedDirective(t *testing.T) {
+	base := "package p\n\n//go:noinline\nfunc a() {}\n\nfunc b() {}\n"
+	head := "package p\n\nfunc a() {}\n\n//go:noinline\nfunc b() {}\n"
+
+	if got, err := GoEquivalent([]byte(base), []byte(head)); err != nil || got {
+		t.Errorf("GoEquivalent() = %v, %v; moving a directive should not be equivalent", got, err)
+	}
+}
+
+func TestGoEquivalentCgoPreamble(t *testing.T) {
+	base := "package p\n\n// #define N 1\nimport \"C\"\n"
+	head := "package p\n\n// #define N 2\nimport \"C\"\n"
+
+	if got, err := GoEquivalent([]byte(base), []byte(head)); err != nil || got {
+		t.Errorf("GoEquivalent() = %v, %v; changing the cgo preamble should not be equivalent", got, err)
+	}
+}
@@ -0,0 +1,705 @@
+package security
+
+import (
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TokenKind classifies a region of source text.
+type TokenKind int
+
+// Token kinds, from least to most likely to alter behavior.
+const (
+	TokenWhitespace TokenKind = iota
+	TokenComment
+	TokenString // String and character literals
+	TokenCode
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenWhitespace:
+		return "whitespace"
+	case TokenComment:
+		return "comment"
+	case TokenString:
+		return "string"
+	default:
+		return "code"
+	}
+}
+
+// Language identifies the lexer used for a file.
+type Language string
+
+// Supported languages. Files in other languages are lexed as code.
+const (
+	LanguageGo         Language = "go"
+	LanguagePython     Language = "python"
+	LanguageJavaScript Language = "javascript" // Also TypeScript
+	LanguageJava       Language = "java"
+	LanguageC          Language = "c" // Also C++
+	LanguageRust       Language = "rust"
+	LanguageRuby       Language = "ruby"
+	LanguageShell      Language = "shell"
+	LanguageYAML       Language = "yaml"
+)
+
+// DetectLanguage returns the language of filename, or "" if it has no lexer.
+func DetectLanguage(filename string) Language {
+	switch strings.ToLower(filepath.Base(filename)) {
+	case "gemfile", "rakefile":
+		return LanguageRuby
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".go":
+		return LanguageGo
+	case ".py", ".pyi":
+		return LanguagePython
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		return LanguageJavaScript
+	case ".java":
+		return LanguageJava
+	case ".c", ".h", ".cc", ".cpp", ".cxx", ".hh", ".hpp":
+		return LanguageC
+	case ".rs":
+		return LanguageRust
+	case ".rb":
+		return LanguageRuby
+	case ".sh", ".bash", ".zsh":
+		return LanguageShell
+	case ".yml", ".yaml":
+		return LanguageYAML
+	default:
+		return ""
+	}
+}
+
+// Region is a run of one token kind within an added line.
+type Region struct {
+	Line int // 1-based line in the new file, or 0 if the hunk header was missing
+	Kind TokenKind
+	Text string
+}
+
+// AddedRegions lexes the new side of each hunk in patch and returns the regions
+// of its added lines, in order.
+//
+// Context lines are lexed too, so a comment or string opened on a context line
+// is recognized. The state before the first line of a hunk is unknown, so hunks
+// are lexed as if they start outside any comment or string. To keep that safe,
+// a line comment containing a delimiter that could close an enclosing block
+// comment or multi-line string is classified as code. AddedRegionsInFile, given
+// the file's full contents, knows the state instead.
+func AddedRegions(patch, filename string) []Region {
+	lang := DetectLanguage(filename)
+
+	var regions []Region
+	for _, h := range parseHunks(patch) {
+		src := strings.Join(h.lines, "\n")
+		kinds := lex(lang, src, true)
+
+		offset := 0
+		for i, line := range h.lines {
+			if h.added[i] {
+				lineNo := 0
+				if h.start > 0 {
+					lineNo = h.start + i
+				}
+				regions = append(regions, splitRegions(lineNo, line, kinds[offset:offset+len(line)])...)
+			}
+			offset += len(line) + 1
+		}
+	}
+	return regions
+}
+
+// AddedRegionsInFile is AddedRegions with the added lines lexed as part of head, the
+// file's full new contents, so a hunk starting inside a comment or string is lexed as
+// such. ok is false if the patch does not match head.
+func AddedRegionsInFile(patch, filename string, head []byte) (regions []Region, ok bool) {
+	src := string(head)
+	kinds := lex(DetectLanguage(filename), src, false)
+
+	starts := []int{0} // Offset of each line
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	for _, h := range parseHunks(patch) {
+		if h.start == 0 && len(h.lines) > 0 {
+			return nil, false
+		}
+		for i, line := range h.lines {
+			lineNo := h.start + i
+			if lineNo > len(starts) {
+				return nil, false
+			}
+			start := starts[lineNo-1]
+			end := start + len(line)
+			if end > len(src) || src[start:end] != line || end < len(src) && src[end] != '\n' {
+				return nil, false
+			}
+			if h.added[i] {
+				regions = append(regions, splitRegions(lineNo, line, kinds[start:end])...)
+			}
+		}
+	}
+	return regions, true
+}
+
+// addsCode reports whether patch adds anything other than comments and whitespace.
+func addsCode(patch, filename string) bool {
+	return containsCode(AddedRegions(patch, filename))
+}
+
+// CommentsOnlyInFile reports whether patch changes only comments and whitespace, with
+// its added lines lexed as part of head, the file's full new contents. A patch that
+// does not match head is not.
+func CommentsOnlyInFile(patch, filename string, head []byte) bool {
+	if WhitespaceOnly(patch, filename) {
+		return true
+	}
+	regions, ok := AddedRegionsInFile(patch, filename, head)
+	return ok && !containsCode(regions)
+}
+
+// containsCode reports whether any region is other than comments and whitespace.
+func containsCode(regions []Region) bool {
+	for _, r := range regions {
+		if r.Kind != TokenWhitespace && r.Kind != TokenComment {
+			return true
+		}
+	}
+	return false
+}
+
+// hunk is the new side of a diff hunk.
+type hunk struct {
+	start int      // New file line of the first line, or 0 if unknown
+	lines []string // Context and added lines without their diff prefix
+	added []bool
+}
+
+// parseHunks splits a unified diff into the new side of its hunks.
+func parseHunks(patch string) []hunk {
+	var hunks []hunk
+	var cur *hunk
+
+	lines := strings.Split(patch, "\n")
+	for i, line := range lines {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			hunks = append(hunks, hunk{start: start})
+			cur = &hunks[len(hunks)-1]
+			continue
+		}
+		if cur == nil && (strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "@@")) {
+			continue
+		}
+		if line == "" && i == len(lines)-1 {
+			break
+		}
+		if cur == nil {
+			hunks = append(hunks, hunk{})
+			cur = &hunks[len(hunks)-1]
+		}
+
+		switch {
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			// Removed lines and "\ No newline at end of file" are not in the new file
+		case strings.HasPrefix(line, "+"):
+			cur.lines = append(cur.lines, line[1:])
+			cur.added = append(cur.added, true)
+		default:
+			cur.lines = append(cur.lines, strings.TrimPrefix(line, " "))
+			cur.added = append(cur.added, false)
+		}
+	}
+	return hunks
+}
+
+// splitRegions groups the bytes of line into runs of the same kind.
+func splitRegions(lineNo int, line string, kinds []TokenKind) []Region {
+	var regions []Region
+	for start := 0; start < len(line); {
+		end := start + 1
+		for end < len(line) && kinds[end] == kinds[start] {
+			end++
+		}
+		regions = append(regions, Region{Line: lineNo, Kind: kinds[start], Text: line[start:end]})
+		start = end
+	}
+	return regions
+}
+
+// lex returns the token kind of every byte of src. partial is true if src is part of
+// a file that may start inside a comment or string, and then line comments containing
+// an ambiguous delimiter are code.
+func lex(lang Language, src string, partial bool) []TokenKind {
+	switch lang {
+	case LanguageGo:
+		return lexGo(src, partial)
+	case LanguageYAML:
+		return lexYAML(src)
+	}
+	if s, ok := syntaxes[lang]; ok {
+		return s.lex(src, partial)
+	}
+	return lexCode(src)
+}
+
+// lexCode classifies every non-whitespace byte as code.
+func lexCode(src string) []TokenKind {
+	kinds := make([]TokenKind, len(src))
+	for i := range src {
+		if !isSpace(src[i]) && src[i] != '\n' {
+			kinds[i] = TokenCode
+		}
+	}
+	return kinds
+}
+
+// goAmbiguous are the Go delimiters that could close an enclosing block comment or raw string.
+var goAmbiguous = []string{"*/", "`"}
+
+// lexGo classifies src with the Go scanner. Source the scanner rejects is all code.
+func lexGo(src string, partial bool) []TokenKind {
+	kinds := make([]TokenKind, len(src))
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	failed := false
+
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(token.Position, string) { failed = true }, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // Automatically inserted
+		}
+
+		kind := TokenCode
+		switch tok {
+		case token.COMMENT:
+			kind = TokenComment
+			if partial && strings.HasPrefix(lit, "//") && containsAny(lit, goAmbiguous) {
+				kind = TokenCode
+			}
+		case token.STRING, token.CHAR:
+			kind = TokenString
+		}
+
+		n := len(lit)
+		if n == 0 {
+			n = len(tok.String())
+		}
+		mark(kinds, file.Offset(pos), file.Offset(pos)+n, kind)
+	}
+
+	if failed {
+		return lexCode(src)
+	}
+	return kinds
+}
+
+// yamlBlockScalar matches the end of a line that starts a literal or folded block scalar.
+var yamlBlockScalar = regexp.MustCompile(`(?:^|[\s:-])[|>][0-9+-]*$`)
+
+// lexYAML classifies YAML. Lines of a block scalar are strings, even when they start with "#".
+func lexYAML(src string) []TokenKind {
+	kinds := make([]TokenKind, len(src))
+
+	blockIndent := -1 // Indentation of the line that opened the current block scalar
+	offset := 0
+	for _, line := range strings.SplitAfter(src, "\n") {
+		body := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(body, " \t")
+		indent := len(body) - len(trimmed)
+
+		if blockIndent >= 0 && (trimmed == "" || indent > blockIndent) {
+			for i := indent; i < len(body); i++ {
+				kinds[offset+i] = TokenString
+			}
+			offset += len(line)
+			continue
+		}
+		blockIndent = -1
+
+		lineKinds := kinds[offset : offset+len(body)]
+		lexYAMLLine(body, lineKinds)
+
+		var code strings.Builder
+		for i := range body {
+			if lineKinds[i] != TokenComment {
+				code.WriteByte(body[i])
+			}
+		}
+		if yamlBlockScalar.MatchString(strings.TrimRight(code.String(), " \t")) {
+			blockIndent = indent
+		}
+		offset += len(line)
+	}
+	return kinds
+}
+
+// lexYAMLLine classifies a single line of YAML outside a block scalar.
+func lexYAMLLine(line string, kinds []TokenKind) {
+	for i := 0; i < len(line); {
+		c := line[i]
+		wordStart := i == 0 || strings.IndexByte(" \t[{,:", line[i-1]) >= 0
+		switch {
+		case isSpace(c):
+			i++
+		case c == '#' && (i == 0 || isSpace(line[i-1])):
+			mark(kinds, i, len(line), TokenComment)
+			return
+		case (c == '"' || c == '\'') && wordStart:
+			end := i + 1
+			for end < len(line) {
+				if c == '"' && line[end] == '\\' {
+					end += 2
+					continue
+				}
+				if line[end] == c {
+					if c == '\'' && end+1 < len(line) && line[end+1] == '\'' {
+						end += 2 // Escaped quote
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			mark(kinds, i, end, TokenString)
+			i = end
+		default:
+			kinds[i] = TokenCode
+			i++
+		}
+	}
+}
+
+// quote is a string literal delimiter.
+type quote struct {
+	delim     string
+	multiline bool // May span lines
+	raw       bool // Backslash does not escape
+}
+
+// syntax describes the comments and literals of a language for the generic lexer.
+type syntax struct {
+	lineComments  []string
+	blockComment  [2]string // Open and close, or empty
+	nested        bool      // Block comments nest
+	hashWordStart bool      // Line comments only start at the beginning of a word
+	quotes        []quote   // Longest delimiters first
+	charLiterals  bool      // ' delimits a character literal when one is complete
+	rawString     func(src string, i int) int
+	heredocs      bool
+	escapes       bool // Backslash escapes the next character outside quotes
+
+	// ambiguous are the delimiters that could close an enclosing block comment
+	// or multi-line string; a line comment containing one is classified as code.
+	ambiguous []string
+}
+
+var cComment = [2]string{"/*", "*/"}
+
+// syntaxes are the languages handled by the generic lexer.
+var syntaxes = map[Language]*syntax{
+	LanguagePython: {
+		lineComments: []string{"#"},
+		quotes:       []quote{{`"""`, true, false}, {`'''`, true, false}, {`"`, false, false}, {`'`, false, false}},
+		ambiguous:    []string{`"""`, `'''`},
+	},
+	LanguageJavaScript: {
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       []quote{{"`", true, false}, {`"`, false, false}, {`'`, false, false}},
+		ambiguous:    []string{"*/", "`"},
+	},
+	LanguageJava: {
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       []quote{{`"""`, true, false}, {`"`, false, false}},
+		charLiterals: true,
+		ambiguous:    []string{"*/", `"""`},
+	},
+	LanguageC: {
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		quotes:       []quote{{`"`, false, false}},
+		charLiterals: true,
+		rawString:    cppRawString,
+		ambiguous:    []string{"*/"},
+	},
+	LanguageRust: {
+		lineComments: []string{"//"},
+		blockComment: cComment,
+		nested:       true,
+		quotes:       []quote{{`"`, true, false}},
+		charLiterals: true, // Lifetimes such as 'a are code
+		rawString:    rustRawString,
+		ambiguous:    []string{"*/", `"`},
+	},
+	LanguageRuby: {
+		lineComments: []string{"#"},
+		quotes:       []quote{{`"`, true, false}, {`'`, true, false}},
+		heredocs:     true,
+		ambiguous:    []string{`"`, `'`},
+	},
+	LanguageShell: {
+		lineComments:  []string{"#"},
+		hashWordStart: true,
+		quotes:        []quote{{`'`, true, true}, {`"`, true, false}},
+		heredocs:      true,
+		escapes:       true,
+		ambiguous:     []string{`'`, `"`},
+	},
+}
+
+// heredocStart matches a heredoc operator and captures its terminator.
+var heredocStart = regexp.MustCompile(`^<<[~-]?(['"]?)([A-Za-z_]\w*)(['"]?)`)
+
+// lex returns the token kind of every byte of src.
+func (s *syntax) lex(src string, partial bool) []TokenKind {
+	kinds := make([]TokenKind, len(src))
+	var terminators []string // Pending heredocs, which start on the next line
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		if c == '\n' {
+			i++
+			for _, term := range terminators {
+				i = heredocBody(src, i, term, kinds)
+			}
+			terminators = nil
+			continue
+		}
+		if isSpace(c) {
+			i++
+			continue
+		}
+
+		kind, end := s.token(src, i, partial)
+		if s.heredocs && kind == TokenCode {
+			if m := heredocStart.FindStringSubmatch(src[i:]); m != nil {
+				terminators = append(terminators, m[2])
+				end = i + len(m[0])
+			}
+		}
+		mark(kinds, i, end, kind)
+		i = end
+	}
+	return kinds
+}
+
+// token classifies the token starting at src[i] and returns its end.
+func (s *syntax) token(src string, i int, partial bool) (TokenKind, int) {
+	rest := src[i:]
+
+	if open := s.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+		return TokenComment, s.closeBlock(src, i)
+	}
+
+	for _, prefix := range s.lineComments {
+		if strings.HasPrefix(rest, prefix) && (!s.hashWordStart || i == 0 || isSpace(src[i-1])) {
+			end := i + strings.IndexByte(rest+"\n", '\n')
+			if partial && containsAny(src[i:end], s.ambiguous) {
+				return TokenCode, end
+			}
+			return TokenComment, end
+		}
+	}
+
+	if s.rawString != nil {
+		if end := s.rawString(src, i); end > 0 {
+			return TokenString, end
+		}
+	}
+
+	if s.charLiterals && src[i] == '\'' {
+		if end := charLiteral(src, i); end > 0 {
+			return TokenString, end
+		}
+		return TokenCode, i + 1
+	}
+
+	for _, q := range s.quotes {
+		if strings.HasPrefix(rest, q.delim) {
+			return TokenString, q.close(src, i)
+		}
+	}
+
+	if s.escapes && src[i] == '\\' {
+		return TokenCode, min(i+2, len(src))
+	}
+
+	end := i + 1
+	if isIdent(src[i]) {
+		for end < len(src) && isIdent(src[end]) {
+			end++
+		}
+	}
+	return TokenCode, end
+}
+
+// closeBlock returns the end of the block comment starting at src[i].
+// An unterminated comment runs to the end of src.
+func (s *syntax) closeBlock(src string, i int) int {
+	open, closing := s.blockComment[0], s.blockComment[1]
+	depth := 0
+	for j := i; j < len(src); {
+		switch {
+		case strings.HasPrefix(src[j:], open) && (s.nested || depth == 0):
+			depth++
+			j += len(open)
+		case strings.HasPrefix(src[j:], closing):
+			depth--
+			j += len(closing)
+			if depth == 0 {
+				return j
+			}
+		default:
+			j++
+		}
+	}
+	return len(src)
+}
+
+// close returns the end of the string literal starting at src[i].
+// An unterminated literal runs to the end of the line, or of src if it may span lines.
+func (q quote) close(src string, i int) int {
+	for j := i + len(q.delim); j < len(src); {
+		switch {
+		case !q.raw && src[j] == '\\':
+			j += 2
+		case strings.HasPrefix(src[j:], q.delim):
+			return j + len(q.delim)
+		case src[j] == '\n' && !q.multiline:
+			return j
+		default:
+			j++
+		}
+	}
+	return len(src)
+}
+
+// charLiteral returns the end of the character literal starting at src[i], or -1.
+func charLiteral(src string, i int) int {
+	j := i + 1
+	if j < len(src) && src[j] == '\\' {
+		end := strings.IndexByte(src[j:min(j+12, len(src))], '\'')
+		if end < 2 {
+			return -1
+		}
+		return j + end + 1
+	}
+	// A single character, which may be a multi-byte rune
+	for k := j + 1; k < min(j+5, len(src)); k++ {
+		if src[k] == '\'' {
+			return k + 1
+		}
+		if src[k] < 0x80 {
+			break
+		}
+	}
+	return -1
+}
+
+// cppRawString returns the end of a C++ raw string such as R"x(...)x" starting at src[i], or -1.
+func cppRawString(src string, i int) int {
+	if !strings.HasPrefix(src[i:], `R"`) {
+		return -1
+	}
+	open := strings.IndexByte(src[i+2:], '(')
+	if open < 0 || open > 16 {
+		return -1
+	}
+	closing := ")" + src[i+2:i+2+open] + `"`
+	end := strings.Index(src[i+3+open:], closing)
+	if end < 0 {
+		return len(src)
+	}
+	return i + 3 + open + end + len(closing)
+}
+
+// rustRawString returns the end of a Rust raw string such as r#"..."# starting at src[i], or -1.
+func rustRawString(src string, i int) int {
+	j := i
+	if strings.HasPrefix(src[j:], "br") {
+		j++
+	}
+	if src[j] != 'r' {
+		return -1
+	}
+	j++
+	hashes := 0
+	for j < len(src) && src[j] == '#' {
+		hashes++
+		j++
+	}
+	if j >= len(src) || src[j] != '"' {
+		return -1
+	}
+	closing := `"` + strings.Repeat("#", hashes)
+	end := strings.Index(src[j+1:], closing)
+	if end < 0 {
+		return len(src)
+	}
+	return j + 1 + end + len(closing)
+}
+
+// heredocBody marks the heredoc starting at src[i] as a string up to its terminator
+// line and returns the offset after it.
+func heredocBody(src string, i int, term string, kinds []TokenKind) int {
+	for i < len(src) {
+		end := i + strings.IndexByte(src[i:]+"\n", '\n')
+		if strings.TrimSpace(src[i:end]) == term {
+			mark(kinds, i, end, TokenCode)
+			return min(end+1, len(src))
+		}
+		for j := i; j < end; j++ {
+			if !isSpace(src[j]) {
+				kinds[j] = TokenString
+			}
+		}
+		i = end + 1
+	}
+	return len(src)
+}
+
+// mark sets the kind of src[start:end], clamped to kinds.
+func mark(kinds []TokenKind, start, end int, kind TokenKind) {
+	for i := max(start, 0); i < min(end, len(kinds)); i++ {
+		kinds[i] = kind
+	}
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,258 @@
+package security
+
+import (
+	"testing"
+)
+
+func TestAddsCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		patch    string
+		want     bool
+	}{
+		{
+			name:     "Go comment",
+			filename: "main.go",
+			patch:    "@@ -1,2 +1,3 @@\n func main() {\n+\t// TODO: handle errors\n }",
+			want:     false,
+		},
+		{
+			name:     "Go pointer assignment",
+			filename: "main.go",
+			patch:    "@@ -1,2 +1,3 @@\n func set(ptr *int) {\n+\t*ptr = 1\n }",
+			want:     true,
+		},
+		{
+			name:     "Go trailing comment after code",
+			filename: "main.go",
+			patch:    "@@ -1,2 +1,2 @@\n func main() {\n-\tx := 1\n+\tx := 2 // bump\n }",
+			want:     true,
+		},
+		{
+			name:     "Go block comment continued from context",
+			filename: "main.go",
+			patch:    "@@ -1,3 +1,4 @@\n /*\n+ * Fixed a typo\n */\n package main",
+			want:     false,
+		},
+		{
+			name:     "Go line comment closing an unseen block comment",
+			filename: "main.go",
+			patch:    "@@ -10,1 +10,2 @@\n x := 1\n+// */ os.Exit(1) /*",
+			want:     true,
+		},
+		{
+			name:     "Go string change",
+			filename: "main.go",
+			patch:    "@@ -1,1 +1,1 @@\n-const s = \"a\"\n+const s = \"b\"",
+			want:     true,
+		},
+		{
+			name:     "C preprocessor directive",
+			filename: "config.h",
+			patch:    "@@ -1,1 +1,2 @@\n #include <stdio.h>\n+#define DEBUG 1",
+			want:     true,
+		},
+		{
+			name:     "C block comment",
+			filename: "main.c",
+			patch:    "@@ -1,1 +1,4 @@\n int x;\n+/*\n+ * Explain x\n+ */",
+			want:     false,
+		},
+		{
+			name:     "C++ comment marker inside a string",
+			filename: "main.cpp",
+			patch:    "@@ -1,1 +1,1 @@\n-auto s = \"\";\n+auto s = \"// not a comment\";",
+			want:     true,
+		},
+		{
+			name:     "JavaScript template literal spanning lines",
+			filename: "app.js",
+			patch:    "@@ -1,2 +1,3 @@\n const sql = `\n+// DROP TABLE users\n `;",
+			want:     true,
+		},
+		{
+			name:     "TypeScript JSDoc",
+			filename: "app.ts",
+			patch:    "@@ -1,1 +1,3 @@\n+/**\n+ * Returns `true` when ready.\n+ */\n export function ready() {}",
+			want:     false,
+		},
+		{
+			name:     "Python comment",
+			filename: "app.py",
+			patch:    "@@ -1,1 +1,2 @@\n def f():\n+    # explain f\n     return 1",
+			want:     false,
+		},
+		{
+			name:     "Python hash inside docstring",
+			filename: "app.py",
+			patch:    "@@ -1,2 +1,3 @@\n def f():\n     \"\"\"\n+    # Usage\n     \"\"\"",
+			want:     true,
+		},
+		{
+			name:     "Rust lifetime is not a character literal",
+			filename: "lib.rs",
+			patch:    "@@ -1,1 +1,1 @@\n-fn f<'a>(x: &'a str) {}\n+fn f<'a>(x: &'a str) {} // ok",
+			want:     true,
+		},
+		{
+			name:     "Rust nested block comment",
+			filename: "lib.rs",
+			patch:    "@@ -1,1 +1,1 @@\n+/* outer /* inner */ still outer */\n fn main() {}",
+			want:     false,
+		},
+		{
+			name:     "Ruby heredoc line starting with hash",
+			filename: "app.rb",
+			patch:    "@@ -1,2 +1,3 @@\n text = <<~MD\n+  # Heading\n MD",
+			want:     true,
+		},
+		{
+			name:     "Shell hash inside a word",
+			filename: "run.sh",
+			patch:    "@@ -1,1 +1,1 @@\n+echo a#b",
+			want:     true,
+		},
+		{
+			name:     "Shell comment",
+			filename: "run.sh",
+			patch:    "@@ -1,1 +1,1 @@\n+  # explain",
+			want:     false,
+		},
+		{
+			name:     "YAML comment",
+			filename: "config.yml",
+			patch:    "@@ -1,1 +1,2 @@\n+# Settings\n key: value",
+			want:     false,
+		},
+		{
+			name:     "YAML heading inside a block scalar",
+			filename: "config.yml",
+			patch:    "@@ -1,2 +1,3 @@\n description: |\n   Intro\n+  # key",
+			want:     true,
+		},
+		{
+			name:     "YAML comment after a block scalar",
+			filename: "config.yml",
+			patch:    "@@ -1,2 +1,3 @@\n description: |\n   Intro\n+# key",
+			want:     false,
+		},
+		{
+			name:     "Ruby hunk starting inside a string",
+			filename: "a.rb",
+			patch:    "@@ -10,2 +10,3 @@\n   more text\n+# \" + File.delete(path) + \"\n   end of text\"",
+			want:     true,
+		},
+		{
+			name:     "Shell hunk starting inside a string",
+			filename: "run.sh",
+			patch:    "@@ -10,1 +10,2 @@\n   more text\n+# ' ; rm -rf / ; echo '",
+			want:     true,
+		},
+		{
+			name:     "JavaScript hunk starting inside a template literal",
+			filename: "app.js",
+			patch:    "@@ -10,1 +10,2 @@\n   more text\n+// `; fetch(url); `",
+			want:     true,
+		},
+		{
+			name:     "Go hunk starting inside a raw string",
+			filename: "main.go",
+			patch:    "@@ -10,1 +10,2 @@\n more text\n+// `; os.Exit(1); x := `",
+			want:     true,
+		},
+		{
+			name:     "Rust hunk starting inside a string",
+			filename: "lib.rs",
+			patch:    "@@ -10,1 +10,2 @@\n more text\n+// \"; std::process::exit(1); let s = \"",
+			want:     true,
+		},
+		{
+			name:     "Unknown language",
+			filename: "build.gradle",
+			patch:    "@@ -1,1 +1,1 @@\n+// comment",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addsCode(tt.patch, tt.filename); got != tt.want {
+				t.Errorf("addsCode() = %v, want %v; regions %+v", got, tt.want, AddedRegions(tt.patch, tt.filename))
+			}
+		})
+	}
+}
+
+func TestAddedRegions(t *testing.T) {
+	patch := "@@ -3,2 +3,3 @@\n func main() {\n+\tfmt.Println(\"hi\") // greet\n }"
+
+	want := []Region{
+		{Line: 4, Kind: TokenWhitespace, Text: "\t"},
+		{Line: 4, Kind: TokenCode, Text: "fmt.Println("},
+		{Line: 4, Kind: TokenString, Text: `"hi"`},
+		{Line: 4, Kind: TokenCode, Text: ")"},
+		{Line: 4, Kind: TokenWhitespace, Text: " "},
+		{Line: 4, Kind: TokenComment, Text: "// greet"},
+	}
+
+	got := AddedRegions(patch, "main.go")
+	if len(got) != len(want) {
+		t.Fatalf("AddedRegions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("region %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCommentsOnlyInFile(t *testing.T) {
+	const ruby = "def greet\n  text = \"Hello,\n  world\n  # drop users\n  \"\n  puts text\nend\n"
+
+	tests := []struct {
+		name     string
+		filename string
+		head     string
+		patch    string
+		want     bool
+	}{
+		{
+			name:     "hunk starting inside a string",
+			filename: "a.rb",
+			head:     ruby,
+			patch:    "@@ -3,2 +3,3 @@\n   world\n+  # drop users\n   \"",
+			want:     false,
+		},
+		{
+			name:     "comment with a quote",
+			filename: "a.rb",
+			head:     "def greet\n  # don't shout\n  puts \"hi\"\nend\n",
+			patch:    "@@ -1,2 +1,3 @@\n def greet\n+  # don't shout\n   puts \"hi\"",
+			want:     true,
+		},
+		{
+			name:     "Go comment after a raw string",
+			filename: "main.go",
+			head:     "package main\n\nvar s = `a\nb`\n\n// `done`\n",
+			patch:    "@@ -4,2 +4,3 @@\n b`\n \n+// `done`",
+			want:     true,
+		},
+		{
+			name:     "patch not matching the file",
+			filename: "a.rb",
+			head:     ruby,
+			patch:    "@@ -1,1 +1,2 @@\n def greet\n+  # hello",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommentsOnlyInFile(tt.patch, tt.filename, []byte(tt.head)); got != tt.want {
+				regions, ok := AddedRegionsInFile(tt.patch, tt.filename, []byte(tt.head))
+				t.Errorf("CommentsOnlyInFile() = %v, want %v; regions %+v, ok %v", got, tt.want, regions, ok)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 )
 
@@ -15,37 +16,79 @@ type ModelProvider string
 const (
 	ModelGemini  ModelProvider = "gemini"
 	ModelClaude  ModelProvider = "claude"  // Future support
-	ModelOpenAI  ModelProvider = "openai"  // Future support
+	ModelOpenAI  ModelProvider = "openai"  // Any OpenAI-compatible endpoint
+	ModelOllama  ModelProvider = "ollama"
 )
 
 // ModelAnalysis represents analysis from a single model
 type ModelAnalysis struct {
 	Provider          ModelProvider
+	Model             string // Model name, distinguishes several models of one provider
 	AltersBehavior    bool
 	Category          string
 	Risky             bool
 	PossiblyMalicious bool
+	InsecureChange    bool
+	NonTrivial        bool
+	Superfluous       bool
+	Vandalism         bool
+	MajorVersionBump  bool
 	Confidence        float64
 	Reason            string
 	RawResponse       string
 }
 
+// Name identifies the model in logs and disagreements, e.g. "ollama/llama3".
+func (a ModelAnalysis) Name() string {
+	if a.Model == "" {
+		return string(a.Provider)
+	}
+	return string(a.Provider) + "/" + a.Model
+}
+
 // ConsensusResult represents the combined analysis from multiple models
 type ConsensusResult struct {
-	Consensus         bool     // Do models agree?
+	Consensus         bool     // Do models agree on behavior and category?
 	AltersBehavior    bool     // Consensus decision
 	Category          string   // Most common category
 	Risky             bool     // Any model thinks it's risky
 	PossiblyMalicious bool     // Any model thinks it's malicious
+	InsecureChange    bool     // Any model thinks it's insecure
+	NonTrivial        bool     // Any model thinks it's non-trivial
+	Superfluous       bool     // Any model thinks it's superfluous
+	Vandalism         bool     // Any model thinks it's vandalism
+	MajorVersionBump  bool     // Any model sees a major version bump
 	ConfidenceScore   float64  // Average confidence
 	Disagreements     []string // List of disagreements
 	ModelCount        int      // Number of models used
 }
 
+// RedFlags lists the flags raised by any model, e.g. "risky".
+func (r *ConsensusResult) RedFlags() []string {
+	var flags []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"possibly malicious", r.PossiblyMalicious},
+		{"vandalism", r.Vandalism},
+		{"insecure change", r.InsecureChange},
+		{"risky", r.Risky},
+		{"non-trivial", r.NonTrivial},
+		{"superfluous", r.Superfluous},
+		{"major version bump", r.MajorVersionBump},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	return flags
+}
+
 // MultiModelAnalyzer provides consensus-based AI analysis
 type MultiModelAnalyzer struct {
 	models        []ModelProvider
-	threshold     float64 // Agreement threshold (e.g., 0.66 for 2/3)
+	threshold     float64 // Fraction of models that must agree (e.g., 0.66 for 2/3)
 	strictMode    bool    // Require unanimous agreement for approval
 	enableLogging bool
 }
@@ -74,18 +117,35 @@ func (m *MultiModelAnalyzer) AnalyzeWithConsensus(ctx context.Context, analyses
 		ModelCount: len(analyses),
 	}
 
-	// Check for unanimous red flags (any model detecting malicious intent)
+	// Red flags raised by any model apply to the consensus
 	for _, analysis := range analyses {
 		if analysis.PossiblyMalicious {
 			result.PossiblyMalicious = true
 			result.Risky = true
 			if m.enableLogging {
-				log.Printf("[MULTI-MODEL] Model %s detected possible malicious intent", analysis.Provider)
+				log.Printf("[MULTI-MODEL] Model %s detected possible malicious intent", analysis.Name())
 			}
 		}
+		if analysis.InsecureChange {
+			result.InsecureChange = true
+			result.Risky = true
+		}
+		if analysis.Vandalism {
+			result.Vandalism = true
+			result.Risky = true
+		}
 		if analysis.Risky {
 			result.Risky = true
 		}
+		if analysis.NonTrivial {
+			result.NonTrivial = true
+		}
+		if analysis.Superfluous {
+			result.Superfluous = true
+		}
+		if analysis.MajorVersionBump {
+			result.MajorVersionBump = true
+		}
 	}
 
 	// Calculate consensus on behavior alteration
@@ -103,13 +163,21 @@ func (m *MultiModelAnalyzer) AnalyzeWithConsensus(ctx context.Context, analyses
 		result.AltersBehavior = altersBehaviorVotes > 0
 		result.Consensus = altersBehaviorVotes == 0 || altersBehaviorVotes == len(analyses)
 	} else {
-		// Use threshold for consensus
-		result.AltersBehavior = agreementRatio >= m.threshold
-		result.Consensus = agreementRatio >= m.threshold || agreementRatio <= (1-m.threshold)
+		// The change is only considered safe if enough models agree it doesn't alter behavior
+		result.AltersBehavior = 1-agreementRatio < m.threshold
+		result.Consensus = agreementRatio >= m.threshold || 1-agreementRatio >= m.threshold
 	}
 
-	// Determine most common category
-	result.Category = m.getMostCommonCategory(analyses)
+	// Determine most common category, which enough models must share
+	var categoryVotes int
+	result.Category, categoryVotes = m.getMostCommonCategory(analyses)
+	requiredRatio := m.threshold
+	if m.strictMode {
+		requiredRatio = 1
+	}
+	if float64(categoryVotes)/float64(len(analyses)) < requiredRatio {
+		result.Consensus = false
+	}
 
 	// Calculate average confidence
 	totalConfidence := 0.0
@@ -130,8 +198,9 @@ func (m *MultiModelAnalyzer) AnalyzeWithConsensus(ctx context.Context, analyses
 	return result, nil
 }
 
-// getMostCommonCategory finds the most common category among analyses
-func (m *MultiModelAnalyzer) getMostCommonCategory(analyses []ModelAnalysis) string {
+// getMostCommonCategory finds the most common category among analyses and its vote count.
+// Ties are broken alphabetically so the result is deterministic.
+func (m *MultiModelAnalyzer) getMostCommonCategory(analyses []ModelAnalysis) (string, int) {
 	categoryCount := make(map[string]int)
 	for _, analysis := range analyses {
 		if analysis.Category != "" {
@@ -140,69 +209,77 @@ func (m *MultiModelAnalyzer) getMostCommonCategory(analyses []ModelAnalysis) str
 	}
 
 	if len(categoryCount) == 0 {
-		return "unknown"
+		return "unknown", 0
 	}
 
 	// Find the most common category
 	maxCount := 0
 	mostCommon := ""
 	for category, count := range categoryCount {
-		if count > maxCount {
+		if count > maxCount || (count == maxCount && category < mostCommon) {
 			maxCount = count
 			mostCommon = category
 		}
 	}
 
-	return mostCommon
+	return mostCommon, maxCount
 }
 
-// findDisagreements identifies where models disagree
+// findDisagreements identifies where models disagree, naming the models on each side
 func (m *MultiModelAnalyzer) findDisagreements(analyses []ModelAnalysis) []string {
 	var disagreements []string
 
-	// Check AltersBehavior disagreement
-	altersBehaviorValues := make(map[bool][]ModelProvider)
-	for _, analysis := range analyses {
-		altersBehaviorValues[analysis.AltersBehavior] = append(
-			altersBehaviorValues[analysis.AltersBehavior], analysis.Provider)
-	}
-
-	if len(altersBehaviorValues) > 1 {
-		var providers []string
-		for value, models := range altersBehaviorValues {
-			modelNames := make([]string, len(models))
-			for i, model := range models {
-				modelNames[i] = string(model)
-			}
-			providers = append(providers, fmt.Sprintf("%v: %s", value, strings.Join(modelNames, ", ")))
+	flags := []struct {
+		name  string
+		value func(ModelAnalysis) bool
+	}{
+		{"AltersBehavior", func(a ModelAnalysis) bool { return a.AltersBehavior }},
+		{"PossiblyMalicious", func(a ModelAnalysis) bool { return a.PossiblyMalicious }},
+		{"InsecureChange", func(a ModelAnalysis) bool { return a.InsecureChange }},
+		{"Risky", func(a ModelAnalysis) bool { return a.Risky }},
+		{"NonTrivial", func(a ModelAnalysis) bool { return a.NonTrivial }},
+	}
+
+	for _, flag := range flags {
+		values := make(map[string][]string)
+		for _, analysis := range analyses {
+			value := fmt.Sprintf("%v", flag.value(analysis))
+			values[value] = append(values[value], analysis.Name())
+		}
+		if len(values) > 1 {
+			disagreements = append(disagreements,
+				fmt.Sprintf("%s disagreement - %s", flag.name, formatVotes(values)))
 		}
-		disagreements = append(disagreements, 
-			fmt.Sprintf("AltersBehavior disagreement - %s", strings.Join(providers, " vs ")))
 	}
 
 	// Check Category disagreement
-	categoryValues := make(map[string][]ModelProvider)
+	categoryValues := make(map[string][]string)
 	for _, analysis := range analyses {
 		categoryValues[analysis.Category] = append(
-			categoryValues[analysis.Category], analysis.Provider)
+			categoryValues[analysis.Category], analysis.Name())
 	}
 
 	if len(categoryValues) > 1 {
 		disagreements = append(disagreements,
-			fmt.Sprintf("Category disagreement - %d different categories", len(categoryValues)))
+			fmt.Sprintf("Category disagreement - %s", formatVotes(categoryValues)))
 	}
 
-	// Check Risk assessment disagreement
-	riskyValues := make(map[bool][]ModelProvider)
-	for _, analysis := range analyses {
-		riskyValues[analysis.Risky] = append(riskyValues[analysis.Risky], analysis.Provider)
-	}
+	return disagreements
+}
 
-	if len(riskyValues) > 1 {
-		disagreements = append(disagreements, "Risk assessment disagreement")
+// formatVotes formats the models voting for each value, e.g. "false: a, b vs true: c".
+func formatVotes(votes map[string][]string) string {
+	values := make([]string, 0, len(votes))
+	for value := range votes {
+		values = append(values, value)
 	}
+	sort.Strings(values)
 
-	return disagreements
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%s: %s", value, strings.Join(votes[value], ", "))
+	}
+	return strings.Join(parts, " vs ")
 }
 
 // AnomalyDetector detects anomalous patterns in model responses
@@ -224,11 +301,21 @@ func NewAnomalyDetector(maxHistory int) *AnomalyDetector {
 
 // DetectAnomaly checks if a response is anomalous compared to history
 func (a *AnomalyDetector) DetectAnomaly(analysis ModelAnalysis) bool {
+	anomalies := a.Detect(analysis)
+	if len(anomalies) > 0 {
+		log.Printf("[ANOMALY] Detected anomalies in model response: %v", anomalies)
+		return true
+	}
+	return false
+}
+
+// Detect returns the ways a response deviates from history, then adds it to history
+func (a *AnomalyDetector) Detect(analysis ModelAnalysis) []string {
 	// Check for sudden change in typical response patterns
 	if len(a.responseHistory) < 10 {
 		// Not enough history to detect anomalies
 		a.addToHistory(analysis)
-		return false
+		return nil
 	}
 
 	// Calculate historical patterns
@@ -275,12 +362,7 @@ func (a *AnomalyDetector) DetectAnomaly(analysis ModelAnalysis) bool {
 
 	a.addToHistory(analysis)
 
-	if len(anomalies) > 0 {
-		log.Printf("[ANOMALY] Detected anomalies in model response: %v", anomalies)
-		return true
-	}
-
-	return false
+	return anomalies
 }
 
 // addToHistory adds an analysis to history with size limit
@@ -0,0 +1,118 @@
+package security
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeWithConsensus(t *testing.T) {
+	safe := func(model string) ModelAnalysis {
+		return ModelAnalysis{Provider: ModelOllama, Model: model, Category: "typo", Confidence: 0.9}
+	}
+
+	tests := []struct {
+		name          string
+		threshold     float64
+		strict        bool
+		analyses      func() []ModelAnalysis
+		wantConsensus bool
+		wantAlters    bool
+		wantFlags     []string
+	}{
+		{
+			name:          "unanimous safe",
+			threshold:     1,
+			analyses:      func() []ModelAnalysis { return []ModelAnalysis{safe("a"), safe("b")} },
+			wantConsensus: true,
+		},
+		{
+			name:      "any dissent alters behavior at threshold 1",
+			threshold: 1,
+			analyses: func() []ModelAnalysis {
+				b := safe("b")
+				b.AltersBehavior = true
+				return []ModelAnalysis{safe("a"), b, safe("c")}
+			},
+			wantAlters: true,
+		},
+		{
+			name:      "two of three is enough at threshold 0.66",
+			threshold: 0.66,
+			analyses: func() []ModelAnalysis {
+				c := safe("c")
+				c.AltersBehavior = true
+				return []ModelAnalysis{safe("a"), safe("b"), c}
+			},
+			wantConsensus: true,
+		},
+		{
+			name:      "strict mode rejects any dissent",
+			threshold: 0.66,
+			strict:    true,
+			analyses: func() []ModelAnalysis {
+				c := safe("c")
+				c.AltersBehavior = true
+				return []ModelAnalysis{safe("a"), safe("b"), c}
+			},
+			wantAlters: true,
+		},
+		{
+			name:      "category split breaks consensus",
+			threshold: 0.66,
+			analyses: func() []ModelAnalysis {
+				b := safe("b")
+				b.Category = "refactor"
+				return []ModelAnalysis{safe("a"), b}
+			},
+		},
+		{
+			name:      "red flags from any model",
+			threshold: 0.66,
+			analyses: func() []ModelAnalysis {
+				b, c := safe("b"), safe("c")
+				b.InsecureChange = true
+				c.NonTrivial = true
+				return []ModelAnalysis{safe("a"), b, c}
+			},
+			wantConsensus: true,
+			wantFlags:     []string{"insecure change", "risky", "non-trivial"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiModelAnalyzer(nil, tt.threshold, tt.strict)
+			result, err := m.AnalyzeWithConsensus(context.Background(), tt.analyses())
+			if err != nil {
+				t.Fatalf("AnalyzeWithConsensus() error = %v", err)
+			}
+			if result.Consensus != tt.wantConsensus {
+				t.Errorf("Consensus = %v, want %v", result.Consensus, tt.wantConsensus)
+			}
+			if result.AltersBehavior != tt.wantAlters {
+				t.Errorf("AltersBehavior = %v, want %v", result.AltersBehavior, tt.wantAlters)
+			}
+			if got := strings.Join(result.RedFlags(), ","); got != strings.Join(tt.wantFlags, ",") {
+				t.Errorf("RedFlags() = %q, want %q", got, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestFindDisagreements(t *testing.T) {
+	analyses := []ModelAnalysis{
+		{Provider: ModelGemini, Model: "gemini-2.0-flash", Category: "typo"},
+		{Provider: ModelOllama, Model: "llama3", Category: "typo", PossiblyMalicious: true},
+		{Provider: ModelOpenAI, Model: "gpt-4o", Category: "docs"},
+	}
+
+	got := NewMultiModelAnalyzer(nil, 1, false).findDisagreements(analyses)
+	want := []string{
+		"PossiblyMalicious disagreement - false: gemini/gemini-2.0-flash, openai/gpt-4o vs true: ollama/llama3",
+		"Category disagreement - docs: openai/gpt-4o vs typo: gemini/gemini-2.0-flash, ollama/llama3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findDisagreements() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,297 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// File types that a path override can assign.
+const (
+	OverrideMarkdown = "markdown" // Documentation, validated leniently
+	OverrideCode     = "code"     // Program source, only comment changes are safe
+	OverrideConfig   = "config"   // Configuration, only comment changes are safe
+	OverrideData     = "data"     // Data such as translations, validated but not treated as code
+)
+
+// PathOverride adjusts the FileTypeConfig for files matching a glob.
+type PathOverride struct {
+	Pattern string `yaml:"pattern"`
+
+	// Type replaces the detected file type (markdown, code, config or data).
+	Type string `yaml:"type"`
+
+	// MaxLineLength replaces the maximum added line length when positive.
+	MaxLineLength int `yaml:"max_line_length"`
+
+	// ForbiddenCharacters replaces the set of forbidden characters when set.
+	ForbiddenCharacters *string `yaml:"forbidden_characters"`
+
+	// AllowApostrophes replaces whether apostrophes are allowed when set.
+	AllowApostrophes *bool `yaml:"allow_apostrophes"`
+
+	// Scripts replaces the Unicode scripts allowed besides Latin when set, e.g.
+	// [Han, Hiragana, Katakana] for Japanese documentation.
+	Scripts []string `yaml:"scripts"`
+}
+
+// PathRules are gitignore-style glob rules controlling which files may be auto-approved.
+// A nil *PathRules has no rules.
+type PathRules struct {
+	// Deny lists paths that always block auto-approval.
+	Deny []string `yaml:"deny"`
+
+	// Allow lists paths that are treated as documentation.
+	Allow []string `yaml:"allow"`
+
+	// Overrides adjust validation for matching paths. Later overrides win.
+	Overrides []PathOverride `yaml:"overrides"`
+}
+
+// ProtectedPath is a built-in rule for paths that always require manual review.
+type ProtectedPath struct {
+	Patterns []string
+	Reason   string
+	Detail   string
+
+	// Overridable rules are heuristics that an Allow rule can lift.
+	Overridable bool
+}
+
+// ProtectedPaths are checked for every file after content validation.
+var ProtectedPaths = []ProtectedPath{
+	{
+		Patterns: []string{"*.sh", "*.bash"},
+		Reason:   "Shell script modifications require manual review",
+		Detail:   "Shell scripts cannot be auto-approved",
+	},
+	{
+		Patterns:    []string{"*script*"},
+		Reason:      "Shell script modifications require manual review",
+		Detail:      "Shell scripts cannot be auto-approved",
+		Overridable: true,
+	},
+	{
+		Patterns: []string{"**/.github/workflows/**"},
+		Reason:   "GitHub Actions workflow changes require manual review",
+		Detail:   "Workflow files cannot be auto-approved",
+	},
+	{
+		Patterns: []string{
+			".travis.yml", ".circleci", "Jenkinsfile", ".gitlab-ci.yml",
+			"azure-pipelines.yml", "buildspec.yml", ".drone.yml",
+		},
+		Reason: "CI/CD configuration changes require manual review",
+		Detail: "CI/CD files cannot be auto-approved",
+	},
+}
+
+// Validate checks that all patterns and override types are valid.
+func (r *PathRules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	for _, p := range r.Deny {
+		if err := ValidatePattern(p); err != nil {
+			return fmt.Errorf("deny: %w", err)
+		}
+	}
+	for _, p := range r.Allow {
+		if err := ValidatePattern(p); err != nil {
+			return fmt.Errorf("allow: %w", err)
+		}
+	}
+	for _, o := range r.Overrides {
+		if err := ValidatePattern(o.Pattern); err != nil {
+			return fmt.Errorf("overrides: %w", err)
+		}
+		switch o.Type {
+		case "", OverrideMarkdown, OverrideCode, OverrideConfig, OverrideData:
+		default:
+			return fmt.Errorf("overrides: unknown type %q for %q (want markdown, code, config or data)", o.Type, o.Pattern)
+		}
+		if o.MaxLineLength < 0 {
+			return fmt.Errorf("overrides: max_line_length for %q must not be negative", o.Pattern)
+		}
+		for _, script := range o.Scripts {
+			if _, ok := unicode.Scripts[script]; !ok {
+				return fmt.Errorf("overrides: unknown script %q for %q (want a Unicode script name such as Han or Cyrillic)", script, o.Pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Denied reports whether the file matches a deny rule, and which one.
+func (r *PathRules) Denied(filename string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	for _, p := range r.Deny {
+		if MatchPath(p, filename) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// Allowed reports whether the file matches an allow rule.
+func (r *PathRules) Allowed(filename string) bool {
+	if r == nil {
+		return false
+	}
+	for _, p := range r.Allow {
+		if MatchPath(p, filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// Protected returns the built-in protection matching the file, or nil.
+// Overridable protections are skipped for allowed files.
+func (r *PathRules) Protected(filename string) *ProtectedPath {
+	allowed := r.Allowed(filename)
+	for i := range ProtectedPaths {
+		p := &ProtectedPaths[i]
+		if p.Overridable && allowed {
+			continue
+		}
+		for _, pattern := range p.Patterns {
+			if MatchPath(pattern, filename) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// FileTypeConfig returns the validation rules for a file, applying allow rules and overrides
+// on top of GetFileTypeConfig.
+func (r *PathRules) FileTypeConfig(filename string) FileTypeConfig {
+	config := GetFileTypeConfig(filename)
+	if r == nil {
+		return config
+	}
+
+	if r.Allowed(filename) {
+		config = fileTypeConfigFor(OverrideMarkdown)
+	}
+
+	for _, o := range r.Overrides {
+		if !MatchPath(o.Pattern, filename) {
+			continue
+		}
+		if o.Type != "" {
+			config = fileTypeConfigFor(o.Type)
+		}
+		if o.MaxLineLength > 0 {
+			config.MaxLineLength = o.MaxLineLength
+		}
+		if o.ForbiddenCharacters != nil {
+			config.ForbiddenCharacters = make(map[rune]bool)
+			for _, char := range *o.ForbiddenCharacters {
+				config.ForbiddenCharacters[char] = true
+			}
+		}
+		if o.AllowApostrophes != nil {
+			config.AllowApostrophes = *o.AllowApostrophes
+		}
+		if o.Scripts != nil {
+			config.AllowedScripts = o.Scripts
+		}
+	}
+
+	return config
+}
+
+// fileTypeConfigFor returns the default configuration for an override type.
+func fileTypeConfigFor(fileType string) FileTypeConfig {
+	switch fileType {
+	case OverrideMarkdown:
+		return GetFileTypeConfig("README.md")
+	case OverrideConfig:
+		return GetFileTypeConfig("config.yaml")
+	case OverrideData:
+		return FileTypeConfig{
+			IsCode:              false,
+			IsConfig:            false,
+			IsMarkdown:          false,
+			AllowApostrophes:    true, // Translations are prose
+			MaxLineLength:       500,
+			ForbiddenCharacters: getMinimalControlChars(),
+		}
+	default:
+		return GetFileTypeConfig("unknown")
+	}
+}
+
+// MatchPath reports whether a slash-separated path matches a gitignore-style pattern.
+// A pattern without a slash matches a file or directory name at any depth, a
+// leading slash anchors the pattern at the repository root, "**" matches any
+// number of directories, and a pattern matching a directory matches everything in it.
+func MatchPath(pattern, name string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	patternSegs := strings.Split(pattern, "/")
+	nameSegs := strings.Split(strings.TrimPrefix(path.Clean("/"+name), "/"), "/")
+
+	if !dirOnly && matchSegments(patternSegs, nameSegs) {
+		return true
+	}
+	for i := 1; i < len(nameSegs); i++ {
+		if matchSegments(patternSegs, nameSegs[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where "**" matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// ValidatePattern checks that a pattern is non-empty and syntactically valid.
+func ValidatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	for _, seg := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
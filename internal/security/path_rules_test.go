@@ -0,0 +1,143 @@
+package security
+
+import (
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"docs/**", "docs/guide.md", true},
+		{"docs/**", "docs/api/v1/index.md", true},
+		{"docs/**", "src/docs/guide.md", false},
+		{"**/testdata/**", "testdata/golden.json", true},
+		{"**/testdata/**", "internal/foo/testdata/golden.json", true},
+		{"**/testdata/**", "internal/testdata.go", false},
+		{"i18n/*.json", "i18n/en.json", true},
+		{"i18n/*.json", "i18n/fr/fr.json", false},
+		{"i18n/*.json", "web/i18n/en.json", false},
+		{"*.sh", "setup.sh", true},
+		{"*.sh", "scripts/deploy/run.sh", true},
+		{"*script*", "scripts/build.py", true},
+		{"*script*", "docs/scripts.md", true},
+		{"*script*", "docs/guide.md", false},
+		{".circleci", ".circleci/config.yml", true},
+		{"/vendor", "vendor/mod/a.go", true},
+		{"/vendor", "third_party/vendor/a.go", false},
+		{"build/", "build/out.txt", true},
+		{"build/", "build", false},
+		{"**/.github/workflows/**", ".github/workflows/ci.yml", true},
+		{"**/.github/workflows/**", ".github/dependabot.yml", false},
+		{"", "anything", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPathRulesFileTypeConfig(t *testing.T) {
+	forbidden := "`"
+	rules := &PathRules{
+		Deny:  []string{"**/testdata/**"},
+		Allow: []string{"docs/**"},
+		Overrides: []PathOverride{
+			{Pattern: "i18n/*.json", Type: OverrideData},
+			{Pattern: "i18n/legacy.json", MaxLineLength: 2000, ForbiddenCharacters: &forbidden},
+		},
+	}
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if _, denied := rules.Denied("pkg/testdata/input.go"); !denied {
+		t.Error("testdata should be denied")
+	}
+
+	docs := rules.FileTypeConfig("docs/setup.yaml")
+	if !docs.IsMarkdown || docs.IsConfig {
+		t.Errorf("allowed path should be treated as docs: %+v", docs)
+	}
+
+	data := rules.FileTypeConfig("i18n/de.json")
+	if data.IsCode || data.IsConfig || data.IsMarkdown {
+		t.Errorf("i18n should be treated as data: %+v", data)
+	}
+	if data.ForbiddenCharacters['"'] {
+		t.Error("quotes should be allowed in translation data")
+	}
+
+	legacy := rules.FileTypeConfig("i18n/legacy.json")
+	if legacy.MaxLineLength != 2000 || len(legacy.ForbiddenCharacters) != 1 {
+		t.Errorf("override not applied: MaxLineLength=%d forbidden=%v", legacy.MaxLineLength, legacy.ForbiddenCharacters)
+	}
+
+	if got := rules.FileTypeConfig("main.go"); !got.IsCode || got.MaxLineLength != GetFileTypeConfig("main.go").MaxLineLength {
+		t.Errorf("unmatched file should keep defaults: %+v", got)
+	}
+
+	var none *PathRules
+	if none.FileTypeConfig("main.go").MaxLineLength != GetFileTypeConfig("main.go").MaxLineLength {
+		t.Error("nil rules should use defaults")
+	}
+}
+
+func TestPathRulesProtected(t *testing.T) {
+	rules := &PathRules{Allow: []string{"docs/**"}}
+
+	tests := []struct {
+		name   string
+		isNil  bool
+		reason string
+	}{
+		{name: "deploy.sh", reason: "Shell script modifications require manual review"},
+		{name: "docs/deploy.sh", reason: "Shell script modifications require manual review"},
+		{name: "scripts/build.py", reason: "Shell script modifications require manual review"},
+		{name: "docs/scripts.md", isNil: true},
+		{name: ".github/workflows/ci.yml", reason: "GitHub Actions workflow changes require manual review"},
+		{name: ".circleci/config.yml", reason: "CI/CD configuration changes require manual review"},
+		{name: "README.md", isNil: true},
+	}
+
+	for _, tt := range tests {
+		got := rules.Protected(tt.name)
+		if tt.isNil {
+			if got != nil {
+				t.Errorf("Protected(%q) = %q, want nil", tt.name, got.Reason)
+			}
+			continue
+		}
+		if got == nil || got.Reason != tt.reason {
+			t.Errorf("Protected(%q) = %v, want %q", tt.name, got, tt.reason)
+		}
+	}
+}
+
+func TestPathRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   *PathRules
+		wantErr bool
+	}{
+		{name: "nil", rules: nil},
+		{name: "valid", rules: &PathRules{Deny: []string{"**/testdata/**"}}},
+		{name: "empty pattern", rules: &PathRules{Allow: []string{""}}, wantErr: true},
+		{name: "bad pattern", rules: &PathRules{Deny: []string{"docs/[a"}}, wantErr: true},
+		{name: "unknown type", rules: &PathRules{Overrides: []PathOverride{{Pattern: "*.txt", Type: "prose"}}}, wantErr: true},
+		{name: "known scripts", rules: &PathRules{Overrides: []PathOverride{{Pattern: "docs/ja/**", Scripts: []string{"Han", "Hiragana", "Katakana"}}}}},
+		{name: "unknown script", rules: &PathRules{Overrides: []PathOverride{{Pattern: "docs/ja/**", Scripts: []string{"Japanese"}}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// ErrSecret is returned by ValidatePatch when an added line contains a credential.
+var ErrSecret = errors.New("possible secret detected")
+
+// SecretRule detects one kind of credential.
+type SecretRule struct {
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+
+	// Group is the submatch holding the secret itself, or 0 for the whole match.
+	Group int
+
+	// MinEntropy is the Shannon entropy, in bits per character, below which a match
+	// is taken to be a placeholder (e.g. "your-secret-here"). Zero disables the check.
+	MinEntropy float64
+}
+
+// SecretRules are the built-in credential detectors.
+var SecretRules = []SecretRule{
+	{
+		ID:          "aws-access-key-id",
+		Description: "AWS access key ID",
+		Pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+		MinEntropy:  3,
+	},
+	{
+		ID:          "aws-secret-access-key",
+		Description: "AWS secret access key",
+		Pattern:     regexp.MustCompile(`(?i)aws[\w.-]{0,20}(?:secret|private)[\w.-]{0,20}["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`),
+		Group:       1,
+		MinEntropy:  4,
+	},
+	{
+		ID:          "gcp-api-key",
+		Description: "Google Cloud API key",
+		Pattern:     regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		ID:          "gcp-service-account",
+		Description: "Google Cloud service account key",
+		Pattern:     regexp.MustCompile(`"private_key_id"\s*:\s*"([0-9a-f]{40})"`),
+		Group:       1,
+	},
+	{
+		ID:          "github-token",
+		Description: "GitHub token",
+		Pattern:     regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{82})\b`),
+	},
+	{
+		ID:          "slack-token",
+		Description: "Slack token",
+		Pattern:     regexp.MustCompile(`\bxox[baprse]-[0-9A-Za-z-]{10,}`),
+	},
+	{
+		ID:          "slack-webhook",
+		Description: "Slack webhook URL",
+		Pattern:     regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9/_-]{20,}`),
+	},
+	{
+		ID:          "private-key",
+		Description: "private key",
+		Pattern:     regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		ID:          "jwt",
+		Description: "JSON Web Token",
+		Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	},
+	{
+		ID:          "generic-secret",
+		Description: "high-entropy secret assignment",
+		Pattern:     regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|credential)[\w.-]*["']?\s*[:=]\s*["']([^"'\s]{16,})["']`),
+		Group:       1,
+		MinEntropy:  3.5,
+	},
+}
+
+// SecretFinding is a credential found on an added patch line.
+type SecretFinding struct {
+	Rule      string
+	PatchLine int // 1-based line within the patch
+	Line      int // 1-based line in the new file, or 0 if the hunk header was missing
+	Redacted  string
+}
+
+func (f SecretFinding) Error() string {
+	return fmt.Sprintf("%v: %s (%s)", ErrSecret, f.Rule, f.Redacted)
+}
+
+func (f SecretFinding) Unwrap() error {
+	return ErrSecret
+}
+
+// secretMatch is the location of a secret within a line.
+type secretMatch struct {
+	rule       *SecretRule
+	start, end int
+}
+
+// findSecrets returns the secrets in text, in rule order.
+func findSecrets(text string) []secretMatch {
+	var matches []secretMatch
+	for i := range SecretRules {
+		rule := &SecretRules[i]
+		for _, m := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*rule.Group], m[2*rule.Group+1]
+			if start < 0 {
+				continue
+			}
+			if rule.MinEntropy > 0 && shannonEntropy(text[start:end]) < rule.MinEntropy {
+				continue
+			}
+			matches = append(matches, secretMatch{rule: rule, start: start, end: end})
+		}
+	}
+	return matches
+}
+
+// ScanSecrets returns the credentials on the added lines of a patch.
+func ScanSecrets(patch string) []SecretFinding {
+	var findings []SecretFinding
+	addedLines(patch, func(patchLine, line int, content string) {
+		for _, m := range findSecrets(content) {
+			findings = append(findings, SecretFinding{
+				Rule:      m.rule.ID,
+				PatchLine: patchLine,
+				Line:      line,
+				Redacted:  redact(content[m.start:m.end]),
+			})
+		}
+	})
+	return findings
+}
+
+// RedactSecrets replaces every credential in text with a redacted form, so it can be
+// logged or sent to a model. It also returns the number of secrets redacted.
+func RedactSecrets(text string) (string, int) {
+	matches := findSecrets(text)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	// Mark the redacted bytes, since matches of different rules may overlap
+	covered := make([]bool, len(text))
+	for _, m := range matches {
+		for i := m.start; i < m.end; i++ {
+			covered[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if !covered[i] {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && covered[j] {
+			j++
+		}
+		b.WriteString(redact(text[i:j]))
+		i = j
+	}
+	return b.String(), len(matches)
+}
+
+// redact keeps the first four characters of a secret, which are usually a
+// well-known prefix such as "AKIA" or "ghp_", and hides the rest.
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return "[REDACTED]"
+	}
+	return secret[:4] + "[REDACTED]"
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
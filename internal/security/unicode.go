@@ -0,0 +1,222 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ErrUnicode is returned by ValidatePatch when an added line contains bidirectional
+// controls, invisible characters or confusable words (Trojan Source, homoglyph attacks).
+var ErrUnicode = errors.New("suspicious Unicode")
+
+// bidiControls are the embedding, override and isolate controls that can reorder how
+// source code is displayed.
+var bidiControls = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x202a, Hi: 0x202e, Stride: 1},
+		{Lo: 0x2066, Hi: 0x2069, Stride: 1},
+	},
+}
+
+// invisibleChars render as nothing, or as blank space that isn't whitespace.
+var invisibleChars = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00ad, Hi: 0x00ad, Stride: 1}, // Soft hyphen
+		{Lo: 0x034f, Hi: 0x034f, Stride: 1}, // Combining grapheme joiner
+		{Lo: 0x061c, Hi: 0x061c, Stride: 1}, // Arabic letter mark
+		{Lo: 0x115f, Hi: 0x1160, Stride: 1}, // Hangul fillers
+		{Lo: 0x17b4, Hi: 0x17b5, Stride: 1}, // Khmer inherent vowels
+		{Lo: 0x180b, Hi: 0x180f, Stride: 1}, // Mongolian variation selectors and vowel separator
+		{Lo: 0x200b, Hi: 0x200f, Stride: 1}, // Zero-width space, joiners and directional marks
+		{Lo: 0x2060, Hi: 0x2064, Stride: 1}, // Word joiner and invisible operators
+		{Lo: 0x206a, Hi: 0x206f, Stride: 1}, // Deprecated format characters
+		{Lo: 0x3164, Hi: 0x3164, Stride: 1}, // Hangul filler
+		{Lo: 0xfe00, Hi: 0xfe0f, Stride: 1}, // Variation selectors
+		{Lo: 0xfeff, Hi: 0xfeff, Stride: 1}, // Zero-width no-break space
+		{Lo: 0xffa0, Hi: 0xffa0, Stride: 1}, // Halfwidth Hangul filler
+		{Lo: 0xfff9, Hi: 0xfffb, Stride: 1}, // Interlinear annotations
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1d173, Hi: 0x1d17a, Stride: 1}, // Musical formatting
+		{Lo: 0xe0000, Hi: 0xe007f, Stride: 1}, // Tags, which can smuggle hidden text to a model
+		{Lo: 0xe0100, Hi: 0xe01ef, Stride: 1}, // Variation selectors supplement
+	},
+}
+
+// proseInvisibleChars are invisible characters needed by some scripts and emoji, allowed
+// outside code and configuration.
+var proseInvisibleChars = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200c, Hi: 0x200d, Stride: 1}, // Zero-width non-joiner and joiner
+		{Lo: 0xfe00, Hi: 0xfe0f, Stride: 1}, // Variation selectors, e.g. emoji presentation
+	},
+	R32: []unicode.Range32{
+		{Lo: 0xe0100, Hi: 0xe01ef, Stride: 1}, // Ideographic variation selectors
+	},
+}
+
+// highlyRestrictive are the script combinations that Unicode TR39 allows within a single
+// identifier at the Highly Restrictive level. Any other mix is suspicious.
+var highlyRestrictive = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// confusables maps letters that are visually confusable with Latin letters to their
+// Latin prototype, following the Unicode TR39 confusables data.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'о': 'o', 'р': 'p', 'ѕ': 's',
+	'у': 'y', 'х': 'x', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l',
+	'А': 'A', 'В': 'B', 'С': 'C', 'Е': 'E', 'Н': 'H', 'І': 'I', 'Ј': 'J', 'К': 'K', 'М': 'M',
+	'О': 'O', 'Р': 'P', 'Ѕ': 'S', 'Т': 'T', 'Х': 'X', 'У': 'Y', 'Ԛ': 'Q', 'Ԝ': 'W',
+	// Greek
+	'α': 'a', 'ι': 'i', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M', 'Ν': 'N',
+	'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+	// Armenian
+	'ո': 'n', 'ս': 'u', 'օ': 'o', 'ց': 'g', 'հ': 'h', 'Ս': 'U', 'Օ': 'O',
+}
+
+// CheckUnicode checks an added line for bidirectional controls, invisible characters and
+// words mixing scripts that Unicode TR39 considers confusable. Words must use Latin or one
+// of config.AllowedScripts; code and configuration also reject words written entirely
+// in Latin lookalikes.
+func CheckUnicode(line string, config FileTypeConfig) error {
+	strict := config.IsCode || config.IsConfig
+	for _, r := range line {
+		if unicode.Is(bidiControls, r) {
+			return fmt.Errorf("%w: bidirectional control character %U", ErrUnicode, r)
+		}
+		if unicode.Is(invisibleChars, r) && (strict || !unicode.Is(proseInvisibleChars, r)) {
+			return fmt.Errorf("%w: invisible character %U", ErrUnicode, r)
+		}
+	}
+
+	for _, word := range strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != '_'
+	}) {
+		if err := checkWord(word, config.AllowedScripts, strict); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWord checks the scripts of a single identifier or word.
+func checkWord(word string, allowed []string, strict bool) error {
+	scripts := wordScripts(word)
+	if len(scripts) == 0 || (len(scripts) == 1 && scripts[0] == "Latin") {
+		return nil
+	}
+
+	if len(scripts) > 1 && !slices.ContainsFunc(highlyRestrictive, func(set []string) bool {
+		return isSubset(scripts, set)
+	}) {
+		msg := fmt.Sprintf("%q mixes %s scripts", word, strings.Join(scripts, " and "))
+		if skeleton := latinSkeleton(word); skeleton != word && isASCII(skeleton) {
+			msg += fmt.Sprintf(" (confusable with %q)", skeleton)
+		}
+		return fmt.Errorf("%w: %s", ErrUnicode, msg)
+	}
+
+	for _, s := range scripts {
+		if s != "Latin" && !slices.Contains(allowed, s) {
+			return fmt.Errorf("%w: %q uses the %s script, which is not allowed in this file", ErrUnicode, word, s)
+		}
+	}
+
+	if skeleton := latinSkeleton(word); strict && isASCII(skeleton) {
+		return fmt.Errorf("%w: %q is confusable with %q", ErrUnicode, word, skeleton)
+	}
+	return nil
+}
+
+// wordScripts returns the scripts used by a word in order of appearance, ignoring the
+// Common and Inherited scripts shared by all of them.
+func wordScripts(word string) []string {
+	var scripts []string
+	for _, r := range word {
+		if s := scriptOf(r); s != "Common" && s != "Inherited" && !slices.Contains(scripts, s) {
+			scripts = append(scripts, s)
+		}
+	}
+	return scripts
+}
+
+// scriptOf returns the Unicode script of a rune, or "Unknown" if it has none.
+func scriptOf(r rune) string {
+	if r < 0x80 {
+		if unicode.IsLetter(r) {
+			return "Latin"
+		}
+		return "Common"
+	}
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return "Unknown"
+}
+
+// latinSkeleton replaces the letters of word that are confusable with Latin letters.
+func latinSkeleton(word string) string {
+	return strings.Map(func(r rune) rune {
+		if latin, ok := confusables[r]; ok {
+			return latin
+		}
+		return r
+	}, word)
+}
+
+// isSubset reports whether every element of a is in b.
+func isSubset(a, b []string) bool {
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// UnicodeFinding is a suspicious character or word on an added patch line.
+type UnicodeFinding struct {
+	PatchLine int // 1-based line within the patch
+	Line      int // 1-based line in the new file, or 0 if the hunk header was missing
+	Err       error
+}
+
+func (f UnicodeFinding) Error() string {
+	return f.Err.Error()
+}
+
+func (f UnicodeFinding) Unwrap() error {
+	return f.Err
+}
+
+// ScanUnicode returns the suspicious Unicode on the added lines of a patch, applying the
+// file's allowed scripts.
+func (v *CodeValidator) ScanUnicode(patch, filename string) []UnicodeFinding {
+	config := v.FileTypeConfig(filename)
+	var findings []UnicodeFinding
+	addedLines(patch, func(patchLine, line int, content string) {
+		if err := CheckUnicode(content, config); err != nil {
+			findings = append(findings, UnicodeFinding{PatchLine: patchLine, Line: line, Err: err})
+		}
+	})
+	return findings
+}
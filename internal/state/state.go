@@ -0,0 +1,132 @@
+// Package state persists what was decided for each pull request across runs,
+// so that polling skips PRs that have not changed since they were analyzed.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAge is how long a PR is remembered after its last analysis; closed PRs are
+// never listed again, so their entries expire.
+const maxAge = 30 * 24 * time.Hour
+
+// PR is the last analysis of a pull request.
+type PR struct {
+	HeadSHA    string    `json:"head_sha"`
+	AnalyzedAt time.Time `json:"analyzed_at"`
+
+	Approvable bool   `json:"approvable"`
+	Final      bool   `json:"final"` // The decision holds until the PR changes
+	Reason     string `json:"reason,omitempty"`
+
+	// Category and Flags come from the AI analysis, if it ran.
+	Category string   `json:"category,omitempty"`
+	Flags    []string `json:"flags,omitempty"`
+
+	// ApprovedSHA is the head commit of our approval, if the PR has one.
+	ApprovedSHA string `json:"approved_sha,omitempty"`
+}
+
+// Store keeps the last analysis of each PR in a JSON file. It is safe for concurrent
+// use. A nil Store remembers nothing.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	prs  map[string]PR
+}
+
+// Load loads the store at path. A missing file starts empty.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, prs: make(map[string]PR)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading PR state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.prs); err != nil {
+		return nil, fmt.Errorf("parsing PR state %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// key identifies a PR; GitHub owner and repository names are case-insensitive.
+func key(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", strings.ToLower(owner), strings.ToLower(repo), number)
+}
+
+// Get returns the last analysis of a PR.
+func (s *Store) Get(owner, repo string, number int) (PR, bool) {
+	if s == nil {
+		return PR{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr, ok := s.prs[key(owner, repo, number)]
+	return pr, ok
+}
+
+// Unchanged reports whether a PR with the given head and last update time can keep its
+// last decision: the decision was final, the head is the one analyzed and nothing (a
+// review, a comment, an edit) has updated the PR since.
+func (s *Store) Unchanged(owner, repo string, number int, headSHA string, updatedAt time.Time) bool {
+	pr, ok := s.Get(owner, repo, number)
+	return ok && pr.Final && headSHA != "" && pr.HeadSHA == headSHA && !updatedAt.After(pr.AnalyzedAt)
+}
+
+// Put records the analysis of a PR and saves the store. A save failure is logged, not
+// returned: the PR is only analyzed again.
+func (s *Store) Put(owner, repo string, number int, pr PR) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prs[key(owner, repo, number)] = pr
+	for k, p := range s.prs {
+		if time.Since(p.AnalyzedAt) > maxAge {
+			delete(s.prs, k)
+		}
+	}
+
+	if err := s.save(); err != nil {
+		log.Printf("[STATE] Warning: failed to save PR state: %v", err)
+	}
+}
+
+// save writes the store atomically, so a crash never leaves a truncated file.
+func (s *Store) save() error {
+	data, err := json.Marshal(s.prs)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "state.json")
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() on missing file error = %v", err)
+	}
+
+	analyzed := time.Now()
+	s.Put("Owner", "Repo", 1, PR{HeadSHA: "abc", AnalyzedAt: analyzed, Final: true, ApprovedSHA: "abc"})
+	s.Put("owner", "repo", 2, PR{HeadSHA: "def", AnalyzedAt: analyzed})
+	s.Put("owner", "repo", 3, PR{HeadSHA: "old", AnalyzedAt: analyzed.Add(-2 * maxAge), Final: true})
+
+	// A new run sees the saved state
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if pr, ok := s.Get("owner", "repo", 1); !ok || pr.ApprovedSHA != "abc" {
+		t.Errorf("Get() = %+v, %v, want the saved PR", pr, ok)
+	}
+	if _, ok := s.Get("owner", "repo", 3); ok {
+		t.Error("Get() returned an expired PR")
+	}
+
+	tests := []struct {
+		name      string
+		number    int
+		headSHA   string
+		updatedAt time.Time
+		want      bool
+	}{
+		{"unchanged", 1, "abc", analyzed.Add(-time.Minute), true},
+		{"new commits", 1, "xyz", analyzed.Add(-time.Minute), false},
+		{"updated since", 1, "abc", analyzed.Add(time.Minute), false},
+		{"decision not final", 2, "def", analyzed.Add(-time.Minute), false},
+		{"never analyzed", 4, "abc", analyzed.Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Unchanged("owner", "repo", tt.number, tt.headSHA, tt.updatedAt); got != tt.want {
+				t.Errorf("Unchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	s.Put("owner", "repo", 1, PR{HeadSHA: "abc", AnalyzedAt: time.Now(), Final: true})
+	if s.Unchanged("owner", "repo", 1, "abc", time.Now().Add(-time.Hour)) {
+		t.Error("a nil store should remember nothing")
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() should fail on a corrupt file")
+	}
+}
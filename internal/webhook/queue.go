@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Queue buffers events for asynchronous processing.
+// Events for a PR that is already waiting are coalesced, so a burst of
+// deliveries (e.g. several check suites completing) triggers one analysis.
+// A PR is processed by one worker at a time: events arriving while it is
+// processed are held, coalesced, and queued once the worker is done.
+type Queue struct {
+	mu       sync.Mutex
+	pending  map[string]Event
+	inFlight map[string]bool
+	held     map[string]Event // Events for PRs in flight
+	keys     chan string
+}
+
+// NewQueue creates a queue holding at most size distinct PRs.
+func NewQueue(size int) *Queue {
+	if size < 1 {
+		size = 1
+	}
+	return &Queue{
+		pending:  make(map[string]Event),
+		inFlight: make(map[string]bool),
+		held:     make(map[string]Event),
+		keys:     make(chan string, size),
+	}
+}
+
+// Push adds an event to the queue. It never blocks; if the queue is full the
+// event is dropped and false is returned.
+func (q *Queue) Push(ev Event) bool {
+	key := ev.Key()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[key]; ok {
+		q.pending[key] = ev
+		return true
+	}
+	if q.inFlight[key] {
+		q.held[key] = ev
+		return true
+	}
+	return q.enqueue(key, ev)
+}
+
+// enqueue queues an event for a PR that is neither waiting nor in flight.
+// The caller must hold q.mu.
+func (q *Queue) enqueue(key string, ev Event) bool {
+	select {
+	case q.keys <- key:
+		q.pending[key] = ev
+		return true
+	default:
+		log.Printf("[WEBHOOK] Queue full, dropping %s for %s", ev.Kind, key)
+		return false
+	}
+}
+
+// start takes the waiting event of a PR and marks the PR in flight.
+func (q *Queue) start(key string) Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ev := q.pending[key]
+	delete(q.pending, key)
+	q.inFlight[key] = true
+	return ev
+}
+
+// done marks a PR no longer in flight, queueing any event held meanwhile.
+func (q *Queue) done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inFlight, key)
+	if ev, ok := q.held[key]; ok {
+		delete(q.held, key)
+		q.enqueue(key, ev)
+	}
+}
+
+// Defer pushes an event again after delay, e.g. to analyze a PR again once its required
+// checks had time to complete. It returns false, dropping the event, if the event was
+// already deferred maxDeferrals times.
+func (q *Queue) Defer(ev Event, delay time.Duration, maxDeferrals int) bool {
+	if ev.Deferrals >= maxDeferrals {
+		log.Printf("[WEBHOOK] Giving up on %s for %s after %d deferrals", ev.Kind, ev.Key(), ev.Deferrals)
+		return false
+	}
+	ev.Deferrals++
+	time.AfterFunc(delay, func() { q.Push(ev) })
+	return true
+}
+
+// Len returns the number of events waiting to be processed, including those held
+// until their PR's current processing is done.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) + len(q.held)
+}
+
+// Run processes events with the given number of workers until ctx is done.
+// Errors returned by fn are logged; the event is not retried.
+func (q *Queue) Run(ctx context.Context, workers int, fn func(context.Context, Event) error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case key := <-q.keys:
+					ev := q.start(key)
+					if err := fn(ctx, ev); err != nil {
+						log.Printf("[WEBHOOK] Failed to process %s for %s: %v", ev.Kind, key, err)
+					}
+					q.done(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
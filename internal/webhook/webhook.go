@@ -0,0 +1,197 @@
+// Package webhook receives GitHub webhook deliveries and turns them into
+// pull request analysis requests.
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v68/github"
+	"github.com/thegroove/trivial-auto-approve/internal/constants"
+)
+
+// maxPayloadSize limits the size of a webhook delivery (GitHub caps payloads at 25 MB).
+const maxPayloadSize = 25 << 20
+
+// handledEvents lists the webhook event types that can change an approval decision.
+var handledEvents = map[string]bool{
+	"pull_request":                true,
+	"check_suite":                 true,
+	"status":                      true,
+	"pull_request_review":         true,
+	"pull_request_review_comment": true,
+	"issue_comment":               true,
+}
+
+// Event identifies a pull request that should be (re)analyzed.
+type Event struct {
+	// Kind is the webhook event and action, e.g. "pull_request.synchronize".
+	Kind string
+
+	// InstallationID is the GitHub App installation that sent the event, or 0.
+	InstallationID int64
+
+	Owner string
+	Repo  string
+
+	// Number is the PR number. It is 0 when the event only identifies a
+	// commit (status events), in which case HeadSHA is set instead. Such
+	// events are pushed again for each PR once its number is known, so that
+	// a PR is only ever processed under its own key.
+	Number  int
+	HeadSHA string
+
+	// Deferrals counts how many times the event was queued again while waiting for
+	// required checks.
+	Deferrals int
+}
+
+// Key returns a string identifying the PR (or commit) the event refers to.
+func (e Event) Key() string {
+	if e.Number > 0 {
+		return fmt.Sprintf("%s/%s#%d", e.Owner, e.Repo, e.Number)
+	}
+	return fmt.Sprintf("%s/%s@%s", e.Owner, e.Repo, e.HeadSHA)
+}
+
+// Handler is an http.Handler that verifies and decodes GitHub webhook deliveries.
+type Handler struct {
+	secret  []byte
+	enqueue func(Event)
+}
+
+// NewHandler creates a handler that passes decoded events to enqueue.
+// Every delivery must be signed with secret.
+func NewHandler(secret string, enqueue func(Event)) (*Handler, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("webhook secret is required")
+	}
+	if enqueue == nil {
+		return nil, fmt.Errorf("enqueue function is required")
+	}
+	return &Handler{secret: []byte(secret), enqueue: enqueue}, nil
+}
+
+// ServeHTTP handles a single webhook delivery.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	signature := r.Header.Get(github.SHA256SignatureHeader)
+	if signature == "" {
+		log.Printf("[WEBHOOK] Rejected delivery %s: missing %s", github.DeliveryID(r), github.SHA256SignatureHeader)
+		http.Error(w, "missing signature", http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := github.ValidatePayloadFromBody(r.Header.Get("Content-Type"), bytes.NewReader(body), signature, h.secret)
+	if err != nil {
+		log.Printf("[WEBHOOK] Rejected delivery %s: %v", github.DeliveryID(r), err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+	events, err := Decode(eventType, payload)
+	if err != nil {
+		log.Printf("[WEBHOOK] Failed to decode %s delivery %s: %v", eventType, github.DeliveryID(r), err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	for _, ev := range events {
+		log.Printf("[WEBHOOK] %s for %s", ev.Kind, ev.Key())
+		h.enqueue(ev)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Decode parses a verified webhook payload and returns the PRs it affects.
+// Events that cannot change an approval decision return no events.
+func Decode(eventType string, payload []byte) ([]Event, error) {
+	// Event types we have no use for (including ping) are acknowledged and ignored
+	if !handledEvents[eventType] {
+		return nil, nil
+	}
+
+	raw, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e := raw.(type) {
+	case *github.PullRequestEvent:
+		switch e.GetAction() {
+		case "opened", "reopened", "synchronize", "ready_for_review":
+			return prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), e.GetNumber()), nil
+		case "edited":
+			// A new base branch brings its own policy, CODEOWNERS and required checks
+			if e.GetChanges().GetBase() != nil {
+				return prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), e.GetNumber()), nil
+			}
+		}
+
+	case *github.CheckSuiteEvent:
+		if e.GetAction() != "completed" {
+			return nil, nil
+		}
+		var events []Event
+		for _, pr := range e.GetCheckSuite().PullRequests {
+			events = append(events, prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), pr.GetNumber())...)
+		}
+		return events, nil
+
+	case *github.StatusEvent:
+		// Pending statuses cannot make a PR approvable
+		if e.GetState() == constants.CheckStatePending || e.GetSHA() == "" {
+			return nil, nil
+		}
+		return []Event{{
+			Kind:           eventType + "." + e.GetState(),
+			InstallationID: e.GetInstallation().GetID(),
+			Owner:          e.GetRepo().GetOwner().GetLogin(),
+			Repo:           e.GetRepo().GetName(),
+			HeadSHA:        e.GetSHA(),
+		}}, nil
+
+	case *github.PullRequestReviewEvent:
+		return prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), e.GetPullRequest().GetNumber()), nil
+
+	case *github.PullRequestReviewCommentEvent:
+		return prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), e.GetPullRequest().GetNumber()), nil
+
+	case *github.IssueCommentEvent:
+		if !e.GetIssue().IsPullRequest() {
+			return nil, nil
+		}
+		return prEvent(eventType, e.GetAction(), e.GetInstallation(), e.GetRepo(), e.GetIssue().GetNumber()), nil
+	}
+
+	return nil, nil
+}
+
+// prEvent builds the event for a PR, returning nothing if the payload is incomplete.
+func prEvent(eventType, action string, inst *github.Installation, repo *github.Repository, number int) []Event {
+	if number <= 0 || repo.GetOwner().GetLogin() == "" || repo.GetName() == "" {
+		return nil
+	}
+	return []Event{{
+		Kind:           eventType + "." + action,
+		InstallationID: inst.GetID(),
+		Owner:          repo.GetOwner().GetLogin(),
+		Repo:           repo.GetName(),
+		Number:         number,
+	}}
+}
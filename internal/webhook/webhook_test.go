@@ -0,0 +1,325 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+// sign computes the X-Hub-Signature-256 header value for a payload.
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// replay sends a recorded payload from testdata to the handler.
+func replay(t *testing.T, h http.Handler, eventType, file, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatalf("reading %s: %v", file, err)
+	}
+	if signature == "" {
+		signature = sign(testSecret, payload)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", "test-delivery")
+	req.Header.Set("X-Hub-Signature-256", signature)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerReplay(t *testing.T) {
+	pr42 := Event{InstallationID: 1001, Owner: "acme", Repo: "widgets", Number: 42}
+	withKind := func(ev Event, kind string) Event {
+		ev.Kind = kind
+		return ev
+	}
+
+	tests := []struct {
+		name      string
+		eventType string
+		file      string
+		want      []Event
+	}{
+		{
+			name:      "pull request synchronize",
+			eventType: "pull_request",
+			file:      "pull_request_synchronize.json",
+			want:      []Event{withKind(pr42, "pull_request.synchronize")},
+		},
+		{
+			name:      "pull request closed is ignored",
+			eventType: "pull_request",
+			file:      "pull_request_closed.json",
+		},
+		{
+			name:      "pull request base changed",
+			eventType: "pull_request",
+			file:      "pull_request_edited_base.json",
+			want:      []Event{withKind(pr42, "pull_request.edited")},
+		},
+		{
+			name:      "pull request title edit is ignored",
+			eventType: "pull_request",
+			file:      "pull_request_edited_title.json",
+		},
+		{
+			name:      "check suite completed for two PRs",
+			eventType: "check_suite",
+			file:      "check_suite_completed.json",
+			want: []Event{
+				withKind(pr42, "check_suite.completed"),
+				{Kind: "check_suite.completed", InstallationID: 1001, Owner: "acme", Repo: "widgets", Number: 43},
+			},
+		},
+		{
+			name:      "status success",
+			eventType: "status",
+			file:      "status_success.json",
+			want: []Event{{
+				Kind:           "status.success",
+				InstallationID: 1001,
+				Owner:          "acme",
+				Repo:           "widgets",
+				HeadSHA:        "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+			}},
+		},
+		{
+			name:      "status pending is ignored",
+			eventType: "status",
+			file:      "status_pending.json",
+		},
+		{
+			name:      "review submitted",
+			eventType: "pull_request_review",
+			file:      "pull_request_review_submitted.json",
+			want:      []Event{withKind(pr42, "pull_request_review.submitted")},
+		},
+		{
+			name:      "comment on PR",
+			eventType: "issue_comment",
+			file:      "issue_comment_pr.json",
+			want:      []Event{withKind(pr42, "issue_comment.created")},
+		},
+		{
+			name:      "comment on issue is ignored",
+			eventType: "issue_comment",
+			file:      "issue_comment_issue.json",
+		},
+		{
+			name:      "unhandled event type is ignored",
+			eventType: "ping",
+			file:      "pull_request_synchronize.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Event
+			h, err := NewHandler(testSecret, func(ev Event) { got = append(got, ev) })
+			if err != nil {
+				t.Fatalf("NewHandler() error = %v", err)
+			}
+
+			rec := replay(t, h, tt.eventType, tt.file, "")
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsBadSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+	}{
+		{name: "wrong secret", signature: sign("wrong", []byte("{}"))},
+		{name: "malformed", signature: "sha256=zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h, err := NewHandler(testSecret, func(Event) { called = true })
+			if err != nil {
+				t.Fatalf("NewHandler() error = %v", err)
+			}
+
+			rec := replay(t, h, "pull_request", "pull_request_synchronize.json", tt.signature)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+			if called {
+				t.Error("enqueue should not be called for an unverified delivery")
+			}
+		})
+	}
+
+	h, err := NewHandler(testSecret, func(Event) {})
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader([]byte("{}")))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned delivery status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	if _, err := NewHandler("", func(Event) {}); err == nil {
+		t.Error("expected error for empty secret")
+	}
+}
+
+func TestQueueCoalesces(t *testing.T) {
+	q := NewQueue(2)
+
+	first := Event{Kind: "pull_request.synchronize", Owner: "acme", Repo: "widgets", Number: 42}
+	second := Event{Kind: "check_suite.completed", Owner: "acme", Repo: "widgets", Number: 42}
+	other := Event{Kind: "pull_request.opened", Owner: "acme", Repo: "widgets", Number: 43}
+	overflow := Event{Kind: "pull_request.opened", Owner: "acme", Repo: "widgets", Number: 44}
+
+	if !q.Push(first) || !q.Push(second) || !q.Push(other) {
+		t.Fatal("Push() should accept events within capacity")
+	}
+	if q.Push(overflow) {
+		t.Error("Push() should drop events when the queue is full")
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", q.Len())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var got []Event
+	go q.Run(ctx, 1, func(_ context.Context, ev Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, ev)
+		if len(got) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	<-ctx.Done()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []Event{second, other}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("processed = %+v, want %+v", got, want)
+	}
+}
+
+func TestQueueOneWorkerPerPR(t *testing.T) {
+	q := NewQueue(4)
+
+	first := Event{Kind: "pull_request.synchronize", Owner: "acme", Repo: "widgets", Number: 42}
+	second := Event{Kind: "pull_request.synchronize", Owner: "acme", Repo: "widgets", Number: 42, Deferrals: 1}
+	third := Event{Kind: "check_suite.completed", Owner: "acme", Repo: "widgets", Number: 42}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started := make(chan Event)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var active, maxActive int
+	var got []Event
+	go q.Run(ctx, 2, func(_ context.Context, ev Event) error {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		got = append(got, ev)
+		mu.Unlock()
+
+		started <- ev
+		<-release
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	})
+
+	q.Push(first)
+	<-started
+
+	// New pushes while the PR is in flight are held for the idle worker, and coalesced
+	if !q.Push(second) || !q.Push(third) {
+		t.Fatal("Push() should hold events for a PR in flight")
+	}
+	if q.Len() != 1 {
+		t.Errorf("Len() = %d, want the held event", q.Len())
+	}
+	select {
+	case ev := <-started:
+		t.Fatalf("%+v started while the PR was in flight", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release <- struct{}{}
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("held event was not processed once the first worker was done")
+	}
+	release <- struct{}{}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxActive != 1 {
+		t.Errorf("%d workers processed the PR at once, want 1", maxActive)
+	}
+	if want := []Event{first, third}; !reflect.DeepEqual(got, want) {
+		t.Errorf("processed = %+v, want %+v", got, want)
+	}
+}
+
+func TestQueueDefer(t *testing.T) {
+	q := NewQueue(1)
+	ev := Event{Kind: "check_suite.completed", Owner: "acme", Repo: "widgets", Number: 42}
+
+	if !q.Defer(ev, time.Millisecond, 1) {
+		t.Fatal("Defer() should accept an event deferred fewer than maxDeferrals times")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("Len() = %d, want the deferred event queued again", q.Len())
+	}
+
+	ev.Deferrals = 1
+	if q.Defer(ev, time.Millisecond, 1) {
+		t.Error("Defer() should give up after maxDeferrals")
+	}
+}